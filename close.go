@@ -0,0 +1,54 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"io"
+	"log"
+	"sync"
+)
+
+var (
+	closeErrorMu      sync.Mutex
+	closeErrorHandler func(name string, err error)
+)
+
+// SetCloseErrorHandler sets the function that Close passes errors to, for
+// example to collect them instead of logging them. Passing nil restores the
+// default, which logs errors with the standard log package.
+func SetCloseErrorHandler(h func(name string, err error)) {
+	closeErrorMu.Lock()
+	closeErrorHandler = h
+	closeErrorMu.Unlock()
+}
+
+// Close is called by the cleanup functions of generated injectors to close
+// a value built by a provider marked with a //wire:closes directive. name
+// identifies the provider. Close calls c.Close and passes any error to the
+// handler set by SetCloseErrorHandler.
+func Close(name string, c io.Closer) {
+	err := c.Close()
+	if err == nil {
+		return
+	}
+	closeErrorMu.Lock()
+	h := closeErrorHandler
+	closeErrorMu.Unlock()
+	if h == nil {
+		log.Printf("wire: closing value from provider %s: %v", name, err)
+		return
+	}
+	h(name, err)
+}
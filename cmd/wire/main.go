@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"reflect"
 	"sort"
 	"strconv"
@@ -44,6 +45,7 @@ func main() {
 	subcommands.Register(&checkCmd{}, "")
 	subcommands.Register(&diffCmd{}, "")
 	subcommands.Register(&genCmd{}, "")
+	subcommands.Register(&setDiffCmd{}, "")
 	subcommands.Register(&showCmd{}, "")
 	flag.Parse()
 
@@ -83,24 +85,123 @@ func packages(f *flag.FlagSet) []string {
 	return pkgs
 }
 
-// newGenerateOptions returns an initialized wire.GenerateOptions, possibly
-// with the Header option set.
-func newGenerateOptions(headerFile string) (*wire.GenerateOptions, error) {
-	opts := new(wire.GenerateOptions)
-	if headerFile != "" {
-		var err error
-		opts.Header, err = ioutil.ReadFile(headerFile)
+// parseImportAliases parses the value of the -import_aliases flag, a
+// comma-separated list of path=name pairs, into a map from import path to
+// name. It returns nil if s is empty.
+func parseImportAliases(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("-import_aliases: %q is not of the form path=name", pair)
+		}
+		path, name := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		aliases[path] = name
+	}
+	return aliases, nil
+}
+
+// readMainTemplate sets opts.MainTemplate to the contents of the file at
+// path, if path is not empty.
+func readMainTemplate(opts *wire.GenerateOptions, path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read main template %q: %v", path, err)
+	}
+	opts.MainTemplate = string(data)
+	return nil
+}
+
+// generateFlags holds the flags shared by the gen and diff commands, which
+// both generate code with the options they set.
+type generateFlags struct {
+	headerFile    string
+	tags          string
+	goVersion     string
+	cacheDir      string
+	strictExports bool
+	tests         bool
+	mainFunc      string
+	mainTemplate  string
+	wrapErrors    bool
+	structErrors  bool
+	instrument    bool
+	otelTrace     bool
+	groupImports  bool
+	oneFile       bool
+	nolint        string
+	inputsHash    bool
+	importAliases string
+	autoDeref     bool
+	emitMarkdown  bool
+}
+
+// register defines the flags in f.
+func (gf *generateFlags) register(f *flag.FlagSet) {
+	f.StringVar(&gf.headerFile, "header_file", "", "path to file to insert as a header in wire_gen.go")
+	f.StringVar(&gf.tags, "tags", "", "append build tags to the default wirebuild")
+	f.StringVar(&gf.goVersion, "go_version", "", "oldest Go version the generated code must compile with, e.g. go1.17 (defaults to the running toolchain)")
+	f.StringVar(&gf.cacheDir, "cache_dir", "", "directory in which to cache generated code for packages that have not changed")
+	f.BoolVar(&gf.strictExports, "strict_exports", false, "fail instead of warning when an exported provider set includes an unexported provider function")
+	f.BoolVar(&gf.tests, "tests", false, "also generate injectors declared in _test.go files")
+	f.StringVar(&gf.mainFunc, "main_func", "", "name of a function, usually main, to generate in package main that calls its injector")
+	f.StringVar(&gf.mainTemplate, "main_template", "", "path to a text/template file for the function named by -main_func")
+	f.BoolVar(&gf.wrapErrors, "wrap_errors", false, "wrap errors returned by providers with the provider's name")
+	f.BoolVar(&gf.structErrors, "structured_errors", false, "return errors from providers as *wire.ProviderError values that identify the provider")
+	f.BoolVar(&gf.instrument, "instrument_providers", false, "trace provider calls with wire.TraceProvider, which logs in builds with the wiredebug tag")
+	f.BoolVar(&gf.otelTrace, "otel_trace", false, "start an OpenTelemetry span around each provider call; injectors must take a context.Context")
+	f.BoolVar(&gf.groupImports, "group_imports", false, "write standard library imports in a separate group, as goimports does")
+	f.BoolVar(&gf.oneFile, "one_file_per_injector", false, "write each injector to its own wire_gen_<injector>.go file")
+	f.StringVar(&gf.nolint, "nolint", "", "comma-separated linters to suppress in generated files with a //nolint directive, or \"all\"")
+	f.BoolVar(&gf.inputsHash, "inputs_hash", false, "record a hash of the injector and provider signatures in a comment of each generated file")
+	f.BoolVar(&gf.autoDeref, "auto_deref", false, "take the address of a provided struct where a pointer to it is needed, and dereference a provided pointer where the struct is needed")
+	f.StringVar(&gf.importAliases, "import_aliases", "", "comma-separated path=name pairs giving preferred names for imports of generated files, e.g. example.com/api/pb=pb")
+	f.BoolVar(&gf.emitMarkdown, "emit_markdown", false, "also write a wire_graph.md file documenting the injectors, providers, and dependency graph of each package")
+}
+
+// apply sets the options in opts that the flags control.
+func (gf *generateFlags) apply(opts *wire.GenerateOptions) error {
+	if gf.headerFile != "" {
+		header, err := ioutil.ReadFile(gf.headerFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read header file %q: %v", headerFile, err)
+			return fmt.Errorf("failed to read header file %q: %v", gf.headerFile, err)
 		}
+		opts.Header = header
 	}
-	return opts, nil
+	opts.Tags = gf.tags
+	opts.GoVersion = gf.goVersion
+	opts.CacheDir = gf.cacheDir
+	opts.StrictExports = gf.strictExports
+	opts.Tests = gf.tests
+	opts.MainFunc = gf.mainFunc
+	opts.WrapErrors = gf.wrapErrors
+	opts.StructuredErrors = gf.structErrors
+	opts.InstrumentProviders = gf.instrument
+	opts.OtelTrace = gf.otelTrace
+	opts.GroupImports = gf.groupImports
+	opts.OneFilePerInjector = gf.oneFile
+	opts.Nolint = gf.nolint
+	opts.InputsHash = gf.inputsHash
+	opts.EmitMarkdown = gf.emitMarkdown
+	opts.AutoDeref = gf.autoDeref
+	aliases, err := parseImportAliases(gf.importAliases)
+	if err != nil {
+		return err
+	}
+	opts.ImportAliases = aliases
+	return readMainTemplate(opts, gf.mainTemplate)
 }
 
 type genCmd struct {
-	headerFile     string
+	generateFlags
 	prefixFileName string
-	tags           string
+	watch          bool
 }
 
 func (*genCmd) Name() string { return "gen" }
@@ -113,12 +214,22 @@ func (*genCmd) Usage() string {
   Given one or more packages, gen creates the wire_gen.go file for each.
 
   If no packages are listed, it defaults to ".".
+
+  With -watch, gen keeps running after the first generation and generates
+  the files again each time a Go file of the packages or of their
+  dependencies changes, until interrupted.
+
+  A wire.yaml file in a package's directory supplies default values for the
+  header_file, output_file_prefix, go_version, and nolint flags for that
+  package. One in the working directory supplies them for all packages, as
+  well as the tags and cache_dir flags; the package's own file takes
+  precedence.
 `
 }
 func (cmd *genCmd) SetFlags(f *flag.FlagSet) {
-	f.StringVar(&cmd.headerFile, "header_file", "", "path to file to insert as a header in wire_gen.go")
+	cmd.register(f)
 	f.StringVar(&cmd.prefixFileName, "output_file_prefix", "", "string to prepend to output file names.")
-	f.StringVar(&cmd.tags, "tags", "", "append build tags to the default wirebuild")
+	f.BoolVar(&cmd.watch, "watch", false, "keep running and generate again whenever a Go file of the packages or their dependencies changes")
 }
 
 func (cmd *genCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
@@ -127,24 +238,33 @@ func (cmd *genCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interfa
 		log.Println("failed to get working directory: ", err)
 		return subcommands.ExitFailure
 	}
-	opts, err := newGenerateOptions(cmd.headerFile)
-	if err != nil {
+	opts := new(wire.GenerateOptions)
+	if err := cmd.apply(opts); err != nil {
 		log.Println(err)
 		return subcommands.ExitFailure
 	}
-
 	opts.PrefixOutputFile = cmd.prefixFileName
-	opts.Tags = cmd.tags
+	opts.ConfigFiles = true
 
+	if cmd.watch {
+		return watch(ctx, wd, packages(f), opts)
+	}
 	outs, errs := wire.Generate(ctx, wd, os.Environ(), packages(f), opts)
 	if len(errs) > 0 {
 		logErrors(errs)
-		log.Println("generate failed")
+		log.Println(generateFailed(errs))
 		return subcommands.ExitFailure
 	}
-	if len(outs) == 0 {
-		return subcommands.ExitSuccess
+	if !writeResults(outs) {
+		log.Println("at least one generate failure")
+		return subcommands.ExitFailure
 	}
+	return subcommands.ExitSuccess
+}
+
+// writeResults writes the generated files of outs and logs their errors. It
+// reports whether every package was generated and written.
+func writeResults(outs []wire.GenerateResult) bool {
 	success := true
 	for _, out := range outs {
 		if len(out.Errs) > 0 {
@@ -163,16 +283,43 @@ func (cmd *genCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interfa
 			success = false
 		}
 	}
-	if !success {
-		log.Println("at least one generate failure")
+	return success
+}
+
+// watch runs wire.Watch for gen -watch, writing the generated files after
+// each generation, until the process is interrupted. Errors are logged and
+// do not stop watching.
+func watch(ctx context.Context, wd string, pkgs []string, opts *wire.GenerateOptions) subcommands.ExitStatus {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	err := wire.Watch(ctx, wd, os.Environ(), pkgs, opts, func(outs []wire.GenerateResult, errs []error) {
+		if len(errs) > 0 {
+			logErrors(errs)
+			log.Println(generateFailed(errs))
+		} else if !writeResults(outs) {
+			log.Println("at least one generate failure")
+		}
+		log.Println("watching for changes")
+	})
+	if err != nil && err != context.Canceled {
+		log.Println(err)
 		return subcommands.ExitFailure
 	}
 	return subcommands.ExitSuccess
 }
 
 type diffCmd struct {
-	headerFile string
-	tags       string
+	generateFlags
 }
 
 func (*diffCmd) Name() string { return "diff" }
@@ -189,11 +336,13 @@ func (*diffCmd) Usage() string {
 
   Similar to the diff command, it returns 0 if no diff, 1 if different, 2
   plus an error if trouble.
+
+  As with gen, wire.yaml files in the package directories and the working
+  directory supply default flag values.
 `
 }
 func (cmd *diffCmd) SetFlags(f *flag.FlagSet) {
-	f.StringVar(&cmd.headerFile, "header_file", "", "path to file to insert as a header in wire_gen.go")
-	f.StringVar(&cmd.tags, "tags", "", "append build tags to the default wirebuild")
+	cmd.register(f)
 }
 func (cmd *diffCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
 	const (
@@ -205,18 +354,17 @@ func (cmd *diffCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interf
 		log.Println("failed to get working directory: ", err)
 		return errReturn
 	}
-	opts, err := newGenerateOptions(cmd.headerFile)
-	if err != nil {
+	opts := new(wire.GenerateOptions)
+	if err := cmd.apply(opts); err != nil {
 		log.Println(err)
 		return subcommands.ExitFailure
 	}
-
-	opts.Tags = cmd.tags
+	opts.ConfigFiles = true
 
 	outs, errs := wire.Generate(ctx, wd, os.Environ(), packages(f), opts)
 	if len(errs) > 0 {
 		logErrors(errs)
-		log.Println("generate failed")
+		log.Println(generateFailed(errs))
 		return errReturn
 	}
 	if len(outs) == 0 {
@@ -364,7 +512,9 @@ func (*checkCmd) Usage() string {
 	return `check [-tags tag,list] [packages]
 
   Given one or more packages, check prints any type-checking or Wire errors
-  found with top-level variable provider sets or injector functions.
+  found with top-level variable provider sets or injector functions. All
+  errors are reported at once; an error shared by several injectors is
+  printed only once.
 
   If no packages are listed, it defaults to ".".
 `
@@ -378,12 +528,60 @@ func (cmd *checkCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...inter
 		log.Println("failed to get working directory: ", err)
 		return subcommands.ExitFailure
 	}
-	_, errs := wire.Load(ctx, wd, os.Environ(), cmd.tags, packages(f))
+	errs := wire.ValidateAll(ctx, wd, os.Environ(), cmd.tags, packages(f))
+	if len(errs) > 0 {
+		logErrors(errs)
+		log.Println("check failed")
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+type setDiffCmd struct {
+	tags string
+}
+
+func (*setDiffCmd) Name() string { return "setdiff" }
+func (*setDiffCmd) Synopsis() string {
+	return "show how a provider set changed between two packages"
+}
+func (*setDiffCmd) Usage() string {
+	return `setdiff [-tags tag,list] old_package new_package set_name
+
+  Given two packages holding versions of the same code, setdiff prints the
+  providers added to, removed from, or changed in the provider set variable
+  set_name between the old and the new package.
+`
+}
+func (cmd *setDiffCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.tags, "tags", "", "append build tags to the default wirebuild")
+}
+func (cmd *setDiffCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 3 {
+		log.Println("setdiff requires an old package, a new package, and a set name")
+		return subcommands.ExitUsageError
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Println("failed to get working directory: ", err)
+		return subcommands.ExitFailure
+	}
+	diff, errs := wire.DiffProviderSets(ctx, wd, os.Environ(), cmd.tags, f.Arg(0), f.Arg(1), f.Arg(2))
 	if len(errs) > 0 {
 		logErrors(errs)
 		log.Println("error loading packages")
 		return subcommands.ExitFailure
 	}
+	for _, p := range diff.Removed {
+		fmt.Printf("- %s %s\n", p.Name, p.Signature)
+	}
+	for _, p := range diff.Added {
+		fmt.Printf("+ %s %s\n", p.Name, p.Signature)
+	}
+	for _, c := range diff.Changed {
+		fmt.Printf("~ %s %s\n", c.Old.Name, c.Old.Signature)
+		fmt.Printf("  %s %s\n", strings.Repeat(" ", len(c.Old.Name)), c.New.Signature)
+	}
 	return subcommands.ExitSuccess
 }
 
@@ -602,6 +800,26 @@ func formatProviderSetName(importPath, varName string) string {
 	return strconv.Quote(importPath) + "." + varName
 }
 
+// generateFailed returns the message that ends the output of a failed
+// generation, telling whether the packages failed to compile or Wire failed
+// to resolve their providers.
+func generateFailed(errs []error) string {
+	for _, err := range errs {
+		// Follow Unwrap methods by hand, since errors.As needs go1.13.
+		for err != nil {
+			if _, ok := err.(*wire.PackageError); ok {
+				return "generate failed: packages do not compile; fix the errors above and run wire again"
+			}
+			u, ok := err.(interface{ Unwrap() error })
+			if !ok {
+				break
+			}
+			err = u.Unwrap()
+		}
+	}
+	return "generate failed"
+}
+
 func logErrors(errs []error) {
 	for _, err := range errs {
 		log.Println(strings.Replace(err.Error(), "\n", "\n\t", -1))
@@ -0,0 +1,130 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ConfigString is called by generated injectors to fill a string struct
+// field tagged `wire:"key=..."` from the config map provided to the
+// injector. It returns an error if key is not set or its value is not a
+// string.
+func ConfigString(config map[string]interface{}, key string) (string, error) {
+	v, err := configValue(config, key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", configTypeError(key, v, "string")
+	}
+	return s, nil
+}
+
+// ConfigInt is called by generated injectors to fill an int struct field
+// tagged `wire:"key=..."` from the config map provided to the injector.
+// Besides ints, it accepts values of the other integer types, whole
+// float64 values as decoded from JSON, and strings that parse as integers.
+// It returns an error if key is not set or its value cannot be converted.
+func ConfigInt(config map[string]interface{}, key string) (int, error) {
+	v, err := configValue(config, key)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("wire: config key %q: %d overflows int", key, v)
+		}
+		n = int64(v)
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, fmt.Errorf("wire: config key %q: %d overflows int", key, v)
+		}
+		n = int64(v)
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("wire: config key %q: %v is not an integer", key, v)
+		}
+		n = int64(v)
+	case string:
+		i, err := strconv.ParseInt(v, 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("wire: config key %q: %v", key, err)
+		}
+		return int(i), nil
+	default:
+		return 0, configTypeError(key, v, "int")
+	}
+	if int64(int(n)) != n {
+		return 0, fmt.Errorf("wire: config key %q: %d overflows int", key, n)
+	}
+	return int(n), nil
+}
+
+// ConfigBool is called by generated injectors to fill a bool struct field
+// tagged `wire:"key=..."` from the config map provided to the injector.
+// Besides bools, it accepts strings that strconv.ParseBool accepts. It
+// returns an error if key is not set or its value cannot be converted.
+func ConfigBool(config map[string]interface{}, key string) (bool, error) {
+	v, err := configValue(config, key)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("wire: config key %q: %v", key, err)
+		}
+		return b, nil
+	default:
+		return false, configTypeError(key, v, "bool")
+	}
+}
+
+func configValue(config map[string]interface{}, key string) (interface{}, error) {
+	v, ok := config[key]
+	if !ok {
+		return nil, fmt.Errorf("wire: config key %q is not set", key)
+	}
+	return v, nil
+}
+
+func configTypeError(key string, v interface{}, want string) error {
+	return fmt.Errorf("wire: config key %q: cannot use %T value as %s", key, v, want)
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+// A ProviderError is returned by injectors generated with structured errors
+// when a provider fails. It identifies the provider, so that callers can
+// inspect it with errors.As instead of parsing the error message.
+type ProviderError struct {
+	// Provider is the name of the provider function, or of the struct type
+	// for a struct provider.
+	Provider string
+	// Package is the import path of the provider's package.
+	Package string
+	// Cause is the error the provider returned.
+	Cause error
+}
+
+// Error returns the provider's error prefixed with the provider's name.
+func (e *ProviderError) Error() string {
+	return "provider " + e.Package + "." + e.Provider + ": " + e.Cause.Error()
+}
+
+// Unwrap returns e.Cause.
+func (e *ProviderError) Unwrap() error {
+	return e.Cause
+}
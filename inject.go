@@ -0,0 +1,126 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+)
+
+// Inject calls injectorFn, an injector function that takes no arguments,
+// and returns its first result. It is meant for development builds, to
+// catch wiring mistakes that the generated code compiles with, such as a
+// provider that returns a typed nil.
+//
+// Inject panics if the injector returns an error, or if its result is the
+// zero value of its type: a nil pointer, map, slice, channel or function, a
+// nil interface or an interface holding such a nil value, or any other zero
+// value of a type with a non-zero size. Injectors that return a cleanup
+// function can't be called through Inject, since it would be lost.
+func Inject(injectorFn interface{}) interface{} {
+	fn := reflect.ValueOf(injectorFn)
+	ft := fn.Type()
+	if fn.Kind() != reflect.Func || ft.NumIn() > 0 || ft.NumOut() == 0 {
+		panic(fmt.Sprintf("wire.Inject: %v is not an injector function with no parameters", ft))
+	}
+	name := runtime.FuncForPC(fn.Pointer()).Name()
+	// Check the signature before calling the injector, so that one whose
+	// cleanup would be lost doesn't build its dependencies first.
+	nout := ft.NumOut()
+	hasErr := nout > 1 && ft.Out(nout-1) == errorType
+	if hasErr {
+		nout--
+	}
+	if nout > 1 {
+		panic(fmt.Sprintf("wire.Inject: %s returns a cleanup function, which Inject would lose", name))
+	}
+	results := fn.Call(nil)
+	if hasErr {
+		if err := results[1]; !err.IsNil() {
+			panic(fmt.Sprintf("wire.Inject: %s failed: %v", name, err.Interface()))
+		}
+	}
+	if msg := zeroResult(results[0]); msg != "" {
+		panic(fmt.Sprintf("wire.Inject: %s returned %s", name, msg))
+	}
+	return results[0].Interface()
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// zeroResult describes v if it is a zero value that Inject rejects, or
+// returns the empty string.
+func zeroResult(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if v.IsNil() {
+			return "nil " + v.Type().String()
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil " + v.Type().String()
+		}
+		if msg := zeroResult(v.Elem()); msg != "" {
+			return v.Type().String() + " holding " + msg
+		}
+	default:
+		if v.Type().Size() > 0 && isZero(v) {
+			return "zero " + v.Type().String()
+		}
+	}
+	return ""
+}
+
+// isZero reports whether v is the zero value of its type, like
+// reflect.Value.IsZero, which isn't available in every Go version Wire
+// supports.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return math.Float64bits(v.Float()) == 0
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return math.Float64bits(real(c)) == 0 && math.Float64bits(imag(c)) == 0
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.UnsafePointer:
+		return v.Pointer() == 0
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return v.IsNil()
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isZero(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isZero(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
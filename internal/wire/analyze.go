@@ -18,10 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"sort"
-	"strings"
 
 	"golang.org/x/tools/go/types/typeutil"
 )
@@ -33,6 +33,11 @@ const (
 	structProvider
 	valueExpr
 	selectorExpr
+	typeAssertExpr
+	groupSlice
+	interfaceSelect
+	implementStruct
+	derefExpr
 )
 
 // A call represents a step of an injector function.  It may be either a
@@ -48,10 +53,19 @@ type call struct {
 	// pkg and name identify one of the following:
 	// 1) the provider to call for kind == funcProviderCall;
 	// 2) the type to construct for kind == structProvider;
-	// 3) the name to select for kind == selectorExpr.
+	// 3) the name to select for kind == selectorExpr;
+	// 4) the wire.As call for kind == typeAssertExpr;
+	// 5) the selector to call for kind == interfaceSelect;
+	// 6) the wire.Implement call for kind == implementStruct;
+	// 7) the struct type for kind == derefExpr.
+	// They are not set for kind == groupSlice.
 	pkg  *types.Package
 	name string
 
+	// typeArgs is the list of type arguments of a generic provider function
+	// for kind == funcProviderCall, or nil.
+	typeArgs []types.Type
+
 	// args is a list of arguments to call the provider with. Each element is:
 	// a) one of the givens (args[i] < len(given)),
 	// b) the result of a previous provider call (args[i] >= len(given))
@@ -69,25 +83,92 @@ type call struct {
 	// This will only be set if kind == structProvider.
 	fieldNames []string
 
+	// configKeys and fieldTypes are parallel to fieldNames. A non-empty
+	// config key means the field is read from the config map argument
+	// under that key, and converted to the field's type.
+	// These will only be set if kind == structProvider.
+	configKeys []string
+	fieldTypes []types.Type
+
 	// ins is the list of types this call receives as arguments.
 	// This will be nil for kind == valueExpr.
 	ins []types.Type
 
-	// The following are only set for kind == funcProviderCall:
+	// The following are only set for kind == funcProviderCall, except that
+	// hasErr is always true for kind == typeAssertExpr and kind ==
+	// interfaceSelect:
 
 	// hasCleanup is true if the provider call returns a cleanup function.
 	hasCleanup bool
+	// closes is true if the provider is marked //wire:closes, so the
+	// cleanup function closes the provider's output.
+	closes bool
 	// hasErr is true if the provider call returns an error.
 	hasErr bool
+	// singleton is true if the provider is marked //wire:singleton.
+	singleton bool
+	// lazy is true if the provider is wrapped in wire.Lazy. out is then the
+	// accessor function type, while hasCleanup and hasErr describe the
+	// provider itself.
+	lazy bool
+	// noValue is true if the provider is passed to wire.NoValue. The call
+	// is made only for its side effects and produces no local.
+	noValue bool
+	// eager is true if the provider is marked //wire:eager. The call is
+	// always made, even if nothing uses its result.
+	eager bool
+	// wrapper is true if the call applies a function passed to wire.Wrap
+	// to a value of out produced by an earlier call.
+	wrapper bool
+	// resultName is the provider's name for its first result, if any.
+	resultName string
+	// selectErr is true if the selector called for kind == interfaceSelect
+	// returns an error.
+	selectErr bool
+	// groupOut is the type the provider returns if it is a member of a
+	// wire.Group. out is then a placeholder for the member.
+	groupOut types.Type
+	// instrument is the wrapper function passed to wire.Copy through which
+	// the provider is called, or nil. hasErr is then always true, since the
+	// wrapper may fail even if the provider cannot, and fnErr is true if
+	// the provider function itself returns an error.
+	instrument *types.Func
+	fnErr      bool
+	// constraint is the //wire:build expression of the provider, or empty.
+	constraint string
 
 	// The following are only set for kind == valueExpr:
 
 	valueExpr     ast.Expr
 	valueTypeInfo *types.Info
+	// valueVar is true if valueExpr is a package-level variable to read
+	// directly, as for wire.EmbedValue.
+	valueVar bool
+	// valueCall is true if valueExpr calls a function, and is evaluated
+	// each time the injector is called.
+	valueCall bool
+	// valueConst is the value of valueExpr if it is a constant expression,
+	// which injectors write as a literal instead of evaluating valueExpr.
+	valueConst constant.Value
 
 	// The following are only set for kind == selectorExpr:
 
 	ptrToField bool
+
+	// The following are only set for kind == implementStruct:
+
+	// implement holds the methods of the interface out, each forwarding
+	// to one of args.
+	implement []ImplementMethod
+}
+
+// valueType returns the type of the value the call produces. It differs
+// from out only for members of a wire.Group.
+func (c *call) valueType() types.Type {
+	if c.groupOut != nil {
+		return c.groupOut
+	}
+	return c.out
 }
 
 // solve finds the sequence of calls required to produce an output type
@@ -113,34 +194,164 @@ func solve(fset *token.FileSet, out types.Type, given *types.Tuple, set *Provide
 		t    types.Type
 		from types.Type
 		up   *frame
+		// For a type wrapped with wire.Wrap, base is true while the
+		// unwrapped value is built, and wrap is true while the wrappers
+		// are applied to it.
+		base bool
+		wrap bool
+	}
+	// wrapping maps each type whose wrappers are being applied to the
+	// index of its unwrapped value.
+	wrapping := new(typeutil.Map)
+	var stk []frame
+	if out != nil {
+		stk = append(stk, frame{t: out})
+	}
+	// Providers passed to wire.NoValue or marked //wire:eager are called
+	// for their side effects, even if nothing else depends on them. Visit
+	// them before out, in a stable order. An injector that calls wire.Use
+	// has no output, and calls every provider of set that way.
+	roots := sideEffectOutputs(set)
+	if out == nil {
+		roots = providerOutputs(set)
+	}
+	for i := len(roots) - 1; i >= 0; i-- {
+		stk = append(stk, frame{t: roots[i]})
 	}
-	stk := []frame{{t: out}}
 dfs:
 	for len(stk) > 0 {
 		curr := stk[len(stk)-1]
 		stk = stk[:len(stk)-1]
+		// Calls produce the types that aliases denote, so that generated
+		// names and graph nodes don't depend on how a type was spelled.
+		curr.t = normalizeAliases(curr.t)
+		w := set.wrapFor(curr.t)
+		if curr.wrap {
+			if wrapping.At(curr.t) == nil {
+				base := index.At(curr.t)
+				if base == errAbort {
+					continue
+				}
+				// The unwrapped value is built. Hide it while the other
+				// arguments of the wrappers are visited, so that any of
+				// them that depends on the wrapped value is reported as a
+				// cycle, then come back to apply the wrappers.
+				index.Delete(curr.t)
+				wrapping.Set(curr.t, base)
+				stk = append(stk, curr)
+				for i := len(w.Funcs) - 1; i >= 0; i-- {
+					p := w.Funcs[i]
+					for j := len(p.Args) - 1; j >= 0; j-- {
+						if t := p.Args[j].Type; !types.Identical(t, curr.t) && index.At(t) == nil {
+							stk = append(stk, frame{t: t, from: curr.t, up: &curr})
+						}
+					}
+				}
+				continue
+			}
+			prev := wrapping.At(curr.t).(int)
+			wrapping.Delete(curr.t)
+			if index.At(curr.t) == errAbort {
+				// A cycle was reported.
+				continue
+			}
+			used = append(used, set.wrapSrcMap.At(curr.t).(*providerSetSrc))
+			for _, p := range w.Funcs {
+				wrapped := wrappedArg(p, curr.t)
+				args := make([]int, len(p.Args))
+				ins := make([]types.Type, len(p.Args))
+				for i := range p.Args {
+					ins[i] = normalizeAliases(p.Args[i].Type)
+					if i == wrapped {
+						args[i] = prev
+						continue
+					}
+					v := index.At(ins[i])
+					if v == errAbort {
+						index.Set(curr.t, errAbort)
+						continue dfs
+					}
+					args[i] = v.(int)
+					if err := verifyArgType(argType(given, calls, args[i]), ins[i]); err != nil {
+						ec.add(notePosition(fset.Position(p.Pos), fmt.Errorf("wrapper %s: %v", p.Name, err)))
+						index.Set(curr.t, errAbort)
+						continue dfs
+					}
+				}
+				prev = given.Len() + len(calls)
+				calls = append(calls, call{
+					kind:       funcProviderCall,
+					pkg:        p.Pkg,
+					name:       p.Name,
+					args:       args,
+					varargs:    p.Varargs,
+					ins:        ins,
+					out:        curr.t,
+					hasCleanup: p.HasCleanup,
+					closes:     p.Closes,
+					hasErr:     p.HasErr,
+					resultName: p.ResultName,
+					wrapper:    true,
+					constraint: p.Constraint,
+				})
+			}
+			index.Set(curr.t, prev)
+			continue
+		}
 		if index.At(curr.t) != nil {
 			continue
 		}
+		if w != nil && wrapping.At(curr.t) != nil {
+			ec.add(notePosition(fset.Position(w.Pos), fmt.Errorf("cycle for %s: %s depends on it, but is needed to wrap it", types.TypeString(curr.t, nil), types.TypeString(curr.from, nil))))
+			index.Set(curr.t, errAbort)
+			continue
+		}
+		if w != nil && !curr.base {
+			// Build the unwrapped value first, then wrap it.
+			stk = append(stk,
+				frame{t: curr.t, from: curr.from, up: curr.up, wrap: true},
+				frame{t: curr.t, from: curr.from, up: curr.up, base: true})
+			continue
+		}
 
 		pv := set.For(curr.t)
 		if pv.IsNil() {
-			if curr.from == nil {
-				ec.add(fmt.Errorf("no provider found for %s, output of injector", types.TypeString(curr.t, nil)))
-				index.Set(curr.t, errAbort)
-				continue
+			missing := &MissingProviderError{Type: types.TypeString(curr.t, nil)}
+			if set.strategyMap != nil {
+				alts, _ := set.strategyMap.At(curr.t).([]*strategyProvider)
+				for _, alt := range alts {
+					missing.Strategies = append(missing.Strategies, alt.p.Strategy)
+				}
 			}
-			sb := new(strings.Builder)
-			fmt.Fprintf(sb, "no provider found for %s", types.TypeString(curr.t, nil))
-			for f := curr.up; f != nil; f = f.up {
-				fmt.Fprintf(sb, "\nneeded by %s in %s", types.TypeString(f.t, nil), set.srcMap.At(f.t).(*providerSetSrc).description(fset, f.t))
+			if set.ambiguousMap != nil {
+				froms, _ := set.ambiguousMap.At(curr.t).([]types.Type)
+				for _, from := range froms {
+					missing.Assignable = append(missing.Assignable, types.TypeString(from, nil))
+				}
+			}
+			if curr.from != nil {
+				for f := curr.up; f != nil; f = f.up {
+					missing.NeededBy = append(missing.NeededBy, DependencyStep{
+						Type:   types.TypeString(f.t, nil),
+						Source: set.srcMap.At(f.t).(*providerSetSrc).description(fset, f.t),
+					})
+				}
 			}
-			ec.add(errors.New(sb.String()))
+			ec.add(missing)
 			index.Set(curr.t, errAbort)
 			continue
 		}
 		src := set.srcMap.At(curr.t).(*providerSetSrc)
 		used = append(used, src)
+		if src.binding(curr.t) != nil {
+			// Bindings identical to the one kept for the type are used
+			// along with it.
+			for _, b := range set.Bindings {
+				if b != src.Binding && types.Identical(b.Iface, curr.t) {
+					used = append(used, &providerSetSrc{Binding: b})
+				}
+			}
+		}
 		if concrete := pv.Type(); !types.Identical(concrete, curr.t) {
 			// Interface binding does not create a call.
 			i := index.At(concrete)
@@ -178,34 +389,85 @@ dfs:
 			args := make([]int, len(p.Args))
 			ins := make([]types.Type, len(p.Args))
 			for i := range p.Args {
-				ins[i] = p.Args[i].Type
-				v := index.At(p.Args[i].Type)
+				ins[i] = normalizeAliases(p.Args[i].Type)
+				v := index.At(ins[i])
 				if v == errAbort {
 					index.Set(curr.t, errAbort)
 					continue dfs
 				}
 				args[i] = v.(int)
+				if p.IsGroup {
+					// The arguments are the members' placeholder types, and
+					// processGroup checked that their outputs fit the slice.
+					continue
+				}
+				if err := verifyArgType(argType(given, calls, args[i]), ins[i]); err != nil {
+					ec.add(notePosition(fset.Position(p.Pos), fmt.Errorf("provider %s: %v", p.Name, err)))
+					index.Set(curr.t, errAbort)
+					continue dfs
+				}
+			}
+			if p.Singleton {
+				// Singletons outlive any one injector call, so they may only
+				// depend on other singletons.
+				for i, a := range args {
+					if a < given.Len() || !calls[a-given.Len()].singleton {
+						ec.add(notePosition(fset.Position(p.Pos), fmt.Errorf("singleton provider %s depends on %s, which is not provided by a singleton", p.Name, types.TypeString(ins[i], nil))))
+						index.Set(curr.t, errAbort)
+						continue dfs
+					}
+				}
 			}
 			index.Set(curr.t, given.Len()+len(calls))
 			kind := funcProviderCall
 			fieldNames := []string(nil)
-			if p.IsStruct {
+			var configKeys []string
+			var fieldTypes []types.Type
+			switch {
+			case p.IsStruct:
 				kind = structProvider
 				for _, arg := range p.Args {
 					fieldNames = append(fieldNames, arg.FieldName)
+					configKeys = append(configKeys, arg.ConfigKey)
+					fieldTypes = append(fieldTypes, arg.FieldType)
 				}
+			case p.TypeAssert:
+				kind = typeAssertExpr
+			case p.Select:
+				kind = interfaceSelect
+			case p.IsGroup:
+				kind = groupSlice
+			case p.Implement != nil:
+				kind = implementStruct
+			case p.Deref:
+				kind = derefExpr
 			}
 			calls = append(calls, call{
 				kind:       kind,
 				pkg:        p.Pkg,
 				name:       p.Name,
+				typeArgs:   p.TypeArgs,
 				args:       args,
 				varargs:    p.Varargs,
 				fieldNames: fieldNames,
+				configKeys: configKeys,
+				fieldTypes: fieldTypes,
 				ins:        ins,
 				out:        curr.t,
 				hasCleanup: p.HasCleanup,
-				hasErr:     p.HasErr,
+				closes:     p.Closes,
+				hasErr:     p.HasErr || p.Instrument != nil,
+				singleton:  p.Singleton,
+				lazy:       p.Lazy,
+				noValue:    p.NoValue,
+				eager:      p.Eager,
+				selectErr:  p.SelectErr,
+				resultName: p.ResultName,
+				groupOut:   p.GroupOut,
+				instrument: p.Instrument,
+				fnErr:      p.HasErr,
+				constraint: p.Constraint,
+				implement:  p.Implement,
 			})
 		case pv.IsValue():
 			v := pv.Value()
@@ -215,6 +477,9 @@ dfs:
 				out:           curr.t,
 				valueExpr:     v.expr,
 				valueTypeInfo: v.info,
+				valueVar:      v.isVar,
+				valueCall:     v.isCall,
+				valueConst:    v.constant,
 			})
 		case pv.IsField():
 			f := pv.Field()
@@ -250,12 +515,145 @@ dfs:
 	if len(ec.errors) > 0 {
 		return nil, ec.errors
 	}
+	if out != nil {
+		if err := verifyArgType(argType(given, calls, index.At(out).(int)), out); err != nil {
+			return nil, []error{fmt.Errorf("injector output: %v", err)}
+		}
+	}
 	if errs := verifyArgsUsed(set, used); len(errs) > 0 {
 		return nil, errs
 	}
 	return calls, nil
 }
 
+// addressedInterface returns I if out is a pointer to an interface type I
+// that set provides, while set does not provide out itself. An injector
+// returning out then builds the interface value and returns its address.
+// addressedInterface returns nil otherwise, including when set provides the
+// pointer type directly.
+func addressedInterface(set *ProviderSet, out types.Type) types.Type {
+	ptr, ok := out.(*types.Pointer)
+	if !ok || !types.IsInterface(ptr.Elem()) || !set.For(out).IsNil() || set.For(ptr.Elem()).IsNil() {
+		return nil
+	}
+	return ptr.Elem()
+}
+
+// normalizeAliases returns t with the aliases it refers to replaced by the
+// types they denote, looking through pointer, slice, array, map, and channel
+// types. Other types are returned as they are: go/types considers them
+// identical to their unaliased forms, so providers match regardless, and
+// only the spelling of the type differs.
+func normalizeAliases(t types.Type) types.Type {
+	switch u := unalias(t).(type) {
+	case *types.Pointer:
+		if elem := normalizeAliases(u.Elem()); elem != u.Elem() {
+			return types.NewPointer(elem)
+		}
+		return u
+	case *types.Slice:
+		if elem := normalizeAliases(u.Elem()); elem != u.Elem() {
+			return types.NewSlice(elem)
+		}
+		return u
+	case *types.Array:
+		if elem := normalizeAliases(u.Elem()); elem != u.Elem() {
+			return types.NewArray(elem, u.Len())
+		}
+		return u
+	case *types.Map:
+		key, elem := normalizeAliases(u.Key()), normalizeAliases(u.Elem())
+		if key != u.Key() || elem != u.Elem() {
+			return types.NewMap(key, elem)
+		}
+		return u
+	case *types.Chan:
+		if elem := normalizeAliases(u.Elem()); elem != u.Elem() {
+			return types.NewChan(u.Dir(), elem)
+		}
+		return u
+	default:
+		return u
+	}
+}
+
+// argType returns the type of the value at index i, using the same
+// numbering as call.args.
+func argType(given *types.Tuple, calls []call, i int) types.Type {
+	if i < given.Len() {
+		return given.At(i).Type()
+	}
+	return calls[i-given.Len()].valueType()
+}
+
+// verifyArgType checks that a value of type from can be used where type to is
+// needed, either directly or through an explicit conversion.
+func verifyArgType(from, to types.Type) error {
+	if types.AssignableTo(from, to) || types.ConvertibleTo(from, to) {
+		return nil
+	}
+	return fmt.Errorf("%s cannot be assigned or converted to %s", types.TypeString(from, nil), types.TypeString(to, nil))
+}
+
+// sideEffectOutputs returns the output types of the providers in set that
+// injectors always call: those passed to wire.NoValue, whose outputs are
+// placeholders, and those marked //wire:eager.
+func sideEffectOutputs(set *ProviderSet) []types.Type {
+	var outs []types.Type
+	for _, t := range sortedTypes(set.providerMap) {
+		if pv := set.For(t); pv.IsProvider() && (pv.Provider().NoValue || pv.Provider().Eager) {
+			outs = append(outs, t)
+		}
+	}
+	return outs
+}
+
+// providerOutputs returns the output types of the provider functions and
+// structs in set, which an injector that calls wire.Use calls in this order,
+// each after its dependencies.
+func providerOutputs(set *ProviderSet) []types.Type {
+	var outs []types.Type
+	for _, t := range sortedTypes(set.providerMap) {
+		pv := set.For(t)
+		if !pv.IsProvider() {
+			continue
+		}
+		for _, o := range pv.Provider().Out {
+			if types.Identical(o, t) {
+				outs = append(outs, t)
+				break
+			}
+		}
+	}
+	return outs
+}
+
+// verifyAmbient checks the injector parameters named by a //wire:ambient
+// directive. An ambient parameter reaches every provider that takes its type
+// unchanged, so set may not wrap its type and it may not be the injector's
+// result.
+func verifyAmbient(names []string, ins *types.Tuple, result types.Type, set *ProviderSet) []error {
+	ec := new(errorCollector)
+	for _, name := range names {
+		var param *types.Var
+		for i := 0; i < ins.Len(); i++ {
+			if ins.At(i).Name() == name {
+				param = ins.At(i)
+				break
+			}
+		}
+		switch {
+		case param == nil:
+			ec.add(fmt.Errorf("//wire:ambient names %s, which is not a parameter of the injector", name))
+		case set.wrapFor(param.Type()) != nil:
+			ec.add(fmt.Errorf("ambient parameter %s of type %s may not be wrapped by wire.Wrap", name, types.TypeString(param.Type(), nil)))
+		case types.Identical(param.Type(), result):
+			ec.add(fmt.Errorf("ambient parameter %s may not be the injector's result", name))
+		}
+	}
+	return ec.errors
+}
+
 // verifyArgsUsed ensures that all of the arguments in set were used during solve.
 func verifyArgsUsed(set *ProviderSet, used []*providerSetSrc) []error {
 	var errs []error
@@ -276,6 +674,10 @@ func verifyArgsUsed(set *ProviderSet, used []*providerSetSrc) []error {
 		}
 	}
 	for _, p := range set.Providers {
+		if p.Strategy != "" {
+			// Alternatives the injector does not select are not used.
+			continue
+		}
 		found := false
 		for _, u := range used {
 			if u.Provider == p {
@@ -323,6 +725,18 @@ func verifyArgsUsed(set *ProviderSet, used []*providerSetSrc) []error {
 			errs = append(errs, fmt.Errorf("unused field %q.%s", f.Parent, f.Name))
 		}
 	}
+	for _, w := range set.Wraps {
+		found := false
+		for _, u := range used {
+			if u.Wrap == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("unused wire.Wrap of %s", types.TypeString(w.Type, nil)))
+		}
+	}
 	return errs
 }
 
@@ -352,26 +766,48 @@ func buildProviderMap(fset *token.FileSet, hasher typeutil.Hasher, set *Provider
 		}
 	}
 	// Process imports, verifying that there are no conflicts between sets.
-	for _, imp := range set.Imports {
+	for i, imp := range set.Imports {
 		src := &providerSetSrc{Import: imp}
-		imp.providerMap.Iterate(func(k types.Type, v interface{}) {
-			if prevSrc := srcMap.At(k); prevSrc != nil {
-				ec.add(bindingConflictError(fset, k, set, src, prevSrc.(*providerSetSrc)))
-				return
+		// The last import of a set created for wire.Override replaces
+		// entries of the others instead of conflicting with them.
+		overrides := set.overrides && i == len(set.Imports)-1
+		// Visit imported types in a stable order so that conflict errors
+		// are reported consistently.
+		for _, k := range sortedTypes(imp.providerMap) {
+			if pt := imp.providerMap.At(k).(*ProvidedType); pt.IsProvider() && (pt.Provider().IsGroup || isGroupMemberOutput(k, pt)) {
+				// Groups and the outputs of their members are collected
+				// again below, including members from the other sets.
+				continue
 			}
-			providerMap.Set(k, v)
+			if prevSrc := srcMap.At(k); prevSrc != nil && !overrides {
+				if !sameBinding(k, src, prevSrc.(*providerSetSrc)) {
+					ec.add(bindingConflictError(fset, k, set, src, prevSrc.(*providerSetSrc)))
+				}
+				continue
+			}
+			providerMap.Set(k, imp.providerMap.At(k))
 			srcMap.Set(k, src)
-		})
+		}
 	}
 	if len(ec.errors) > 0 {
 		return nil, nil, ec.errors
 	}
 
-	// Process non-binding providers in new set.
+	// The entries of a set created by wire.Decorate replace those of the
+	// base set instead of conflicting with them.
+	decorates := func(prevSrc interface{}) bool {
+		return set.decorate && prevSrc.(*providerSetSrc).Import != nil
+	}
+
+	// Process non-binding providers in new set. Providers marked
+	// //wire:strategy are alternatives, collected by buildStrategyMap.
 	for _, p := range set.Providers {
+		if p.Strategy != "" {
+			continue
+		}
 		src := &providerSetSrc{Provider: p}
 		for _, typ := range p.Out {
-			if prevSrc := srcMap.At(typ); prevSrc != nil {
+			if prevSrc := srcMap.At(typ); prevSrc != nil && !decorates(prevSrc) {
 				ec.add(bindingConflictError(fset, typ, set, src, prevSrc.(*providerSetSrc)))
 				continue
 			}
@@ -381,7 +817,7 @@ func buildProviderMap(fset *token.FileSet, hasher typeutil.Hasher, set *Provider
 	}
 	for _, v := range set.Values {
 		src := &providerSetSrc{Value: v}
-		if prevSrc := srcMap.At(v.Out); prevSrc != nil {
+		if prevSrc := srcMap.At(v.Out); prevSrc != nil && !decorates(prevSrc) {
 			ec.add(bindingConflictError(fset, v.Out, set, src, prevSrc.(*providerSetSrc)))
 			continue
 		}
@@ -391,7 +827,7 @@ func buildProviderMap(fset *token.FileSet, hasher typeutil.Hasher, set *Provider
 	for _, f := range set.Fields {
 		src := &providerSetSrc{Field: f}
 		for _, typ := range f.Out {
-			if prevSrc := srcMap.At(typ); prevSrc != nil {
+			if prevSrc := srcMap.At(typ); prevSrc != nil && !decorates(prevSrc) {
 				ec.add(bindingConflictError(fset, typ, set, src, prevSrc.(*providerSetSrc)))
 				continue
 			}
@@ -403,12 +839,51 @@ func buildProviderMap(fset *token.FileSet, hasher typeutil.Hasher, set *Provider
 		return nil, nil, ec.errors
 	}
 
+	for _, g := range groupProviders(fset, providerMap) {
+		typ := g.Out[0]
+		src := &providerSetSrc{Provider: g}
+		if prevSrc := srcMap.At(typ); prevSrc != nil {
+			ec.add(bindingConflictError(fset, typ, set, src, prevSrc.(*providerSetSrc)))
+			continue
+		}
+		providerMap.Set(typ, &ProvidedType{t: typ, p: g})
+		srcMap.Set(typ, src)
+	}
+	if len(ec.errors) > 0 {
+		return nil, nil, ec.errors
+	}
+
+	// Each group member also provides its output on its own, from the same
+	// call as its element of the group, unless another entry provides the
+	// type or other members produce it too.
+	memberOuts := new(typeutil.Map) // to []types.Type of placeholders
+	for _, t := range sortedTypes(providerMap) {
+		pt := providerMap.At(t).(*ProvidedType)
+		if pt.IsProvider() && pt.Provider().Group != nil && types.Identical(t, pt.Type()) {
+			out := pt.Provider().GroupOut
+			placeholders, _ := memberOuts.At(out).([]types.Type)
+			memberOuts.Set(out, append(placeholders, t))
+		}
+	}
+	for _, out := range sortedTypes(memberOuts) {
+		placeholders := memberOuts.At(out).([]types.Type)
+		if len(placeholders) > 1 || srcMap.At(out) != nil {
+			continue
+		}
+		providerMap.Set(out, providerMap.At(placeholders[0]))
+		srcMap.Set(out, srcMap.At(placeholders[0]))
+	}
+
 	// Process bindings in set. Must happen after the other providers to
 	// ensure the concrete type is being provided.
 	for _, b := range set.Bindings {
 		src := &providerSetSrc{Binding: b}
-		if prevSrc := srcMap.At(b.Iface); prevSrc != nil {
-			ec.add(bindingConflictError(fset, b.Iface, set, src, prevSrc.(*providerSetSrc)))
+		if prevSrc := srcMap.At(b.Iface); prevSrc != nil && !decorates(prevSrc) {
+			// Identical bindings, as from sets that both bind an interface
+			// to the same type, resolve the same way.
+			if !sameBinding(b.Iface, src, prevSrc.(*providerSetSrc)) {
+				ec.add(bindingConflictError(fset, b.Iface, set, src, prevSrc.(*providerSetSrc)))
+			}
 			continue
 		}
 		concrete := providerMap.At(b.Provided)
@@ -429,6 +904,314 @@ func buildProviderMap(fset *token.FileSet, hasher typeutil.Hasher, set *Provider
 	return providerMap, srcMap, nil
 }
 
+// buildStrategyMap creates the strategyMap field for a given provider set,
+// whose providerMap and srcMap fields must already be built. A type may
+// have any number of alternatives, but each for a different strategy, and
+// none if the set provides the type otherwise.
+func buildStrategyMap(fset *token.FileSet, hasher typeutil.Hasher, set *ProviderSet) (*typeutil.Map, []error) {
+	var strategyMap *typeutil.Map
+	ec := new(errorCollector)
+	add := func(typ types.Type, alt *strategyProvider) {
+		if prevSrc := set.srcMap.At(typ); prevSrc != nil {
+			ec.add(bindingConflictError(fset, typ, set, alt.src, prevSrc.(*providerSetSrc)))
+			return
+		}
+		if strategyMap == nil {
+			strategyMap = new(typeutil.Map)
+			strategyMap.SetHasher(hasher)
+		}
+		alts, _ := strategyMap.At(typ).([]*strategyProvider)
+		for _, prev := range alts {
+			if prev.p.Strategy == alt.p.Strategy {
+				ec.add(bindingConflictError(fset, typ, set, alt.src, prev.src))
+				return
+			}
+		}
+		strategyMap.Set(typ, append(alts, alt))
+	}
+	for _, imp := range set.Imports {
+		if imp.strategyMap == nil {
+			continue
+		}
+		src := &providerSetSrc{Import: imp}
+		for _, typ := range sortedTypes(imp.strategyMap) {
+			for _, alt := range imp.strategyMap.At(typ).([]*strategyProvider) {
+				add(typ, &strategyProvider{p: alt.p, src: src})
+			}
+		}
+	}
+	for _, p := range set.Providers {
+		if p.Strategy == "" {
+			continue
+		}
+		src := &providerSetSrc{Provider: p}
+		for _, typ := range p.Out {
+			add(typ, &strategyProvider{p: p, src: src})
+		}
+	}
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	return strategyMap, nil
+}
+
+// selectStrategy returns the provider set an injector marked
+// //wire:strategy with the given name, or with none if it is empty, is
+// built from. The alternatives of set for the strategy are added to the
+// providerMap of the returned set, and its strategyMap holds the
+// alternatives of the types for which none was selected.
+func selectStrategy(fset *token.FileSet, hasher typeutil.Hasher, set *ProviderSet, strategy string) (*ProviderSet, []error) {
+	if set.strategyMap == nil {
+		if strategy != "" {
+			return nil, []error{fmt.Errorf("no provider in the set is marked //wire:strategy %s", strategy)}
+		}
+		return set, nil
+	}
+	cp := *set
+	cp.providerMap = new(typeutil.Map)
+	cp.providerMap.SetHasher(hasher)
+	cp.srcMap = new(typeutil.Map)
+	cp.srcMap.SetHasher(hasher)
+	set.providerMap.Iterate(func(k types.Type, v interface{}) {
+		cp.providerMap.Set(k, v)
+		cp.srcMap.Set(k, set.srcMap.At(k))
+	})
+	cp.strategyMap = nil
+	found := false
+	for _, typ := range sortedTypes(set.strategyMap) {
+		alts := set.strategyMap.At(typ).([]*strategyProvider)
+		selected := false
+		for _, alt := range alts {
+			if alt.p.Strategy == strategy {
+				cp.providerMap.Set(typ, &ProvidedType{t: typ, p: alt.p})
+				cp.srcMap.Set(typ, alt.src)
+				selected = true
+			}
+		}
+		if selected {
+			found = true
+			continue
+		}
+		if cp.strategyMap == nil {
+			cp.strategyMap = new(typeutil.Map)
+			cp.strategyMap.SetHasher(hasher)
+		}
+		cp.strategyMap.Set(typ, alts)
+	}
+	if strategy != "" && !found {
+		return nil, []error{fmt.Errorf("no provider in the set is marked //wire:strategy %s", strategy)}
+	}
+	if errs := verifyAcyclic(cp.providerMap, hasher); len(errs) > 0 {
+		return nil, errs
+	}
+	return &cp, nil
+}
+
+// allowAssignable returns the provider set an injector marked
+// //wire:assignable is built from. Each type that out or a provider in set
+// needs but set does not provide is bound to the one provided type that is
+// assignable to it, as an interface binding is. Types assignable from
+// several provided types are left out and recorded in the ambiguousMap of
+// the returned set.
+func allowAssignable(hasher typeutil.Hasher, set *ProviderSet, out types.Type) (*ProviderSet, []error) {
+	cp := *set
+	cp.providerMap = new(typeutil.Map)
+	cp.providerMap.SetHasher(hasher)
+	cp.srcMap = new(typeutil.Map)
+	cp.srcMap.SetHasher(hasher)
+	set.providerMap.Iterate(func(k types.Type, v interface{}) {
+		cp.providerMap.Set(k, v)
+		cp.srcMap.Set(k, set.srcMap.At(k))
+	})
+	provided := sortedTypes(set.providerMap)
+	var needed []types.Type
+	if out != nil {
+		needed = append(needed, out)
+	}
+	for _, t := range provided {
+		if pv := set.For(t); pv.IsProvider() {
+			for _, a := range pv.Provider().Args {
+				needed = append(needed, a.Type)
+			}
+		}
+	}
+	for _, t := range needed {
+		if cp.providerMap.At(t) != nil || (cp.ambiguousMap != nil && cp.ambiguousMap.At(t) != nil) {
+			continue
+		}
+		var froms []types.Type
+		for _, from := range provided {
+			if types.AssignableTo(from, t) {
+				froms = append(froms, from)
+			}
+		}
+		switch len(froms) {
+		case 0:
+		case 1:
+			cp.providerMap.Set(t, set.providerMap.At(froms[0]))
+			cp.srcMap.Set(t, set.srcMap.At(froms[0]))
+		default:
+			if cp.ambiguousMap == nil {
+				cp.ambiguousMap = new(typeutil.Map)
+				cp.ambiguousMap.SetHasher(hasher)
+			}
+			cp.ambiguousMap.Set(t, froms)
+		}
+	}
+	if errs := verifyAcyclic(cp.providerMap, hasher); len(errs) > 0 {
+		return nil, errs
+	}
+	return &cp, nil
+}
+
+// derefPointers returns a copy of set that provides each named struct type
+// needed by out or a provider in set, but not provided by set, by
+// dereferencing the pointer to it if set provides one, and each pointer to a
+// named struct type needed but not provided by taking the address of the
+// struct if set provides it.
+func derefPointers(hasher typeutil.Hasher, set *ProviderSet, out types.Type) *ProviderSet {
+	cp := *set
+	cp.providerMap = new(typeutil.Map)
+	cp.providerMap.SetHasher(hasher)
+	cp.srcMap = new(typeutil.Map)
+	cp.srcMap.SetHasher(hasher)
+	set.providerMap.Iterate(func(k types.Type, v interface{}) {
+		cp.providerMap.Set(k, v)
+		cp.srcMap.Set(k, set.srcMap.At(k))
+	})
+	var needed []types.Type
+	if out != nil {
+		needed = append(needed, out)
+	}
+	for _, t := range sortedTypes(set.providerMap) {
+		if pv := set.For(t); pv.IsProvider() {
+			for _, a := range pv.Provider().Args {
+				needed = append(needed, a.Type)
+			}
+		}
+	}
+	for _, t := range needed {
+		if cp.providerMap.At(t) != nil || (cp.ambiguousMap != nil && cp.ambiguousMap.At(t) != nil) {
+			continue
+		}
+		named, from := namedStruct(t), types.Type(nil)
+		if ptr, ok := t.(*types.Pointer); ok {
+			named, from = namedStruct(ptr.Elem()), ptr.Elem()
+		} else if named != nil {
+			from = types.NewPointer(t)
+		}
+		if named == nil || set.providerMap.At(from) == nil {
+			continue
+		}
+		obj := named.Obj()
+		p := &Provider{
+			Pkg:   obj.Pkg(),
+			Name:  obj.Name(),
+			Pos:   obj.Pos(),
+			Args:  []ProviderInput{{Type: from}},
+			Out:   []types.Type{t},
+			Deref: true,
+		}
+		cp.providerMap.Set(t, &ProvidedType{t: t, p: p})
+		cp.srcMap.Set(t, set.srcMap.At(from))
+	}
+	return &cp
+}
+
+// namedStruct returns t if it is a named struct type, or nil otherwise.
+func namedStruct(t types.Type) *types.Named {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return named
+}
+
+// buildWrapMap creates the wrapMap and wrapSrcMap fields for a given
+// provider set from its own Wraps and those of its imports. Both maps are
+// nil if no Wrap applies.
+func buildWrapMap(fset *token.FileSet, hasher typeutil.Hasher, set *ProviderSet) (*typeutil.Map, *typeutil.Map, []error) {
+	var wrapMap, srcMap *typeutil.Map
+	ec := new(errorCollector)
+	add := func(w *Wrap, src *providerSetSrc) {
+		if wrapMap == nil {
+			wrapMap = new(typeutil.Map)
+			wrapMap.SetHasher(hasher)
+			srcMap = new(typeutil.Map) // to *providerSetSrc
+			srcMap.SetHasher(hasher)
+		}
+		if prev, _ := wrapMap.At(w.Type).(*Wrap); prev != nil {
+			// The same Wrap may be imported through several sets.
+			if prev != w {
+				ec.add(notePosition(fset.Position(w.Pos), fmt.Errorf("%s is already wrapped by wire.Wrap (%s)", types.TypeString(w.Type, nil), fset.Position(prev.Pos))))
+			}
+			return
+		}
+		wrapMap.Set(w.Type, w)
+		srcMap.Set(w.Type, src)
+	}
+	for _, imp := range set.Imports {
+		if imp.wrapMap == nil {
+			continue
+		}
+		src := &providerSetSrc{Import: imp}
+		for _, t := range sortedTypes(imp.wrapMap) {
+			add(imp.wrapMap.At(t).(*Wrap), src)
+		}
+	}
+	for _, w := range set.Wraps {
+		add(w, &providerSetSrc{Wrap: w})
+	}
+	if len(ec.errors) > 0 {
+		return nil, nil, ec.errors
+	}
+	return wrapMap, srcMap, nil
+}
+
+// isGroupMemberOutput reports whether t is provided by pt as the output of a
+// group member rather than as the member's placeholder type.
+func isGroupMemberOutput(t types.Type, pt *ProvidedType) bool {
+	return pt.Provider().Group != nil && !types.Identical(t, pt.Type())
+}
+
+// groupProviders returns a provider for each group with members in
+// providerMap, which builds the group's slice type from the members in order
+// of position.
+func groupProviders(fset *token.FileSet, providerMap *typeutil.Map) []*Provider {
+	var groups []*Provider
+	byType := new(typeutil.Map) // to *Provider
+	for _, t := range sortedTypes(providerMap) {
+		pt := providerMap.At(t).(*ProvidedType)
+		if !pt.IsProvider() || pt.Provider().Group == nil || isGroupMemberOutput(t, pt) {
+			continue
+		}
+		m := pt.Provider()
+		g, _ := byType.At(m.Group).(*Provider)
+		if g == nil {
+			g = &Provider{Out: []types.Type{m.Group}, IsGroup: true}
+			byType.Set(m.Group, g)
+			groups = append(groups, g)
+		}
+		g.Args = append(g.Args, ProviderInput{Type: t})
+	}
+	for _, g := range groups {
+		sort.SliceStable(g.Args, func(i, j int) bool {
+			pi := fset.Position(providerMap.At(g.Args[i].Type).(*ProvidedType).Provider().Pos)
+			pj := fset.Position(providerMap.At(g.Args[j].Type).(*ProvidedType).Provider().Pos)
+			if pi.Filename != pj.Filename {
+				return pi.Filename < pj.Filename
+			}
+			return pi.Offset < pj.Offset
+		})
+		first := providerMap.At(g.Args[0].Type).(*ProvidedType).Provider()
+		g.Pkg, g.Pos = first.Pkg, first.Pos
+	}
+	return groups
+}
+
 func verifyAcyclic(providerMap *typeutil.Map, hasher typeutil.Hasher) []error {
 	// We must visit every provider type inside provider map, but we don't
 	// have a well-defined starting point and there may be several
@@ -439,9 +1222,7 @@ func verifyAcyclic(providerMap *typeutil.Map, hasher typeutil.Hasher) []error {
 	visited.SetHasher(hasher)
 	ec := new(errorCollector)
 	// Sort output types so that errors about cycles are consistent.
-	outputs := providerMap.Keys()
-	sort.Slice(outputs, func(i, j int) bool { return types.TypeString(outputs[i], nil) < types.TypeString(outputs[j], nil) })
-	for _, root := range outputs {
+	for _, root := range sortedTypes(providerMap) {
 		// Depth-first search using a stack of trails through the provider map.
 		stk := [][]types.Type{{root}}
 		for len(stk) > 0 {
@@ -476,20 +1257,26 @@ func verifyAcyclic(providerMap *typeutil.Map, hasher typeutil.Hasher) []error {
 					hasCycle := false
 					for i, b := range curr {
 						if types.Identical(a, b) {
-							sb := new(strings.Builder)
-							fmt.Fprintf(sb, "cycle for %s:\n", types.TypeString(a, nil))
+							cycle := &CycleError{Type: types.TypeString(a, nil)}
 							for j := i; j < len(curr); j++ {
+								var provider string
 								t := providerMap.At(curr[j]).(*ProvidedType)
-								if t.IsProvider() {
+								switch {
+								case t.IsProvider() && t.Provider().IsGroup:
+									provider = "wire.Group"
+								case t.IsProvider():
 									p := t.Provider()
-									fmt.Fprintf(sb, "%s (%s.%s) ->\n", types.TypeString(curr[j], nil), p.Pkg.Path(), p.Name)
-								} else {
+									provider = p.Pkg.Path() + "." + p.Name
+								default:
 									p := t.Field()
-									fmt.Fprintf(sb, "%s (%s.%s) ->\n", types.TypeString(curr[j], nil), p.Parent, p.Name)
+									provider = fmt.Sprintf("%s.%s", p.Parent, p.Name)
 								}
+								cycle.Path = append(cycle.Path, CycleStep{
+									Type:     types.TypeString(curr[j], nil),
+									Provider: provider,
+								})
 							}
-							fmt.Fprintf(sb, "%s", types.TypeString(a, nil))
-							ec.add(errors.New(sb.String()))
+							ec.add(cycle)
 							hasCycle = true
 							break
 						}
@@ -507,15 +1294,28 @@ func verifyAcyclic(providerMap *typeutil.Map, hasher typeutil.Hasher) []error {
 	return ec.errors
 }
 
+// sortedTypes returns the keys of m sorted by their type strings. Iterating
+// over a typeutil.Map directly visits keys in an unspecified order.
+func sortedTypes(m *typeutil.Map) []types.Type {
+	keys := m.Keys()
+	sort.Slice(keys, func(i, j int) bool { return types.TypeString(keys[i], nil) < types.TypeString(keys[j], nil) })
+	return keys
+}
+
+// sameBinding reports whether cur and prev both provide typ with a
+// wire.Bind call to the same concrete type. Such bindings don't conflict.
+func sameBinding(typ types.Type, cur, prev *providerSetSrc) bool {
+	b1, b2 := cur.binding(typ), prev.binding(typ)
+	return b1 != nil && b2 != nil && types.Identical(b1.Provided, b2.Provided)
+}
+
 // bindingConflictError creates a new error describing multiple bindings
 // for the same output type.
 func bindingConflictError(fset *token.FileSet, typ types.Type, set *ProviderSet, cur, prev *providerSetSrc) error {
-	sb := new(strings.Builder)
-	if set.VarName != "" {
-		fmt.Fprintf(sb, "%s has ", set.VarName)
-	}
-	fmt.Fprintf(sb, "multiple bindings for %s\n", types.TypeString(typ, nil))
-	fmt.Fprintf(sb, "current:\n<- %s\n", strings.Join(cur.trace(fset, typ), "\n<- "))
-	fmt.Fprintf(sb, "previous:\n<- %s", strings.Join(prev.trace(fset, typ), "\n<- "))
-	return notePosition(fset.Position(set.Pos), errors.New(sb.String()))
+	return notePosition(fset.Position(set.Pos), &ConflictError{
+		SetName:  set.VarName,
+		Type:     types.TypeString(typ, nil),
+		Current:  cur.trace(fset, typ),
+		Previous: prev.trace(fset, typ),
+	})
 }
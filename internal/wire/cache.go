@@ -0,0 +1,452 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion identifies the format of cache entries and the generator
+// that wrote them. It must be incremented whenever either changes, so that
+// stale entries are not reused.
+const cacheVersion = 1
+
+// A cacheEntry records the result of generating code for a single package,
+// along with the source files it was generated from.
+type cacheEntry struct {
+	Version int
+	// OptionsHash is a hash of the GenerateOptions that affect the output.
+	OptionsHash string
+	PkgPath     string
+	OutputPath  string
+	// Files lists the Go files of the package and its transitive
+	// dependencies, sorted by path.
+	Files   []cachedFile
+	Content []byte
+}
+
+// A cachedFile records the state of a source file when a cache entry was
+// written.
+type cachedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Hash is the hex-encoded SHA-256 hash of the file's contents.
+	Hash string
+}
+
+// generateCached is like generate, but reuses the results recorded in
+// opts.CacheDir for packages whose source files have not changed since the
+// last run. Determining which files a package depends on only requires
+// listing packages, not type-checking them.
+func generateCached(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions) ([]GenerateResult, []error) {
+	pkgs, errs := loadFiles(ctx, wd, env, opts.Tags, patterns)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	target := buildTarget(env, opts.Tags)
+	generated := make([]GenerateResult, len(pkgs))
+	var stale []string
+	staleIndex := make(map[string]int)
+	// Record the state of each stale package's files before generating, so
+	// that files edited during generation invalidate the new entry.
+	snapshots := make(map[string][]cachedFile)
+	optsHashes := make(map[string]string)
+	for i, pkg := range pkgs {
+		// Packages may have options of their own from wire.yaml files.
+		optsHash, outputPath := "", ""
+		if dir, err := detectOutputDir(pkg.GoFiles); err == nil {
+			if pkgOpts, _, err := packageOptions(opts, dir, 0); err == nil {
+				optsHash = cacheOptionsHash(pkgOpts, target)
+				outputPath = filepath.Join(dir, pkgOpts.PrefixOutputFile+outputFileName(pkg))
+			}
+		}
+		optsHashes[pkg.PkgPath] = optsHash
+		// Leave out the generated file, so that writing it doesn't
+		// invalidate the entry.
+		files := withoutFile(dependencyFiles(pkg), outputPath)
+		if e := readCacheEntry(opts.CacheDir, pkg.PkgPath); e != nil && optsHash != "" && e.valid(optsHash, files) {
+			generated[i] = GenerateResult{
+				PkgPath:    e.PkgPath,
+				OutputPath: e.OutputPath,
+				Content:    e.Content,
+			}
+			continue
+		}
+		stale = append(stale, pkg.PkgPath)
+		staleIndex[pkg.PkgPath] = i
+		if snap, err := statFiles(files); err == nil {
+			snapshots[pkg.PkgPath] = snap
+		}
+	}
+	if len(stale) == 0 {
+		return generated, nil
+	}
+	results, errs := generate(ctx, wd, env, stale, opts)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	for _, res := range results {
+		i, ok := staleIndex[res.PkgPath]
+		if !ok {
+			continue
+		}
+		generated[i] = res
+		snap := snapshots[res.PkgPath]
+		optsHash := optsHashes[res.PkgPath]
+		if len(res.Errs) > 0 || snap == nil || optsHash == "" {
+			continue
+		}
+		// The cache is only an optimization, so failing to write it is
+		// not an error.
+		_ = writeCacheEntry(opts.CacheDir, &cacheEntry{
+			Version:     cacheVersion,
+			OptionsHash: optsHash,
+			PkgPath:     res.PkgPath,
+			OutputPath:  res.OutputPath,
+			Files:       snap,
+			Content:     res.Content,
+		})
+	}
+	return generated, nil
+}
+
+// loadFiles lists the packages that match the given patterns, along with
+// their transitive dependencies, source files and modules, without
+// type-checking them. If listing reports errors, the packages that could be
+// listed are returned along with them.
+func loadFiles(ctx context.Context, wd string, env []string, tags string, patterns []string) ([]*packages.Package, []error) {
+	cfg := &packages.Config{
+		Context:    ctx,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Dir:        wd,
+		Env:        env,
+		BuildFlags: []string{buildTagsFlag(tags)},
+	}
+	escaped := make([]string, len(patterns))
+	for i := range patterns {
+		escaped[i] = "pattern=" + patterns[i]
+	}
+	pkgs, err := packages.Load(cfg, escaped...)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var errs []error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		errs = append(errs, packageErrors(p)...)
+	})
+	return pkgs, errs
+}
+
+// dependencyFiles returns the sorted paths of the Go files in pkg and its
+// transitive dependencies. The files pkg ignores are included too, since
+// they may be inject files excluded by a //go:build ignore constraint.
+func dependencyFiles(pkg *packages.Package) []string {
+	files := append([]string(nil), pkg.IgnoredFiles...)
+	packages.Visit([]*packages.Package{pkg}, nil, func(p *packages.Package) {
+		files = append(files, p.GoFiles...)
+	})
+	sort.Strings(files)
+	return files
+}
+
+// withoutFile returns files without path.
+func withoutFile(files []string, path string) []string {
+	kept := files[:0]
+	for _, f := range files {
+		if f != path {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// cacheOptionsHash returns a hash of the options that affect generated code.
+// The Go toolchain version is included since it determines the default
+// GoVersion and how generated code is formatted, and the target platform
+// since it determines which constrained providers may be used.
+func cacheOptionsHash(opts *GenerateOptions, target *build.Context) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%q\x00%q\x00%q\x00%t\x00", cacheVersion, runtime.Version(), opts.PrefixOutputFile, opts.Tags, opts.GoVersion, opts.StrictExports)
+	fmt.Fprintf(h, "%q\x00%q\x00%t\x00", opts.MainFunc, opts.MainTemplate, opts.WrapErrors)
+	fmt.Fprintf(h, "%t\x00%t\x00%t\x00%q\x00", opts.InstrumentProviders, opts.OtelTrace, opts.GroupImports, opts.Nolint)
+	fmt.Fprintf(h, "%t\x00%t\x00%t\x00", opts.InputsHash, opts.StructuredErrors, opts.AutoDeref)
+	paths := make([]string, 0, len(opts.ImportAliases))
+	for path := range opts.ImportAliases {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(h, "%q=%q\x00", path, opts.ImportAliases[path])
+	}
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00", target.GOOS, target.GOARCH, target.CgoEnabled)
+	h.Write(opts.Header)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheEntryPath returns the path of the cache entry for a package.
+func cacheEntryPath(dir, pkgPath string) string {
+	sum := sha256.Sum256([]byte(pkgPath))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readCacheEntry reads the cache entry for a package, returning nil if
+// there is none or it cannot be read.
+func readCacheEntry(dir, pkgPath string) *cacheEntry {
+	data, err := ioutil.ReadFile(cacheEntryPath(dir, pkgPath))
+	if err != nil {
+		return nil
+	}
+	e := new(cacheEntry)
+	if err := json.Unmarshal(data, e); err != nil || e.PkgPath != pkgPath {
+		return nil
+	}
+	return e
+}
+
+// valid reports whether e was written by this version of Wire with the
+// same options and from the same contents of files.
+func (e *cacheEntry) valid(optsHash string, files []string) bool {
+	if e.Version != cacheVersion || e.OptionsHash != optsHash {
+		return false
+	}
+	return filesUnchanged(e.Files, files)
+}
+
+// filesUnchanged reports whether snapshot records the current contents of
+// files, in order. Files whose size and modification time are unchanged are
+// assumed to have the same contents.
+func filesUnchanged(snapshot []cachedFile, files []string) bool {
+	if len(snapshot) != len(files) {
+		return false
+	}
+	for i, f := range snapshot {
+		if f.Path != files[i] {
+			return false
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return false
+		}
+		if info.Size() == f.Size && info.ModTime().Equal(f.ModTime) {
+			continue
+		}
+		cur, err := statFile(f.Path)
+		if err != nil || cur.Hash != f.Hash {
+			return false
+		}
+	}
+	return true
+}
+
+// writeCacheEntry records e in dir. The entry is written to a temporary
+// file first so that concurrent readers never see a partial entry.
+func writeCacheEntry(dir string, e *cacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "entry")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), cacheEntryPath(dir, e.PkgPath))
+}
+
+// statFiles returns the current state of the files at paths.
+func statFiles(paths []string) ([]cachedFile, error) {
+	files := make([]cachedFile, 0, len(paths))
+	for _, path := range paths {
+		f, err := statFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// statFile returns the current state of the file at path.
+func statFile(path string) (cachedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+	sum := sha256.Sum256(data)
+	return cachedFile{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// A ProviderCache holds loaded packages and the provider sets found in them,
+// so that a long-running process can call Generate repeatedly without
+// reloading and re-analyzing packages that have not changed. A package is
+// reloaded if any of its source files or those of its dependencies change,
+// or if it is generated with different build tags, working directory, or
+// environment.
+//
+// A ProviderCache is safe for concurrent use by multiple goroutines.
+// Packages that were loaded together share type-checked dependencies, so
+// Generate calls that use any of the same such packages are serialized;
+// calls for packages loaded separately run in parallel.
+type ProviderCache struct {
+	mu        sync.RWMutex                   // guards entries and lastBatch
+	entries   map[string]*providerCacheEntry // keyed by package path
+	lastBatch uint64
+}
+
+// A providerCacheEntry is a package loaded by a ProviderCache.
+type providerCacheEntry struct {
+	// key identifies the settings the package was loaded with.
+	key   string
+	files []cachedFile
+	pkg   *packages.Package
+	oc    *objectCache
+	batch *loadBatch
+}
+
+// A loadBatch guards the packages returned by a single load. Provider sets
+// are computed lazily and record state in the type information of the
+// packages, so only one Generate call may use a batch at a time.
+type loadBatch struct {
+	mu sync.Mutex
+	id uint64 // orders locking to avoid deadlock
+}
+
+// NewProviderCache returns an empty ProviderCache.
+func NewProviderCache() *ProviderCache {
+	return &ProviderCache{entries: make(map[string]*providerCacheEntry)}
+}
+
+// generate implements Generate for packages loaded through pc.
+func (pc *ProviderCache) generate(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions, goMinor int) ([]GenerateResult, []error) {
+	listed, errs := loadFiles(ctx, wd, env, opts.Tags, patterns)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	key := strings.Join(append([]string{wd, opts.Tags}, env...), "\x00")
+	pkgs := make([]*packages.Package, len(listed))
+	caches := make([]*objectCache, len(listed))
+	batches := make([]*loadBatch, len(listed))
+	var stale []string
+	staleIndex := make(map[string]int)
+	snapshots := make(map[string][]cachedFile)
+	pc.mu.RLock()
+	for i, lp := range listed {
+		files := dependencyFiles(lp)
+		if e := pc.entries[lp.PkgPath]; e != nil && e.key == key && filesUnchanged(e.files, files) {
+			pkgs[i], caches[i], batches[i] = e.pkg, e.oc, e.batch
+			continue
+		}
+		stale = append(stale, lp.PkgPath)
+		staleIndex[lp.PkgPath] = i
+		if snap, err := statFiles(files); err == nil {
+			snapshots[lp.PkgPath] = snap
+		}
+	}
+	pc.mu.RUnlock()
+	if len(stale) > 0 {
+		loaded, errs := load(ctx, wd, env, opts.Tags, stale, false)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		pc.mu.Lock()
+		pc.lastBatch++
+		batch := &loadBatch{id: pc.lastBatch}
+		for _, path := range stale {
+			delete(pc.entries, path)
+		}
+		// Packages loaded together share a batch, so they can share an
+		// object cache too.
+		var oc *objectCache
+		if len(loaded) > 0 {
+			oc = newObjectCache(loaded)
+		}
+		for _, pkg := range loaded {
+			i, ok := staleIndex[pkg.PkgPath]
+			if !ok {
+				continue
+			}
+			pkgs[i], caches[i], batches[i] = pkg, oc, batch
+			if snap := snapshots[pkg.PkgPath]; snap != nil {
+				pc.entries[pkg.PkgPath] = &providerCacheEntry{key: key, files: snap, pkg: pkg, oc: oc, batch: batch}
+			}
+		}
+		pc.mu.Unlock()
+	}
+	for i, pkg := range pkgs {
+		if pkg == nil {
+			return nil, []error{fmt.Errorf("package %s was listed but not loaded", listed[i].PkgPath)}
+		}
+	}
+	unlock := lockBatches(batches)
+	defer unlock()
+	return generatePackages(pkgs, caches, env, opts, goMinor), nil
+}
+
+// lockBatches locks each distinct batch in order of creation and returns a
+// function that unlocks them.
+func lockBatches(batches []*loadBatch) (unlock func()) {
+	seen := make(map[*loadBatch]bool)
+	var distinct []*loadBatch
+	for _, b := range batches {
+		if !seen[b] {
+			seen[b] = true
+			distinct = append(distinct, b)
+		}
+	}
+	sort.Slice(distinct, func(i, j int) bool { return distinct[i].id < distinct[j].id })
+	for _, b := range distinct {
+		b.mu.Lock()
+	}
+	return func() {
+		for _, b := range distinct {
+			b.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileName is the name of the file that LoadConfig reads.
+const ConfigFileName = "wire.yaml"
+
+// A PackageConfig holds the generation settings read from a wire.yaml file.
+// Fields that are not set in the file are left empty.
+type PackageConfig struct {
+	// HeaderFile is the path of a file to insert at the start of each
+	// generated file. LoadConfig resolves it relative to the directory
+	// containing wire.yaml.
+	HeaderFile string
+	// OutputFilePrefix is prepended to the generated file name.
+	OutputFilePrefix string
+	// Tags are build tags to use in addition to wireinject.
+	Tags string
+	// GoVersion is the oldest Go version the generated code must compile
+	// with. See GenerateOptions.GoVersion.
+	GoVersion string
+	// CacheDir is a directory in which to cache generated code. LoadConfig
+	// resolves it relative to the directory containing wire.yaml.
+	CacheDir string
+	// Nolint lists the linters that generated files suppress with a
+	// //nolint directive. See GenerateOptions.Nolint.
+	Nolint string
+}
+
+// LoadConfig reads the wire.yaml file in dir. It returns nil and no error if
+// the file does not exist.
+//
+// The file is a YAML mapping of the keys header_file, output_file_prefix,
+// tags, go_version, cache_dir, and nolint to string values. Only single-line
+// plain, single-quoted, and double-quoted values are supported.
+func LoadConfig(dir string) (*PackageConfig, error) {
+	path := filepath.Join(dir, ConfigFileName)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.HeaderFile != "" && !filepath.IsAbs(cfg.HeaderFile) {
+		cfg.HeaderFile = filepath.Join(dir, cfg.HeaderFile)
+	}
+	if cfg.CacheDir != "" && !filepath.IsAbs(cfg.CacheDir) {
+		cfg.CacheDir = filepath.Join(dir, cfg.CacheDir)
+	}
+	return cfg, nil
+}
+
+// parseConfig parses the contents of a wire.yaml file. Errors include the
+// line number they occurred on.
+func parseConfig(data []byte) (*PackageConfig, error) {
+	cfg := new(PackageConfig)
+	fields := map[string]*string{
+		"header_file":        &cfg.HeaderFile,
+		"output_file_prefix": &cfg.OutputFilePrefix,
+		"tags":               &cfg.Tags,
+		"go_version":         &cfg.GoVersion,
+		"cache_dir":          &cfg.CacheDir,
+		"nolint":             &cfg.Nolint,
+	}
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := sc.Text()
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: nested values are not supported", lineno)
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineno)
+		}
+		key := strings.TrimSpace(line[:i])
+		field := fields[key]
+		if field == nil {
+			return nil, fmt.Errorf("line %d: unknown key %q", lineno, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineno, key)
+		}
+		seen[key] = true
+		val, err := parseConfigValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", lineno, key, err)
+		}
+		*field = val
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseConfigValue parses a single-line YAML scalar, dropping any trailing
+// comment.
+func parseConfigValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		if err := checkTrailing(s[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		// In single-quoted YAML strings, '' is an escaped quote.
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				sb.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				sb.WriteByte('\'')
+				i++
+				continue
+			}
+			if err := checkTrailing(s[i+1:]); err != nil {
+				return "", err
+			}
+			return sb.String(), nil
+		}
+		return "", fmt.Errorf("unterminated string %s", s)
+	default:
+		// A plain value ends at a comment.
+		if strings.HasPrefix(s, "#") {
+			return "", nil
+		}
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = strings.TrimSpace(s[:i])
+		}
+		if s != "" && strings.ContainsRune("[{|>&*!", rune(s[0])) {
+			return "", fmt.Errorf("unsupported value %s", s)
+		}
+		return s, nil
+	}
+}
+
+// closingQuote returns the index of the double quote that ends the string
+// starting at s[0], or -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// checkTrailing reports an error if s, the text after a quoted value, holds
+// anything other than a comment.
+func checkTrailing(s string) error {
+	if s = strings.TrimSpace(s); s != "" && !strings.HasPrefix(s, "#") {
+		return fmt.Errorf("unexpected %s after string", s)
+	}
+	return nil
+}
+
+// Merge fills in the options in opts that are unset with the settings in
+// cfg, reading the header file if needed. Options already set in opts take
+// precedence.
+func (cfg *PackageConfig) Merge(opts *GenerateOptions) error {
+	if len(opts.Header) == 0 && cfg.HeaderFile != "" {
+		header, err := ioutil.ReadFile(cfg.HeaderFile)
+		if err != nil {
+			return fmt.Errorf("failed to read header file %q: %v", cfg.HeaderFile, err)
+		}
+		opts.Header = header
+	}
+	if opts.PrefixOutputFile == "" {
+		opts.PrefixOutputFile = cfg.OutputFilePrefix
+	}
+	if opts.Tags == "" {
+		opts.Tags = cfg.Tags
+	}
+	if opts.GoVersion == "" {
+		opts.GoVersion = cfg.GoVersion
+	}
+	if opts.CacheDir == "" {
+		opts.CacheDir = cfg.CacheDir
+	}
+	if opts.Nolint == "" {
+		opts.Nolint = cfg.Nolint
+	}
+	return nil
+}
+
+// packageOptions returns the options to generate the package in dir with,
+// and the minor version of the oldest Go release the code must compile with.
+// If opts.ConfigFiles is set, the options left unset are filled in with the
+// settings of the wire.yaml file in dir, other than tags and cache_dir, and
+// then with those of the file in the working directory. Otherwise, opts and
+// goMinor are returned unchanged.
+func packageOptions(opts *GenerateOptions, dir string, goMinor int) (*GenerateOptions, int, error) {
+	if !opts.ConfigFiles {
+		return opts, goMinor, nil
+	}
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cfg == nil && opts.wdConfig == nil {
+		return opts, goMinor, nil
+	}
+	pkgOpts := *opts
+	if cfg != nil {
+		cfg.Tags, cfg.CacheDir = "", ""
+		if err := cfg.Merge(&pkgOpts); err != nil {
+			return nil, 0, err
+		}
+	}
+	if opts.wdConfig != nil {
+		if err := opts.wdConfig.Merge(&pkgOpts); err != nil {
+			return nil, 0, err
+		}
+	}
+	if pkgOpts.GoVersion != opts.GoVersion {
+		if goMinor, err = parseGoVersion(pkgOpts.GoVersion); err != nil {
+			return nil, 0, err
+		}
+	}
+	if _, err := nolintDirective(pkgOpts.Nolint); err != nil {
+		return nil, 0, err
+	}
+	return &pkgOpts, goMinor, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// buildTarget returns the build context of the code that Wire generates for:
+// the go command's default context, with GOOS, GOARCH and CGO_ENABLED taken
+// from env if they are set there, and tags, the value of
+// GenerateOptions.Tags, as build tags.
+func buildTarget(env []string, tags string) *build.Context {
+	target := build.Default
+	for _, kv := range env {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			continue
+		}
+		switch k, v := kv[:i], kv[i+1:]; k {
+		case "GOOS":
+			target.GOOS = v
+		case "GOARCH":
+			target.GOARCH = v
+		case "CGO_ENABLED":
+			target.CgoEnabled = v == "1"
+		}
+	}
+	target.BuildTags = strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	return &target
+}
+
+// constraintDirective returns the build constraint expression of a
+// //wire:build line in doc, or the empty string if there is none. The
+// expression has the syntax of a //go:build line, which the compiler only
+// accepts at the top of a file.
+func constraintDirective(doc *ast.CommentGroup) (string, error) {
+	if doc == nil {
+		return "", nil
+	}
+	const prefix = "//wire:build"
+	expr := ""
+	for _, c := range doc.List {
+		text := strings.TrimSpace(c.Text)
+		if !strings.HasPrefix(text, prefix) || len(text) > len(prefix) && text[len(prefix)] != ' ' && text[len(prefix)] != '\t' {
+			continue
+		}
+		if expr != "" {
+			return "", errors.New("multiple //wire:build lines")
+		}
+		expr = strings.TrimSpace(text[len(prefix):])
+		if expr == "" {
+			return "", errors.New("//wire:build line has no constraint")
+		}
+		// Report malformed constraints here rather than when the provider
+		// is used.
+		if _, err := satisfiesConstraint(&build.Default, expr); err != nil {
+			return "", err
+		}
+	}
+	return expr, nil
+}
+
+// satisfiesConstraint reports whether the build constraint expression expr
+// is satisfied when building for target.
+func satisfiesConstraint(target *build.Context, expr string) (bool, error) {
+	// The go/build package only evaluates the constraints of files, so
+	// evaluate them for a file that has only this one.
+	ctx := *target
+	src := "//go:build " + expr + "\n\npackage p\n"
+	ctx.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(src)), nil
+	}
+	ok, err := ctx.MatchFile("", "constraint.go")
+	if err != nil {
+		return false, fmt.Errorf("invalid //wire:build constraint %q", expr)
+	}
+	return ok, nil
+}
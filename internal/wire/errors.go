@@ -15,7 +15,11 @@
 package wire
 
 import (
+	"fmt"
 	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 // errorCollector manages a list of errors. The zero value is an empty list.
@@ -82,3 +86,172 @@ func (w *wireErr) Error() string {
 	}
 	return w.position.String() + ": " + w.error.Error()
 }
+
+// Unwrap returns the underlying error.
+func (w *wireErr) Unwrap() error {
+	return w.error
+}
+
+// A DependencyStep describes one link in a chain of dependencies.
+type DependencyStep struct {
+	// Type is the type that has the dependency.
+	Type string
+	// Source describes what provides Type, including its position.
+	Source string
+}
+
+// MissingProviderError is reported when no provider is found for a type that
+// an injector needs.
+type MissingProviderError struct {
+	// Type is the type that has no provider.
+	Type string
+	// NeededBy is the chain of types that required Type, starting with the
+	// type that depends on it directly. It is empty if Type is the output
+	// of the injector.
+	NeededBy []DependencyStep
+	// Strategies lists the //wire:strategy names of the providers of Type,
+	// none of which the injector selects.
+	Strategies []string
+	// Assignable lists the provided types that Type is assignable from,
+	// when an injector marked //wire:assignable could use more than one.
+	Assignable []string
+}
+
+func (e *MissingProviderError) Error() string {
+	note := ""
+	if len(e.Strategies) > 0 {
+		note = fmt.Sprintf(" (its providers are marked //wire:strategy %s, and the injector selects none of them)", strings.Join(e.Strategies, ", "))
+	}
+	if len(e.Assignable) > 0 {
+		note = fmt.Sprintf(" (it is assignable from %s, so the choice is ambiguous)", strings.Join(e.Assignable, ", "))
+	}
+	if len(e.NeededBy) == 0 {
+		return fmt.Sprintf("no provider found for %s, output of injector%s", e.Type, note)
+	}
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "no provider found for %s%s", e.Type, note)
+	for _, step := range e.NeededBy {
+		fmt.Fprintf(sb, "\nneeded by %s in %s", step.Type, step.Source)
+	}
+	return sb.String()
+}
+
+// A CycleStep is one provider in a dependency cycle.
+type CycleStep struct {
+	// Type is the type produced by Provider.
+	Type string
+	// Provider identifies the provider of Type. It is either an import path
+	// and function name ("example.com/foo.NewFoo") or a struct type and
+	// field name for wire.FieldsOf.
+	Provider string
+}
+
+// CycleError is reported when providers depend on each other in a cycle.
+type CycleError struct {
+	// Type is the type at which the cycle was detected.
+	Type string
+	// Path lists the providers that form the cycle, starting and ending
+	// with a dependency on Type.
+	Path []CycleStep
+}
+
+func (e *CycleError) Error() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "cycle for %s:\n", e.Type)
+	for _, step := range e.Path {
+		fmt.Fprintf(sb, "%s (%s) ->\n", step.Type, step.Provider)
+	}
+	sb.WriteString(e.Type)
+	return sb.String()
+}
+
+// ConflictError is reported when a provider set has more than one way to
+// provide the same type.
+type ConflictError struct {
+	// SetName is the name of the provider set variable, if any.
+	SetName string
+	// Type is the type with multiple bindings.
+	Type string
+	// Current and Previous trace the two conflicting sources of Type,
+	// outermost first.
+	Current  []string
+	Previous []string
+}
+
+func (e *ConflictError) Error() string {
+	sb := new(strings.Builder)
+	if e.SetName != "" {
+		fmt.Fprintf(sb, "%s has ", e.SetName)
+	}
+	fmt.Fprintf(sb, "multiple bindings for %s\n", e.Type)
+	fmt.Fprintf(sb, "current:\n<- %s\n", strings.Join(e.Current, "\n<- "))
+	fmt.Fprintf(sb, "previous:\n<- %s", strings.Join(e.Previous, "\n<- "))
+	return sb.String()
+}
+
+// SignatureError is reported when a provider or injector function has a
+// signature that Wire cannot use.
+type SignatureError struct {
+	// Func is the name of the provider or injector function.
+	Func string
+	// Injector is true if Func is an injector rather than a provider.
+	Injector bool
+	// Err describes the problem with the signature.
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	if e.Injector {
+		// Injector errors are already prefixed with the injector's name.
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("wrong signature for provider %s: %v", e.Func, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// PackageError is reported when a package cannot be loaded, parsed, or
+// type-checked. Wire needs the packages it analyzes to compile, so these
+// errors are reported before any providers are resolved; they point at the
+// package's code rather than at its provider sets.
+type PackageError struct {
+	// PkgPath is the import path of the package.
+	PkgPath string
+	// Pos is the position of the error as "file:line:col", or empty if it
+	// is unknown.
+	Pos string
+	// Msg is the error reported by the go command, parser, or type checker.
+	Msg string
+	// Kind tells which of them reported the error.
+	Kind packages.ErrorKind
+	// Excluded is the path of a file of the package that declares the
+	// undefined identifier of a type error, but is excluded by the
+	// wireinject build tag, or empty.
+	Excluded string
+}
+
+func (e *PackageError) Error() string {
+	msg := e.Msg
+	if e.Kind == packages.ParseError || e.Kind == packages.TypeError {
+		msg = fmt.Sprintf("package %s does not compile: %s", e.PkgPath, msg)
+	}
+	if e.Excluded != "" {
+		msg += fmt.Sprintf(" (declared in %s, which the wireinject build tag excludes; move the declaration to a file without the !wireinject constraint)", e.Excluded)
+	}
+	if e.Pos == "" {
+		return msg
+	}
+	return e.Pos + ": " + msg
+}
+
+// packageErrors returns the errors of pkg as PackageErrors.
+func packageErrors(pkg *packages.Package) []error {
+	var errs []error
+	for _, e := range pkg.Errors {
+		errs = append(errs, &PackageError{PkgPath: pkg.PkgPath, Pos: e.Pos, Msg: e.Msg, Kind: e.Kind})
+	}
+	return errs
+}
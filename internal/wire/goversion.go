@@ -0,0 +1,112 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"fmt"
+	"go/types"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// The first Go 1.x releases that support language features and APIs used by
+// generated code.
+const (
+	// wrapErrorsMinorVersion supports the %w verb of fmt.Errorf, which
+	// GenerateOptions.WrapErrors uses.
+	wrapErrorsMinorVersion = 13
+	// testCleanupMinorVersion supports testing.T.Cleanup, which test kits
+	// use to call cleanup functions.
+	testCleanupMinorVersion = 14
+	// genericsMinorVersion supports generics.
+	genericsMinorVersion = 18
+)
+
+// parseGoVersion parses a Go language version such as "go1.17" or "go1.17.3"
+// and returns its minor version. An empty string is interpreted as the
+// version of the Go toolchain running Wire, or 0 (meaning no limit) if that
+// version is not a release.
+func parseGoVersion(v string) (int, error) {
+	if v == "" {
+		minor, err := parseGoVersion(runtime.Version())
+		if err != nil {
+			return 0, nil
+		}
+		return minor, nil
+	}
+	rest := strings.TrimPrefix(v, "go1.")
+	if rest == v {
+		return 0, fmt.Errorf("invalid Go version %q: must be of the form go1.N", v)
+	}
+	// Drop any patch or pre-release suffix, as in "go1.17.3" or "go1.18rc1".
+	if i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		rest = rest[:i]
+	}
+	minor, err := strconv.Atoi(rest)
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("invalid Go version %q: must be of the form go1.N", v)
+	}
+	return minor, nil
+}
+
+// genericType returns the first instantiated generic type or type parameter
+// found in t, or nil if t does not use generics.
+func genericType(t types.Type) types.Type {
+	switch t := t.(type) {
+	case *types.Named:
+		if len(namedTypeArgs(t)) > 0 {
+			return t
+		}
+	case *types.Pointer:
+		return genericType(t.Elem())
+	case *types.Slice:
+		return genericType(t.Elem())
+	case *types.Array:
+		return genericType(t.Elem())
+	case *types.Chan:
+		return genericType(t.Elem())
+	case *types.Map:
+		if g := genericType(t.Key()); g != nil {
+			return g
+		}
+		return genericType(t.Elem())
+	case *types.Signature:
+		if g := genericTupleType(t.Params()); g != nil {
+			return g
+		}
+		return genericTupleType(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if g := genericType(t.Field(i).Type()); g != nil {
+				return g
+			}
+		}
+	default:
+		if isTypeParam(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+func genericTupleType(tuple *types.Tuple) types.Type {
+	for i := 0; i < tuple.Len(); i++ {
+		if g := genericType(tuple.At(i).Type()); g != nil {
+			return g
+		}
+	}
+	return nil
+}
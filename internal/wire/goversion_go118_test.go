@@ -0,0 +1,58 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package wire
+
+import (
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestGenericType(t *testing.T) {
+	pkg := types.NewPackage("example.com/foo", "foo")
+	tparam := types.NewTypeParam(types.NewTypeName(token.NoPos, pkg, "T", nil), types.NewInterfaceType(nil, nil))
+	box := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Box", nil), nil, nil)
+	box.SetTypeParams([]*types.TypeParam{tparam})
+	box.SetUnderlying(types.NewStruct([]*types.Var{types.NewField(token.NoPos, pkg, "V", tparam, false)}, nil))
+	boxInt, err := types.Instantiate(nil, box, []types.Type{types.Typ[types.Int]}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Plain", nil), types.NewStruct(nil, nil), nil)
+
+	tests := []struct {
+		name string
+		t    types.Type
+		want bool
+	}{
+		{"Basic", types.Typ[types.Int], false},
+		{"Named", plain, false},
+		{"PointerToNamed", types.NewPointer(plain), false},
+		{"Instantiated", boxInt, true},
+		{"PointerToInstantiated", types.NewPointer(boxInt), true},
+		{"SliceOfInstantiated", types.NewSlice(boxInt), true},
+		{"MapOfInstantiated", types.NewMap(types.Typ[types.String], boxInt), true},
+		{"FuncReturningInstantiated", types.NewSignature(nil, nil, types.NewTuple(types.NewVar(token.NoPos, pkg, "", boxInt)), false), true},
+		{"TypeParam", tparam, true},
+	}
+	for _, test := range tests {
+		if got := genericType(test.t) != nil; got != test.want {
+			t.Errorf("genericType(%s) != nil = %t; want %t", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// A Graph is the resolved dependency graph of the injectors in a set of
+// packages.
+type Graph struct {
+	Fset *token.FileSet
+
+	// Injectors holds the graph of each injector, in the order they are
+	// declared.
+	Injectors []*InjectorGraph
+}
+
+// An InjectorGraph is the dependency graph of a single injector. Types are
+// identified by their canonical string form, as returned by types.TypeString
+// with a nil qualifier.
+type InjectorGraph struct {
+	Injector *Injector
+
+	// Output is the type returned by the injector. It is empty for an
+	// injector that calls wire.Use.
+	Output string
+
+	// Nodes maps each type used by the injector to how it is provided.
+	Nodes map[string]*GraphNode
+}
+
+// A NodeKind describes how a type in a Graph is provided.
+type NodeKind int
+
+// Kinds of graph nodes.
+const (
+	// ArgNode is an argument to the injector.
+	ArgNode NodeKind = iota
+	// ProviderNode is the result of calling a provider function.
+	ProviderNode
+	// StructNode is a struct built from its fields.
+	StructNode
+	// ValueNode is an expression passed to wire.Value or
+	// wire.InterfaceValue.
+	ValueNode
+	// FieldNode is a field of another type in the graph.
+	FieldNode
+	// BindingNode is an interface bound to a concrete type.
+	BindingNode
+	// AssertionNode is the result of a type assertion declared with
+	// wire.As.
+	AssertionNode
+	// GroupNode is a slice of the members of a wire.Group.
+	GroupNode
+	// DerefNode is a struct or a pointer to it, converted from the other
+	// by GenerateOptions.AutoDeref.
+	DerefNode
+)
+
+// MarshalText encodes the kind as its String form, so that a Graph encoded
+// as JSON names the kind of each node.
+func (k NodeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// String returns a lowercase name for the kind, such as "provider".
+func (k NodeKind) String() string {
+	switch k {
+	case ArgNode:
+		return "arg"
+	case ProviderNode:
+		return "provider"
+	case StructNode:
+		return "struct"
+	case ValueNode:
+		return "value"
+	case FieldNode:
+		return "field"
+	case BindingNode:
+		return "binding"
+	case AssertionNode:
+		return "assertion"
+	case GroupNode:
+		return "group"
+	case DerefNode:
+		return "deref"
+	default:
+		return "NodeKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// A GraphNode describes how a single type in an injector is provided.
+type GraphNode struct {
+	// Type is the type this node provides.
+	Type string
+
+	Kind NodeKind
+
+	// Provider identifies what provides the type: a provider function or
+	// struct type as ""path/to/pkg".Name" for ProviderNode and StructNode,
+	// or the field name for FieldNode. It is empty for other kinds.
+	Provider string
+
+	// Deps lists the types this node depends on, in argument order. A
+	// BindingNode depends on the concrete type bound to the interface.
+	Deps []string
+
+	// Pos is the position of the provider, value, or field. It is the
+	// zero Position for arguments and bindings.
+	Pos token.Position
+
+	// Annotations holds the metadata attached to the provider function or
+	// struct with wire.Annotate.
+	Annotations []Annotation
+
+	// Wrappers lists the functions passed to wire.Wrap that the value is
+	// passed through, in the order they are applied, as
+	// ""path/to/pkg".Name". Their other arguments are included in Deps.
+	Wrappers []string
+}
+
+// ResolveGraph loads the packages that match the given patterns and
+// resolves the dependency graph of each injector they declare. Injectors
+// that fail to resolve are reported as errors and omitted from the Graph.
+// The arguments are interpreted as they are by Load.
+func ResolveGraph(ctx context.Context, wd string, env []string, tags string, patterns []string) (*Graph, []error) {
+	pkgs, errs := load(ctx, wd, env, tags, patterns, false)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(pkgs) == 0 {
+		return new(Graph), nil
+	}
+	g := &Graph{Fset: pkgs[0].Fset}
+	oc := newObjectCache(pkgs)
+	ec := new(errorCollector)
+	for _, pkg := range pkgs {
+		if isWireImport(pkg.PkgPath) {
+			// The marker function package confuses analysis.
+			continue
+		}
+		injectors, errs := oc.solveInjectors(pkg, false)
+		ec.add(errs...)
+		for _, inj := range injectors {
+			g.Injectors = append(g.Injectors, injectorGraph(g.Fset, inj))
+		}
+	}
+	return g, ec.errors
+}
+
+// injectorGraph converts a solved injector into its graph.
+func injectorGraph(fset *token.FileSet, inj *solvedInjector) *InjectorGraph {
+	output := inj.out
+	if inj.addr {
+		output = types.NewPointer(output)
+	}
+	if inj.closure != nil {
+		output = inj.closure
+	}
+	ig := &InjectorGraph{
+		Injector: inj.injector,
+		Nodes:    make(map[string]*GraphNode),
+	}
+	if output != nil {
+		ig.Output = types.TypeString(output, nil)
+	}
+	// typeAt returns the type produced by a given or call, using the same
+	// numbering as call.args.
+	typeAt := func(i int) types.Type {
+		if i < inj.ins.Len() {
+			return inj.ins.At(i).Type()
+		}
+		return inj.calls[i-inj.ins.Len()].out
+	}
+	// addBinding records that t is satisfied by the concrete type at index i.
+	addBinding := func(t types.Type, i int) {
+		concrete := typeAt(i)
+		if types.Identical(t, concrete) {
+			return
+		}
+		ts := types.TypeString(t, nil)
+		if ig.Nodes[ts] == nil {
+			ig.Nodes[ts] = &GraphNode{
+				Type: ts,
+				Kind: BindingNode,
+				Deps: []string{types.TypeString(concrete, nil)},
+			}
+		}
+	}
+	for i := 0; i < inj.ins.Len(); i++ {
+		ts := types.TypeString(inj.ins.At(i).Type(), nil)
+		ig.Nodes[ts] = &GraphNode{Type: ts, Kind: ArgNode}
+	}
+	for _, c := range inj.calls {
+		if c.wrapper {
+			var deps []string
+			for j, t := range c.ins {
+				addBinding(t, c.args[j])
+				if !types.Identical(t, c.out) {
+					deps = append(deps, types.TypeString(t, nil))
+				}
+			}
+			// The node of the unwrapped value, which may be an interface
+			// bound to a concrete type, precedes its wrappers.
+			n := ig.Nodes[types.TypeString(c.out, nil)]
+			n.Wrappers = append(n.Wrappers, strconv.Quote(c.pkg.Path())+"."+c.name)
+			n.Deps = append(n.Deps, deps...)
+			continue
+		}
+		n := &GraphNode{Type: types.TypeString(c.out, nil)}
+		pv := inj.set.For(c.out)
+		switch c.kind {
+		case funcProviderCall, interfaceSelect:
+			n.Kind = ProviderNode
+			n.Provider = strconv.Quote(c.pkg.Path()) + "." + c.name
+			n.Pos = fset.Position(pv.Provider().Pos)
+			n.Annotations = pv.Provider().Annotations
+		case structProvider:
+			n.Kind = StructNode
+			n.Provider = strconv.Quote(c.pkg.Path()) + "." + c.name
+			n.Pos = fset.Position(pv.Provider().Pos)
+			n.Annotations = pv.Provider().Annotations
+		case valueExpr:
+			n.Kind = ValueNode
+			n.Pos = fset.Position(pv.Value().Pos)
+		case typeAssertExpr:
+			n.Kind = AssertionNode
+			n.Pos = fset.Position(pv.Provider().Pos)
+		case implementStruct:
+			n.Kind = StructNode
+			n.Pos = fset.Position(pv.Provider().Pos)
+		case groupSlice:
+			n.Kind = GroupNode
+			n.Pos = fset.Position(pv.Provider().Pos)
+		case derefExpr:
+			n.Kind = DerefNode
+			n.Pos = fset.Position(pv.Provider().Pos)
+		case selectorExpr:
+			n.Kind = FieldNode
+			n.Provider = c.name
+			n.Pos = fset.Position(pv.Field().Pos)
+			// Fields depend on their parent struct, which is not listed in
+			// ins.
+			n.Deps = []string{types.TypeString(typeAt(c.args[0]), nil)}
+		}
+		for j, t := range c.ins {
+			n.Deps = append(n.Deps, types.TypeString(t, nil))
+			addBinding(t, c.args[j])
+		}
+		ig.Nodes[n.Type] = n
+	}
+	// The injector's output may itself be an interface bound to a
+	// concrete type.
+	if inj.out != nil {
+		addBinding(inj.out, outputIndex(inj.calls, inj.ins.Len(), inj.set, inj.out))
+	}
+	return ig
+}
+
+// A ProviderRef identifies an entry of a provider set that an injector uses.
+type ProviderRef struct {
+	Kind NodeKind
+
+	// Name identifies the entry as GraphNode.Provider does: a provider
+	// function or struct type as ""path/to/pkg".Name, or a field name. It
+	// is empty for values, bindings, and assertions.
+	Name string
+
+	// Type is the type the injector uses the entry for. For a binding, it
+	// is the interface type.
+	Type string
+
+	// Pos is the position of the provider function, struct type, field,
+	// wire.Value call, or wire.Bind call.
+	Pos token.Position
+}
+
+// MinimalSet loads the packages that match the given patterns and returns
+// the providers, values, fields, and interface bindings that the injector
+// named injector actually uses, ordered by position. A provider set made of
+// just these entries is enough to build the injector. The other arguments
+// are interpreted as they are by Load.
+func MinimalSet(ctx context.Context, wd string, env []string, tags string, patterns []string, injector string) ([]ProviderRef, []error) {
+	pkgs, errs := load(ctx, wd, env, tags, patterns, false)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	oc := newObjectCache(pkgs)
+	ec := new(errorCollector)
+	var found []*solvedInjector
+	for _, pkg := range pkgs {
+		if isWireImport(pkg.PkgPath) {
+			// The marker function package confuses analysis.
+			continue
+		}
+		injectors, errs := oc.solveInjectors(pkg, false)
+		ec.add(errs...)
+		for _, inj := range injectors {
+			if inj.injector.FuncName == injector {
+				found = append(found, inj)
+			}
+		}
+	}
+	switch {
+	case len(found) == 1:
+		return minimalSet(pkgs[0].Fset, found[0]), nil
+	case len(found) > 1:
+		return nil, []error{fmt.Errorf("injector %s is declared in more than one package", injector)}
+	case len(ec.errors) > 0:
+		return nil, ec.errors
+	default:
+		return nil, []error{fmt.Errorf("no injector named %s", injector)}
+	}
+}
+
+// minimalSet returns the set entries used by a solved injector.
+func minimalSet(fset *token.FileSet, inj *solvedInjector) []ProviderRef {
+	var refs []ProviderRef
+	seen := make(map[interface{}]bool)
+	// add records the entry that provides t, unless it is an injector
+	// argument or was already recorded.
+	add := func(t types.Type) {
+		src := leafSrc(inj.set, t)
+		if src == nil {
+			return
+		}
+		ref := ProviderRef{Type: types.TypeString(t, nil)}
+		var key interface{}
+		switch {
+		case src.Provider != nil && src.Provider.IsGroup:
+			// The group's members are recorded as its dependencies.
+			return
+		case src.Provider != nil:
+			p := src.Provider
+			key = p
+			switch {
+			case p.IsStruct:
+				ref.Kind = StructNode
+			case p.TypeAssert:
+				ref.Kind = AssertionNode
+			case p.Implement != nil:
+				ref.Kind = StructNode
+			default:
+				ref.Kind = ProviderNode
+			}
+			if !p.TypeAssert && p.Implement == nil {
+				ref.Name = strconv.Quote(p.Pkg.Path()) + "." + p.Name
+			}
+			ref.Pos = fset.Position(p.Pos)
+		case src.Binding != nil:
+			key = src.Binding
+			ref.Kind = BindingNode
+			ref.Pos = fset.Position(src.Binding.Pos)
+		case src.Value != nil:
+			key = src.Value
+			ref.Kind = ValueNode
+			ref.Pos = fset.Position(src.Value.Pos)
+		case src.Field != nil:
+			key = src.Field
+			ref.Kind = FieldNode
+			ref.Name = src.Field.Name
+			ref.Pos = fset.Position(src.Field.Pos)
+		default:
+			return
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+	if inj.out != nil {
+		add(inj.out)
+	}
+	for _, c := range inj.calls {
+		add(c.out)
+		for _, t := range c.ins {
+			add(t)
+		}
+		if c.kind == selectorExpr {
+			// Fields depend on their parent struct, which is not listed in
+			// ins.
+			add(inj.set.For(c.out).Field().Parent)
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		pi, pj := refs[i].Pos, refs[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		if pi.Offset != pj.Offset {
+			return pi.Offset < pj.Offset
+		}
+		return refs[i].Type < refs[j].Type
+	})
+	return refs
+}
+
+// leafSrc returns the source of t in set, following imported sets down to
+// the entry that provides it. It returns nil if set does not provide t.
+func leafSrc(set *ProviderSet, t types.Type) *providerSetSrc {
+	src, _ := set.srcMap.At(t).(*providerSetSrc)
+	for src != nil && src.Import != nil {
+		src, _ = src.Import.srcMap.At(t).(*providerSetSrc)
+	}
+	return src
+}
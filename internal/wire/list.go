@@ -0,0 +1,91 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// An InjectorInfo describes an injector function as it is declared.
+type InjectorInfo struct {
+	Injector *Injector
+
+	// Signature is the injector's function type, such as
+	// "func(ctx context.Context) (*Server, error)". Types from the
+	// injector's package are not qualified.
+	Signature string
+
+	// Sets holds the arguments of the injector's call to wire.Build or
+	// wire.BuildOnce as they are written, such as "ServerSet" or
+	// "new(Config)".
+	Sets []string
+
+	// Pos is the position of the injector function.
+	Pos token.Position
+}
+
+// ListInjectors loads the packages that match the given patterns and
+// describes the injectors they declare, in the order they are declared.
+// Unlike Generate and ResolveGraph, it does not solve the injectors'
+// dependency graphs, so injectors whose providers are missing or conflict
+// are listed too. The arguments are interpreted as they are by Load.
+func ListInjectors(ctx context.Context, wd string, env []string, tags string, patterns []string) ([]*InjectorInfo, []error) {
+	pkgs, errs := load(ctx, wd, env, tags, patterns, false)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	var infos []*InjectorInfo
+	ec := new(errorCollector)
+	for _, pkg := range pkgs {
+		if isWireImport(pkg.PkgPath) {
+			// The marker function package confuses analysis.
+			continue
+		}
+		qual := types.RelativeTo(pkg.Types)
+		for _, f := range pkg.Syntax {
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || skipInjector(fn, pkg.Name, false) {
+					continue
+				}
+				buildCall, err := findInjectorBuild(pkg.TypesInfo, fn)
+				if err != nil {
+					ec.add(notePosition(pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+					continue
+				}
+				if buildCall == nil {
+					continue
+				}
+				info := &InjectorInfo{
+					Injector: &Injector{
+						ImportPath: pkg.PkgPath,
+						FuncName:   fn.Name.Name,
+					},
+					Signature: types.TypeString(pkg.TypesInfo.ObjectOf(fn.Name).Type(), qual),
+					Pos:       pkg.Fset.Position(fn.Pos()),
+				}
+				for _, arg := range buildCall.Args {
+					info.Sets = append(info.Sets, types.ExprString(arg))
+				}
+				infos = append(infos, info)
+			}
+		}
+	}
+	return infos, ec.errors
+}
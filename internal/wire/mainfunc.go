@@ -0,0 +1,215 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/types"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// DefaultMainTemplate is the template used to generate the function named by
+// GenerateOptions.MainFunc when GenerateOptions.MainTemplate is empty. It
+// calls the injector, calls the Run method of its result, and exits through
+// log.Fatal if either fails.
+const DefaultMainTemplate = `func {{.Func}}() {
+	{{.Value}}{{if .HasCleanup}}, {{.Cleanup}}{{end}}{{if .HasErr}}, {{.Err}}{{end}} := {{.Injector}}()
+{{- if .HasErr}}
+	if {{.Err}} != nil {
+		{{import "log"}}.Fatal({{.Err}})
+	}
+{{- end}}
+{{- if .RunErr}}
+	{{if .HasErr}}{{.Err}} = {{else}}{{.Err}} := {{end}}{{.Value}}.Run()
+{{- if .HasCleanup}}
+	{{.Cleanup}}()
+{{- end}}
+	if {{.Err}} != nil {
+		{{import "log"}}.Fatal({{.Err}})
+	}
+{{- else}}
+{{- if .HasCleanup}}
+	defer {{.Cleanup}}()
+{{- end}}
+	{{.Value}}.Run()
+{{- end}}
+}
+`
+
+// MainTemplateData is the data a main function template is executed with.
+// Templates may also call import with a package path to get the name that
+// generated code uses to refer to the package, such as {{import "log"}}.
+type MainTemplateData struct {
+	// Func is the name of the function to generate.
+	Func string
+
+	// Injector is the name of the injector to call.
+	Injector string
+
+	// Value, Cleanup, and Err are unused names for the injector's results.
+	Value   string
+	Cleanup string
+	Err     string
+
+	// HasCleanup and HasErr report whether the injector returns a cleanup
+	// function and an error.
+	HasCleanup bool
+	HasErr     bool
+
+	// HasRun reports whether the injector's output has a Run method that
+	// takes no arguments and returns nothing or an error. RunErr reports
+	// whether it returns an error.
+	HasRun bool
+	RunErr bool
+}
+
+// mainTemplate parses the main function template selected by opts.
+func mainTemplate(opts *GenerateOptions) (*template.Template, error) {
+	text := opts.MainTemplate
+	if text == "" {
+		text = DefaultMainTemplate
+	}
+	t, err := template.New("main").Funcs(template.FuncMap{
+		"import": func(string) string { return "" },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("main template: %v", err)
+	}
+	return t, nil
+}
+
+// mainFunc emits the function named by opts.MainFunc, which calls the only
+// injector that g generated.
+func (g *gen) mainFunc(opts *GenerateOptions) error {
+	if len(g.injectors) != 1 {
+		return fmt.Errorf("generating %s requires exactly one injector in package; found %d", opts.MainFunc, len(g.injectors))
+	}
+	inj := g.injectors[0]
+	if g.nameInFileScope(opts.MainFunc) {
+		return fmt.Errorf("cannot generate %s: name is already declared", opts.MainFunc)
+	}
+	if injectorInputs(inj.sig).Len() > 0 {
+		return fmt.Errorf("cannot generate %s: injector %s has parameters or a receiver", opts.MainFunc, inj.name)
+	}
+	if inj.use {
+		return fmt.Errorf("cannot generate %s: injector %s uses wire.Use", opts.MainFunc, inj.name)
+	}
+	out, err := funcOutput(inj.sig)
+	if err != nil {
+		// This should be checked by the caller already.
+		panic(err)
+	}
+	var names []string
+	local := func(name string) string {
+		n := disambiguate(name, func(n string) bool {
+			if n == inj.name || n == opts.MainFunc || g.nameInFileScope(n) {
+				return true
+			}
+			for _, other := range names {
+				if n == other {
+					return true
+				}
+			}
+			return false
+		})
+		names = append(names, n)
+		return n
+	}
+	data := &MainTemplateData{
+		Func:       opts.MainFunc,
+		Injector:   inj.name,
+		Value:      local("app"),
+		Cleanup:    local("cleanup"),
+		Err:        local("err"),
+		HasCleanup: out.cleanup,
+		HasErr:     out.err,
+	}
+	data.HasRun, data.RunErr = runMethod(out.out, g.pkg.Types)
+	if opts.MainTemplate == "" && !data.HasRun {
+		return fmt.Errorf("cannot generate %s: %s has no Run method", opts.MainFunc, types.TypeString(out.out, nil))
+	}
+	t, err := mainTemplate(opts)
+	if err != nil {
+		return err
+	}
+	t.Funcs(template.FuncMap{
+		"import": func(path string) (string, error) {
+			if path == "" {
+				return "", errors.New("import of empty path")
+			}
+			return g.importPath(path), nil
+		},
+	})
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return fmt.Errorf("main template: %v", err)
+	}
+	g.declared[opts.MainFunc] = true
+	g.p("%s\n", bytes.TrimSpace(buf.Bytes()))
+	g.p("\n")
+	return nil
+}
+
+// importPath returns the name generated code uses to refer to the package
+// with the given import path, adding an import if needed. The package name
+// is taken from the package's own imports if possible, and is otherwise
+// guessed from the last element of path.
+func (g *gen) importPath(path string) string {
+	if imp := g.pkg.Imports[path]; imp != nil {
+		return g.qualifyImport(imp.Name, path)
+	}
+	name := path[strings.LastIndex(path, "/")+1:]
+	valid := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	if _, seen := g.imports[path]; seen || valid == name {
+		return g.qualifyImport(name, path)
+	}
+	// The guess is not an identifier, so always name the import.
+	id := g.qualifyImport(valid, path)
+	info := g.imports[path]
+	info.differs = true
+	g.imports[path] = info
+	return id
+}
+
+// runMethod reports whether t has a Run method, callable from pkg, that takes
+// no arguments and returns nothing or an error, and whether it returns an
+// error.
+func runMethod(t types.Type, pkg *types.Package) (hasRun, runErr bool) {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, "Run")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false, false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() > 0 {
+		return false, false
+	}
+	switch results := sig.Results(); {
+	case results.Len() == 0:
+		return true, false
+	case results.Len() == 1 && types.Identical(results.At(0).Type(), errorType):
+		return true, true
+	}
+	return false, false
+}
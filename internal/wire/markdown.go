@@ -0,0 +1,190 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// markdownFileName is the name of the file that GenerateOptions.EmitMarkdown
+// writes for each package, without GenerateOptions.PrefixOutputFile.
+const markdownFileName = "wire_graph.md"
+
+// graphMarkdown documents the injectors of pkg as Markdown: a table of the
+// injectors, a table of the providers they call, and a Mermaid diagram of
+// their dependency graphs. It returns nil if pkg has no injectors. The
+// injectors are solved as GenerateOptions.AutoDeref does if autoDeref is
+// true.
+func graphMarkdown(pkg *packages.Package, oc *objectCache, autoDeref bool) ([]byte, []error) {
+	injectors, errs := oc.solveInjectors(pkg, autoDeref)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(injectors) == 0 {
+		return nil, nil
+	}
+	q := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<!-- Code generated by Wire. DO NOT EDIT. -->\n\n")
+	fmt.Fprintf(&buf, "# Dependency graph of `%s`\n\n", pkg.PkgPath)
+
+	buf.WriteString("## Injectors\n\n")
+	buf.WriteString("| Injector | Signature |\n")
+	buf.WriteString("| --- | --- |\n")
+	for _, inj := range injectors {
+		fmt.Fprintf(&buf, "| `%s` | `%s` |\n", inj.injector.FuncName, markdownCell(types.TypeString(inj.sig, q)))
+	}
+
+	type providerRow struct {
+		name, path, sig string
+	}
+	var rows []providerRow
+	seen := make(map[*Provider]bool)
+	for _, inj := range injectors {
+		for _, c := range inj.calls {
+			if c.kind != funcProviderCall && c.kind != structProvider && c.kind != interfaceSelect || c.wrapper {
+				continue
+			}
+			p := inj.set.For(c.out).Provider()
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			rows = append(rows, providerRow{
+				name: p.Name,
+				path: p.Pkg.Path(),
+				sig:  providerSignature(p, q),
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].path != rows[j].path {
+			return rows[i].path < rows[j].path
+		}
+		return rows[i].name < rows[j].name
+	})
+	buf.WriteString("\n## Providers\n\n")
+	buf.WriteString("| Provider | Import Path | Signature |\n")
+	buf.WriteString("| --- | --- | --- |\n")
+	for _, r := range rows {
+		fmt.Fprintf(&buf, "| `%s` | `%s` | `%s` |\n", r.name, r.path, markdownCell(r.sig))
+	}
+
+	buf.WriteString("\n## Dependency Graph\n\n")
+	buf.WriteString("```mermaid\nflowchart LR\n")
+	writeMermaid(&buf, pkg.Fset, injectors, q)
+	buf.WriteString("```\n")
+	return buf.Bytes(), nil
+}
+
+// writeMermaid writes the nodes and edges of a Mermaid flowchart of the
+// dependency graphs of injectors to buf. Each type is a node, shared by the
+// injectors that use it, with an edge to each type that depends on it, and
+// each injector is a node with an edge from its output.
+func writeMermaid(buf *bytes.Buffer, fset *token.FileSet, injectors []*solvedInjector, q types.Qualifier) {
+	// labels maps the canonical string of each type to its string in the
+	// document.
+	labels := make(map[string]string)
+	addLabel := func(t types.Type) {
+		labels[types.TypeString(t, nil)] = types.TypeString(t, q)
+	}
+	graphs := make([]*InjectorGraph, len(injectors))
+	for i, inj := range injectors {
+		graphs[i] = injectorGraph(fset, inj)
+		for j := 0; j < inj.ins.Len(); j++ {
+			addLabel(inj.ins.At(j).Type())
+		}
+		for _, c := range inj.calls {
+			addLabel(c.out)
+			for _, t := range c.ins {
+				addLabel(t)
+			}
+		}
+		if inj.out != nil {
+			addLabel(inj.out)
+		}
+	}
+	nodes := make(map[string]*GraphNode)
+	for _, g := range graphs {
+		for t, n := range g.Nodes {
+			if nodes[t] == nil || nodes[t].Kind == ArgNode {
+				nodes[t] = n
+			}
+		}
+	}
+	keys := make([]string, 0, len(nodes))
+	for t := range nodes {
+		keys = append(keys, t)
+	}
+	sort.Strings(keys)
+	ids := make(map[string]string, len(keys))
+	for i, t := range keys {
+		ids[t] = fmt.Sprintf("t%d", i)
+		label := labels[t]
+		if label == "" {
+			label = t
+		}
+		label = strings.Replace(label, `"`, "#quot;", -1)
+		if nodes[t].Kind == ArgNode {
+			fmt.Fprintf(buf, "\t%s([\"%s\"])\n", ids[t], label)
+		} else {
+			fmt.Fprintf(buf, "\t%s[\"%s\"]\n", ids[t], label)
+		}
+	}
+	for i, inj := range injectors {
+		fmt.Fprintf(buf, "\ti%d[[\"%s\"]]\n", i, inj.injector.FuncName)
+	}
+	edges := make(map[string]bool)
+	for _, t := range keys {
+		for _, g := range graphs {
+			n := g.Nodes[t]
+			if n == nil {
+				continue
+			}
+			for _, dep := range n.Deps {
+				e := fmt.Sprintf("\t%s --> %s\n", ids[dep], ids[t])
+				if !edges[e] {
+					edges[e] = true
+					buf.WriteString(e)
+				}
+			}
+		}
+	}
+	for i, g := range graphs {
+		if g.Output == "" {
+			continue
+		}
+		if id := ids[g.Output]; id != "" {
+			fmt.Fprintf(buf, "\t%s --> i%d\n", id, i)
+		}
+	}
+}
+
+// markdownCell escapes s for use in a cell of a Markdown table.
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
@@ -19,12 +19,19 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/parser"
+	"go/printer"
 	"go/token"
 	"go/types"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/packages"
@@ -40,6 +47,7 @@ type providerSetSrc struct {
 	Import      *ProviderSet
 	InjectorArg *InjectorArg
 	Field       *Field
+	Wrap        *Wrap
 }
 
 // description returns a string describing the source of p, including line numbers.
@@ -51,6 +59,10 @@ func (p *providerSetSrc) description(fset *token.FileSet, typ types.Type) string
 		return fmt.Sprintf("%q ", s)
 	}
 	switch {
+	case p.Provider != nil && p.Provider.IsGroup:
+		return fmt.Sprintf("wire.Group (%s)", fset.Position(p.Provider.Pos))
+	case p.Provider != nil && p.Provider.Implement != nil:
+		return fmt.Sprintf("wire.Implement (%s)", fset.Position(p.Provider.Pos))
 	case p.Provider != nil:
 		kind := "provider"
 		if p.Provider.IsStruct {
@@ -58,7 +70,9 @@ func (p *providerSetSrc) description(fset *token.FileSet, typ types.Type) string
 		}
 		return fmt.Sprintf("%s %s(%s)", kind, quoted(p.Provider.Name), fset.Position(p.Provider.Pos))
 	case p.Binding != nil:
-		return fmt.Sprintf("wire.Bind (%s)", fset.Position(p.Binding.Pos))
+		// Name the bound type, so that competing bindings of an
+		// interface can be told apart.
+		return fmt.Sprintf("wire.Bind to %s (%s)", types.TypeString(p.Binding.Provided, nil), fset.Position(p.Binding.Pos))
 	case p.Value != nil:
 		return fmt.Sprintf("wire.Value (%s)", fset.Position(p.Value.Pos))
 	case p.Import != nil:
@@ -68,6 +82,8 @@ func (p *providerSetSrc) description(fset *token.FileSet, typ types.Type) string
 		return fmt.Sprintf("argument %s to injector function %s (%s)", args.Tuple.At(p.InjectorArg.Index).Name(), args.Name, fset.Position(args.Pos))
 	case p.Field != nil:
 		return fmt.Sprintf("wire.FieldsOf (%s)", fset.Position(p.Field.Pos))
+	case p.Wrap != nil:
+		return fmt.Sprintf("wire.Wrap (%s)", fset.Position(p.Wrap.Pos))
 	}
 	panic("providerSetSrc with no fields set")
 }
@@ -86,6 +102,19 @@ func (p *providerSetSrc) trace(fset *token.FileSet, typ types.Type) []string {
 	return retval
 }
 
+// binding returns the wire.Bind call that p provides typ with, following
+// imports, or nil if typ is not provided by a binding.
+func (p *providerSetSrc) binding(typ types.Type) *IfaceBinding {
+	for p.Import != nil {
+		parent := p.Import.srcMap.At(typ)
+		if parent == nil {
+			return nil
+		}
+		p = parent.(*providerSetSrc)
+	}
+	return p.Binding
+}
+
 // A ProviderSet describes a set of providers.  The zero value is an empty
 // ProviderSet.
 type ProviderSet struct {
@@ -102,10 +131,19 @@ type ProviderSet struct {
 	Bindings  []*IfaceBinding
 	Values    []*Value
 	Fields    []*Field
+	Wraps     []*Wrap
 	Imports   []*ProviderSet
 	// InjectorArgs is only filled in for wire.Build.
 	InjectorArgs *InjectorArgs
 
+	// decorate is true for sets created by wire.Decorate. The set's own
+	// entries then take precedence over those of its single import.
+	decorate bool
+
+	// overrides is true for sets created for wire.Override. The entries of
+	// the set's last import then take precedence over those of the others.
+	overrides bool
+
 	// providerMap maps from provided type to a *ProvidedType.
 	// It includes all of the imported types.
 	providerMap *typeutil.Map
@@ -113,12 +151,38 @@ type ProviderSet struct {
 	// srcMap maps from provided type to a *providerSetSrc capturing the
 	// Provider, Binding, Value, or Import that provided the type.
 	srcMap *typeutil.Map
+
+	// wrapMap maps from wrapped type to the *Wrap that applies to it,
+	// including those of imported sets. It is nil if no Wrap applies.
+	wrapMap *typeutil.Map
+
+	// wrapSrcMap maps from wrapped type to a *providerSetSrc capturing the
+	// Wrap or Import that wraps the type.
+	wrapSrcMap *typeutil.Map
+
+	// strategyMap maps from provided type to the []*strategyProvider
+	// alternatives for it: the providers marked //wire:strategy, including
+	// those of imported sets. These types are not in providerMap until an
+	// injector selects a strategy. It is nil if the set has no alternatives.
+	strategyMap *typeutil.Map
+
+	// ambiguousMap maps from a needed type to the []types.Type it is
+	// assignable from, when an injector marked //wire:assignable could use
+	// several of them. It is nil if there are none.
+	ambiguousMap *typeutil.Map
+}
+
+// A strategyProvider is a provider marked //wire:strategy that is one of the
+// alternatives for a type.
+type strategyProvider struct {
+	p   *Provider
+	src *providerSetSrc
 }
 
 // Outputs returns a new slice containing the set of possible types the
-// provider set can produce. The order is unspecified.
+// provider set can produce, sorted by their type strings.
 func (set *ProviderSet) Outputs() []types.Type {
-	return set.providerMap.Keys()
+	return sortedTypes(set.providerMap)
 }
 
 // For returns a ProvidedType for the given type, or the zero ProvidedType.
@@ -130,13 +194,25 @@ func (set *ProviderSet) For(t types.Type) ProvidedType {
 	return *pt.(*ProvidedType)
 }
 
+// wrapFor returns the Wrap that applies to values of type t, or nil.
+func (set *ProviderSet) wrapFor(t types.Type) *Wrap {
+	if set.wrapMap == nil {
+		return nil
+	}
+	w, _ := set.wrapMap.At(t).(*Wrap)
+	return w
+}
+
 // An IfaceBinding declares that a type should be used to satisfy inputs
-// of the given interface type.
+// of the given interface type, or of a type with the same underlying type.
 type IfaceBinding struct {
-	// Iface is the interface type, which is what can be injected.
+	// Iface is the interface type, which is what can be injected. It may
+	// also be a non-interface type with the same underlying type as
+	// Provided.
 	Iface types.Type
 
-	// Provided is always a type that is assignable to Iface.
+	// Provided is a type that is assignable to Iface or, if Iface is not
+	// an interface, convertible to it.
 	Provided types.Type
 
 	// Pos is the position where the binding was declared.
@@ -152,6 +228,10 @@ type Provider struct {
 	// Name is the name of the Go object.
 	Name string
 
+	// TypeArgs is the list of type arguments with which a generic provider
+	// function is instantiated, as in NewFoo[T], or nil.
+	TypeArgs []types.Type
+
 	// Pos is the source position of the func keyword or type spec
 	// defining this provider.
 	Pos token.Pos
@@ -177,6 +257,127 @@ type Provider struct {
 	// HasErr reports whether the provider function can return an error.
 	// (Always false for structs.)
 	HasErr bool
+
+	// Closes reports whether the provider function is marked with a
+	// //wire:closes directive. The output's Close method, from io.Closer,
+	// is then used as the cleanup function, or the builtin close if the
+	// output is a channel. (Always false for structs.)
+	Closes bool
+
+	// Singleton reports whether the provider function is marked with a
+	// //wire:singleton directive. A singleton provider is called at most
+	// once per package; every injector shares its result.
+	Singleton bool
+
+	// Eager reports whether the provider function is marked with a
+	// //wire:eager directive. Injectors whose provider set includes an
+	// eager provider always call it, even if nothing depends on its output.
+	Eager bool
+
+	// Lazy reports whether the provider was wrapped in a call to wire.Lazy.
+	// Out then holds a function type returning the provider function's
+	// output, and HasCleanup and HasErr describe the provider function
+	// rather than the lazy accessor.
+	Lazy bool
+
+	// NoValue reports whether the provider was passed to wire.NoValue. The
+	// provider function returns nothing, and Out holds a placeholder type
+	// unique to the function that only the injector depends on.
+	NoValue bool
+
+	// TypeAssert reports whether the provider was created by wire.As or
+	// wire.Assert. It
+	// has a single argument of interface type, which the injector asserts
+	// to Out[0] instead of calling a function. HasErr is always true, since
+	// the assertion can fail.
+	TypeAssert bool
+
+	// Select reports whether the provider was created by wire.BindInterface.
+	// The provider function returns a value of an interface type, which the
+	// injector asserts to Out[0]. SelectErr reports whether the function
+	// returns an error too. HasErr is always true, since the assertion can
+	// fail.
+	Select    bool
+	SelectErr bool
+
+	// ResultName is the name of the provider function's first result, or
+	// empty if the result is unnamed. Generated code prefers it for the
+	// variable holding the provider's output.
+	ResultName string
+
+	// Group is the slice type of the group the provider was added to with
+	// wire.Group, or nil. Out then holds a placeholder type unique to the
+	// provider and group, and GroupOut holds the provider function's output.
+	Group    types.Type
+	GroupOut types.Type
+
+	// IsGroup reports whether the provider builds the slice type Out[0]
+	// from the members of a group. It is not a Go object: Args hold the
+	// placeholder types of the members, and Pkg and Pos are those of the
+	// first member.
+	IsGroup bool
+
+	// Annotations holds the metadata attached to the provider with
+	// wire.Annotate, in the order it was given. Generated code ignores it.
+	Annotations []Annotation
+
+	// Instrument is the generic wrapper function passed to wire.Copy for a
+	// set including the provider, or nil. Generated injectors call the
+	// provider function through it.
+	Instrument *types.Func
+
+	// Strategy is the name given by a //wire:strategy directive on the
+	// provider function, or empty. A provider with a strategy is one of
+	// several alternatives for its type, and is only used by injectors
+	// marked with a //wire:strategy directive of the same name.
+	Strategy string
+
+	// Constraint is the build constraint expression of a //wire:build line
+	// in the provider function's doc comment, or empty. Injectors may only use
+	// the provider when generating for a platform that satisfies it.
+	Constraint string
+
+	// Implement holds the methods of the interface Out[0] if the provider
+	// was created by wire.Implement, or nil. The injector builds a struct
+	// holding the values of Args, whose methods forward to them.
+	Implement []ImplementMethod
+
+	// Deref reports whether the provider was added by
+	// GenerateOptions.AutoDeref. It is not a Go object: it has a single
+	// argument, a named struct type or a pointer to one, and takes the
+	// address of the struct or dereferences the pointer to produce Out[0].
+	// Pkg, Name, and Pos are those of the struct type.
+	Deref bool
+}
+
+// An ImplementMethod is a method of an interface passed to wire.Implement.
+type ImplementMethod struct {
+	// Name is the name of the method.
+	Name string
+
+	// Sig is the signature of the method, without a receiver.
+	Sig *types.Signature
+
+	// Arg is the index of the provider argument whose method of the same
+	// name and signature the method forwards to.
+	Arg int
+}
+
+// An Annotation is a metadata value attached to a provider with
+// wire.Annotate.
+type Annotation struct {
+	// Expr is the source of the expression passed to wire.Annotate.
+	Expr string
+
+	// Type is the type of the expression, as returned by types.TypeString
+	// with a nil qualifier.
+	Type string
+
+	// Value is the value of a constant expression: the string itself for
+	// string constants, or the exact value as returned by
+	// constant.Value.ExactString otherwise. It is empty if the expression
+	// is not constant.
+	Value string
 }
 
 // ProviderInput describes an incoming edge in the provider graph.
@@ -185,6 +386,12 @@ type ProviderInput struct {
 
 	// If the provider is a struct, FieldName will be the field name to set.
 	FieldName string
+
+	// ConfigKey is the key of a struct field tagged `wire:"key=..."`. The
+	// field is then read from the config map under that key: Type is the
+	// config map type and FieldType is the type of the field.
+	ConfigKey string
+	FieldType types.Type
 }
 
 // Value describes a value expression.
@@ -200,6 +407,19 @@ type Value struct {
 
 	// info is the type info for the expression.
 	info *types.Info
+
+	// isVar is true for wire.EmbedValue, whose expression is a
+	// package-level variable that injectors read directly.
+	isVar bool
+
+	// isCall is true if the expression calls a function. Injectors then
+	// evaluate it each time they are called, instead of reading a
+	// package-level variable initialized with it.
+	isCall bool
+
+	// constant is the value of the expression if it is a constant
+	// expression, or nil. Injectors inline it as a literal.
+	constant constant.Value
 }
 
 // InjectorArg describes a specific argument passed to an injector function.
@@ -237,6 +457,28 @@ type Field struct {
 	Out []types.Type
 }
 
+// Wrap describes a call to wire.Wrap.
+type Wrap struct {
+	// Type is the type whose values are wrapped.
+	Type types.Type
+	// Funcs are the wrapper functions, in the order they are applied. Each
+	// takes an argument of Type, and its first output is Type.
+	Funcs []*Provider
+	// Pos is the source position of the call to wire.Wrap.
+	Pos token.Pos
+}
+
+// wrappedArg returns the index of the argument of p that receives the
+// wrapped value of type t, or -1 if there is none.
+func wrappedArg(p *Provider, t types.Type) int {
+	for i, a := range p.Args {
+		if types.Identical(a.Type, t) {
+			return i
+		}
+	}
+	return -1
+}
+
 // Load finds all the provider sets in the packages that match the given
 // patterns, as well as the provider sets' transitive dependencies. It
 // may return both errors and Info. The patterns are defined by the
@@ -249,7 +491,7 @@ type Field struct {
 // In case of duplicate environment variables, the last one in the list
 // takes precedence.
 func Load(ctx context.Context, wd string, env []string, tags string, patterns []string) (*Info, []error) {
-	pkgs, errs := load(ctx, wd, env, tags, patterns)
+	pkgs, errs := load(ctx, wd, env, tags, patterns, false)
 	if len(errs) > 0 {
 		return nil, errs
 	}
@@ -285,58 +527,196 @@ func Load(ctx context.Context, wd string, env []string, tags string, patterns []
 			id := ProviderSetID{ImportPath: pset.PkgPath, VarName: name}
 			info.Sets[id] = pset
 		}
-		for _, f := range pkg.Syntax {
-			for _, decl := range f.Decls {
-				fn, ok := decl.(*ast.FuncDecl)
-				if !ok {
-					continue
-				}
-				buildCall, err := findInjectorBuild(pkg.TypesInfo, fn)
-				if err != nil {
-					ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
-					continue
-				}
-				if buildCall == nil {
+		injectors, errs := oc.solveInjectors(pkg, false)
+		ec.add(errs...)
+		for _, inj := range injectors {
+			info.Injectors = append(info.Injectors, inj.injector)
+		}
+	}
+	return info, ec.errors
+}
+
+// A solvedInjector is an injector function along with the calls it makes.
+type solvedInjector struct {
+	injector *Injector
+	// sig is the signature the injector function is declared with.
+	sig *types.Signature
+	ins *types.Tuple
+	// out is the type that calls build. It is the injector's output,
+	// unless addr is set: the injector then returns a pointer to out.
+	out  types.Type
+	addr bool
+	// closure is the injector's output if it returns a closure that
+	// builds out or its address, as described by deferredOutput.
+	// Otherwise it is nil.
+	closure types.Type
+	set     *ProviderSet
+	calls   []call
+}
+
+// unexportedProviders returns an error for each unexported provider function
+// of pkg that is included, directly or through another set, in one of pkg's
+// exported provider set variables. Other packages can refer to such a set,
+// but injectors generated there cannot call the provider. Package main and
+// test packages are skipped, since they cannot be imported.
+func (oc *objectCache) unexportedProviders(pkg *packages.Package) []error {
+	if pkg.Name == "main" || isTestVariant(pkg) {
+		return nil
+	}
+	scope := pkg.Types.Scope()
+	var errs []error
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.Var)
+		if !ok || !obj.Exported() || !isProviderSetType(obj.Type()) {
+			continue
+		}
+		item, setErrs := oc.get(obj)
+		if len(setErrs) > 0 {
+			// Reported when the set is used.
+			continue
+		}
+		seen := make(map[*Provider]bool)
+		stk := []*ProviderSet{item.(*ProviderSet)}
+		for len(stk) > 0 {
+			set := stk[len(stk)-1]
+			stk = stk[:len(stk)-1]
+			for _, p := range set.Providers {
+				if p.IsStruct || p.Pkg != pkg.Types || ast.IsExported(p.Name) || seen[p] {
 					continue
 				}
-				sig := pkg.TypesInfo.ObjectOf(fn.Name).Type().(*types.Signature)
-				ins, out, err := injectorFuncSignature(sig)
-				if err != nil {
-					if w, ok := err.(*wireErr); ok {
-						ec.add(notePosition(w.position, fmt.Errorf("inject %s: %v", fn.Name.Name, w.error)))
-					} else {
-						ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+				seen[p] = true
+				errs = append(errs, notePosition(oc.fset.Position(p.Pos),
+					fmt.Errorf("exported provider set %s includes unexported provider %s, which injectors in other packages cannot call", name, p.Name)))
+			}
+			stk = append(stk, set.Imports...)
+		}
+	}
+	return errs
+}
+
+// solveInjectors finds the injector functions declared in pkg and solves
+// each of them, converting between structs and pointers to them as
+// GenerateOptions.AutoDeref does if autoDeref is true. Injectors with errors
+// are omitted from the result.
+func (oc *objectCache) solveInjectors(pkg *packages.Package, autoDeref bool) ([]*solvedInjector, []error) {
+	fset := oc.fset
+	ec := new(errorCollector)
+	var injectors []*solvedInjector
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || skipInjector(fn, pkg.Name, false) {
+				continue
+			}
+			buildCall, err := findInjectorBuild(pkg.TypesInfo, fn)
+			if err != nil {
+				ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+				continue
+			}
+			if buildCall == nil {
+				continue
+			}
+			sig, err := buildInputs(pkg.TypesInfo, pkg.TypesInfo.ObjectOf(fn.Name).Type().(*types.Signature), buildCall)
+			if err != nil {
+				ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+				continue
+			}
+			use := isUse(pkg.TypesInfo, buildCall)
+			ins, out, err := injectorFuncSignature(sig, use)
+			if err == nil && isBuildOnce(pkg.TypesInfo, fn, buildCall) {
+				err = verifyBuildOnce(ins, out, use)
+			}
+			if err != nil {
+				err = &SignatureError{Func: fn.Name.Name, Injector: true, Err: err}
+				ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, err)))
+				continue
+			}
+			injectorArgs := &InjectorArgs{
+				Name:  fn.Name.Name,
+				Tuple: ins,
+				Pos:   fn.Pos(),
+			}
+			set, errs := oc.processNewSet(pkg.TypesInfo, pkg.PkgPath, buildCall, injectorArgs, "")
+			if len(errs) > 0 {
+				ec.add(notePositionAll(fset.Position(fn.Pos()), errs)...)
+				continue
+			}
+			strategy, err := strategyDirective(fn.Doc)
+			if err != nil {
+				ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+				continue
+			}
+			set, errs = selectStrategy(fset, oc.hasher, set, strategy)
+			if len(errs) > 0 {
+				ec.add(mapErrors(errs, func(e error) error {
+					if w, ok := e.(*wireErr); ok {
+						return notePosition(w.position, fmt.Errorf("inject %s: %w", fn.Name.Name, w.error))
 					}
-					continue
-				}
-				injectorArgs := &InjectorArgs{
-					Name:  fn.Name.Name,
-					Tuple: ins,
-					Pos:   fn.Pos(),
-				}
-				set, errs := oc.processNewSet(pkg.TypesInfo, pkg.PkgPath, buildCall, injectorArgs, "")
-				if len(errs) > 0 {
-					ec.add(notePositionAll(fset.Position(fn.Pos()), errs)...)
-					continue
-				}
-				_, errs = solve(fset, out.out, ins, set)
+					return notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, e))
+				})...)
+				continue
+			}
+			if hasDirective(fn.Doc, "assignable") {
+				set, errs = allowAssignable(oc.hasher, set, out.out)
 				if len(errs) > 0 {
 					ec.add(mapErrors(errs, func(e error) error {
 						if w, ok := e.(*wireErr); ok {
-							return notePosition(w.position, fmt.Errorf("inject %s: %v", fn.Name.Name, w.error))
+							return notePosition(w.position, fmt.Errorf("inject %s: %w", fn.Name.Name, w.error))
 						}
-						return notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, e))
+						return notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, e))
 					})...)
 					continue
 				}
-				info.Injectors = append(info.Injectors, &Injector{
+			}
+			if autoDeref {
+				set = derefPointers(oc.hasher, set, out.out)
+			}
+			ambient, err := ambientDirective(fn.Doc)
+			if err != nil {
+				ec.add(notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
+				continue
+			}
+			if errs := verifyAmbient(ambient, ins, out.out, set); len(errs) > 0 {
+				ec.add(mapErrors(errs, func(e error) error {
+					return notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, e))
+				})...)
+				continue
+			}
+			result := out.out
+			var closure types.Type
+			if inner, ok := deferredOutput(set, out); ok {
+				result, closure = inner.out, out.out
+			}
+			target := result
+			if iface := addressedInterface(set, target); iface != nil {
+				target = iface
+			}
+			calls, errs := solve(fset, target, ins, set)
+			if len(errs) > 0 {
+				ec.add(mapErrors(errs, func(e error) error {
+					if w, ok := e.(*wireErr); ok {
+						return notePosition(w.position, fmt.Errorf("inject %s: %w", fn.Name.Name, w.error))
+					}
+					return notePosition(fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, e))
+				})...)
+				continue
+			}
+			injectors = append(injectors, &solvedInjector{
+				injector: &Injector{
 					ImportPath: pkg.PkgPath,
 					FuncName:   fn.Name.Name,
-				})
-			}
+				},
+				sig:     pkg.TypesInfo.ObjectOf(fn.Name).Type().(*types.Signature),
+				ins:     ins,
+				out:     target,
+				addr:    target != result,
+				closure: closure,
+				set:     set,
+				calls:   calls,
+			})
 		}
 	}
-	return info, ec.errors
+	return injectors, ec.errors
 }
 
 // load typechecks the packages that match the given patterns and
@@ -349,18 +729,16 @@ func Load(ctx context.Context, wd string, env []string, tags string, patterns []
 // env is nil or empty, it is interpreted as an empty set of variables.
 // In case of duplicate environment variables, the last one in the list
 // takes precedence.
-func load(ctx context.Context, wd string, env []string, tags string, patterns []string) ([]*packages.Package, []error) {
+func load(ctx context.Context, wd string, env []string, tags string, patterns []string, tests bool) ([]*packages.Package, []error) {
 	cfg := &packages.Config{
 		Context:    ctx,
 		Mode:       packages.LoadAllSyntax,
 		Dir:        wd,
 		Env:        env,
-		BuildFlags: []string{"-tags=wireinject"},
+		BuildFlags: []string{buildTagsFlag(tags)},
+		Tests:      tests,
 		// TODO(light): Use ParseFile to skip function bodies and comments in indirect packages.
 	}
-	if len(tags) > 0 {
-		cfg.BuildFlags[0] += " " + tags
-	}
 	escaped := make([]string, len(patterns))
 	for i := range patterns {
 		escaped[i] = "pattern=" + patterns[i]
@@ -369,100 +747,610 @@ func load(ctx context.Context, wd string, env []string, tags string, patterns []
 	if err != nil {
 		return nil, []error{err}
 	}
+	// Inject files excluded by a //go:build ignore constraint are only
+	// found once the packages are listed, so load them again with those
+	// files included.
+	if overlay := ignoredInjectOverlay(pkgs); len(overlay) > 0 {
+		cfg.Overlay = overlay
+		pkgs, err = packages.Load(cfg, escaped...)
+		if err != nil {
+			return nil, []error{err}
+		}
+	}
+	// Types declared in files excluded by the wireinject tag are resolved
+	// by type-checking the packages again. The files may import packages
+	// that were not loaded, which are then loaded along with the patterns.
+	target := buildTarget(env, tags)
+	if imports := resolveExcludedTypes(pkgs, target); len(imports) > 0 {
+		roots := make(map[string]bool)
+		for _, p := range pkgs {
+			roots[p.ID] = true
+		}
+		for _, path := range imports {
+			escaped = append(escaped, "pattern="+path)
+		}
+		pkgs, err = packages.Load(cfg, escaped...)
+		if err != nil {
+			return nil, []error{err}
+		}
+		resolveExcludedTypes(pkgs, target)
+		n := 0
+		for _, p := range pkgs {
+			if roots[p.ID] {
+				pkgs[n] = p
+				n++
+			}
+		}
+		pkgs = pkgs[:n]
+	}
 	var errs []error
 	for _, p := range pkgs {
-		for _, e := range p.Errors {
-			errs = append(errs, e)
+		pkgErrs := packageErrors(p)
+		if len(pkgErrs) > 0 {
+			noteExcludedDecls(p, target, pkgErrs)
 		}
+		errs = append(errs, pkgErrs...)
 	}
 	if len(errs) > 0 {
 		return nil, errs
 	}
+	if tests {
+		// Drop the generated test main packages.
+		n := 0
+		for _, p := range pkgs {
+			if !strings.HasSuffix(p.ID, ".test") {
+				pkgs[n] = p
+				n++
+			}
+		}
+		pkgs = pkgs[:n]
+	}
 	return pkgs, nil
 }
 
-// Info holds the result of Load.
-type Info struct {
-	Fset *token.FileSet
-
-	// Sets contains all the provider sets in the initial packages.
-	Sets map[ProviderSetID]*ProviderSet
-
-	// Injectors contains all the injector functions in the initial packages.
-	// The order is undefined.
-	Injectors []*Injector
-}
-
-// A ProviderSetID identifies a named provider set.
-type ProviderSetID struct {
-	ImportPath string
-	VarName    string
+// ignoredInjectOverlay returns an overlay that includes the inject files
+// of pkgs that are excluded from builds by a //go:build ignore constraint
+// rather than by the wireinject tag. Such files are marked with a
+// //wire:inject comment before the package clause. The overlay replaces
+// their ignore constraint with wireinject, keeping line numbers intact.
+func ignoredInjectOverlay(pkgs []*packages.Package) map[string][]byte {
+	var overlay map[string][]byte
+	for _, pkg := range pkgs {
+		for _, name := range pkg.IgnoredFiles {
+			if !strings.HasSuffix(name, ".go") || overlay[name] != nil {
+				continue
+			}
+			src, err := ioutil.ReadFile(name)
+			if err != nil {
+				// Leave it to the go command to report unreadable files.
+				continue
+			}
+			if src, ok := ignoredInjectFile(src); ok {
+				if overlay == nil {
+					overlay = make(map[string][]byte)
+				}
+				overlay[name] = src
+			}
+		}
+	}
+	return overlay
 }
 
-// String returns the ID as ""path/to/pkg".Foo".
-func (id ProviderSetID) String() string {
-	return strconv.Quote(id.ImportPath) + "." + id.VarName
+// ignoredInjectFile reports whether src is an inject file excluded by a
+// //go:build ignore constraint and, if so, returns src with the
+// constraint replaced by wireinject.
+func ignoredInjectFile(src []byte) ([]byte, bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	marked := false
+	for _, cg := range f.Comments {
+		if cg.Pos() < f.Package && hasDirective(cg, "inject") {
+			marked = true
+			break
+		}
+	}
+	if !marked {
+		return nil, false
+	}
+	lines := strings.SplitAfter(string(src), "\n")
+	ignored := false
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case "//go:build ignore":
+			lines[i] = strings.Replace(line, "ignore", "wireinject", 1)
+			ignored = true
+		case "// +build ignore":
+			lines[i] = strings.Replace(line, "ignore", "wireinject", 1)
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	if !ignored {
+		return nil, false
+	}
+	return []byte(strings.Join(lines, "")), true
 }
 
-// An Injector describes an injector function.
-type Injector struct {
-	ImportPath string
-	FuncName   string
+// resolveExcludedTypes type-checks again each of the root packages pkgs
+// whose type errors report identifiers as undefined that are types declared
+// in files excluded by the wireinject build tag, and the root packages that
+// import such a package. If the package then type-checks, its type
+// information replaces the one that packages.Load recorded. target is the
+// build context without the wireinject tag; only files that it includes are
+// considered. resolveExcludedTypes returns the paths of the packages that the
+// files import and that are not loaded; packages needing them are left
+// unchanged.
+//
+// Wire type-checks packages with the wireinject tag, so that inject files
+// are used in place of their counterparts, such as the generated
+// wire_gen.go, which declare the same functions. A file marked
+// //go:build !wireinject is excluded as a whole, though, and the types it
+// declares are missing too, even though types have no counterparts in
+// inject files. So the package is checked with the declarations of those
+// types added, along with their methods, whose bodies are left out. The
+// file's functions, variables and constants stay excluded, as do files
+// generated by Wire.
+func resolveExcludedTypes(pkgs []*packages.Package, target *build.Context) []string {
+	roots := make(map[*packages.Package]bool)
+	for _, p := range pkgs {
+		roots[p] = true
+	}
+	loaded := make(map[string]*packages.Package)
+	var order []*packages.Package
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if loaded[p.PkgPath] == nil {
+			loaded[p.PkgPath] = p
+		}
+		if roots[p] {
+			order = append(order, p)
+		}
+	})
+	var missing []string
+	seen := make(map[string]bool)
+	rechecked := make(map[*packages.Package]bool)
+	for _, pkg := range order {
+		files := excludedTypeDecls(pkg, target)
+		if len(files) == 0 && !importsAny(pkg, rechecked) {
+			continue
+		}
+		complete := true
+		for _, f := range files {
+			for _, spec := range f.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				if err != nil || pkg.Imports[path] != nil || loaded[path] != nil {
+					continue
+				}
+				complete = false
+				if !seen[path] {
+					seen[path] = true
+					missing = append(missing, path)
+				}
+			}
+		}
+		if complete && recheckPackage(pkg, files, loaded) {
+			rechecked[pkg] = true
+		}
+	}
+	return missing
 }
 
-// String returns the injector name as ""path/to/pkg".Foo".
-func (in *Injector) String() string {
-	return strconv.Quote(in.ImportPath) + "." + in.FuncName
+// importsAny reports whether pkg imports one of the packages in set.
+func importsAny(pkg *packages.Package, set map[*packages.Package]bool) bool {
+	for _, p := range pkg.Imports {
+		if set[p] {
+			return true
+		}
+	}
+	return false
 }
 
-// objectCache is a lazily evaluated mapping of objects to Wire structures.
-type objectCache struct {
-	fset     *token.FileSet
-	packages map[string]*packages.Package
-	objects  map[objRef]objCacheEntry
-	hasher   typeutil.Hasher
+// excludedTypeDecls returns the files of pkg that are excluded by the
+// wireinject build tag alone and declare a type that one of pkg's type
+// errors reports as undefined, reduced by typeDecls. It returns nil if pkg
+// has errors other than type errors.
+func excludedTypeDecls(pkg *packages.Package, target *build.Context) []*ast.File {
+	if len(pkg.Errors) == 0 || pkg.Types == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	undefined := make(map[string]bool)
+	for _, e := range pkg.Errors {
+		if e.Kind != packages.TypeError {
+			return nil
+		}
+		id := identAt(pkg, e.Pos)
+		if id != nil && pkg.TypesInfo.Uses[id] == nil && pkg.TypesInfo.Defs[id] == nil {
+			undefined[id.Name] = true
+		}
+	}
+	if len(undefined) == 0 {
+		return nil
+	}
+	var files []*ast.File
+	for _, name := range excludedFiles(pkg, target) {
+		f, err := parser.ParseFile(pkg.Fset, name, nil, parser.ParseComments)
+		if err != nil || isWireGenerated(f) {
+			continue
+		}
+		f, declared := typeDecls(f, pkg.Types.Scope())
+		for _, name := range declared {
+			if undefined[name] {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	return files
 }
 
-type objRef struct {
-	importPath string
-	name       string
+// typeDecls returns a copy of f that holds only its imports, its
+// declarations of types that scope lacks, and the methods of those types
+// without their bodies, along with the names of the types.
+func typeDecls(f *ast.File, scope *types.Scope) (*ast.File, []string) {
+	var decls []ast.Decl
+	var names []string
+	declared := make(map[string]bool)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		switch gen.Tok {
+		case token.IMPORT:
+			decls = append(decls, gen)
+		case token.TYPE:
+			var specs []ast.Spec
+			for _, spec := range gen.Specs {
+				name := spec.(*ast.TypeSpec).Name.Name
+				if scope.Lookup(name) == nil {
+					specs = append(specs, spec)
+					names = append(names, name)
+					declared[name] = true
+				}
+			}
+			if len(specs) > 0 {
+				typeDecl := *gen
+				typeDecl.Specs = specs
+				decls = append(decls, &typeDecl)
+			}
+		}
+	}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || !declared[receiverTypeName(fn.Recv.List[0].Type)] {
+			continue
+		}
+		method := *fn
+		method.Body = nil
+		decls = append(decls, &method)
+	}
+	return &ast.File{Package: f.Package, Name: f.Name, Decls: decls, Imports: f.Imports}, names
 }
 
-type objCacheEntry struct {
-	val  interface{} // *Provider, *ProviderSet, *IfaceBinding, or *Value
-	errs []error
+// receiverTypeName returns the name of the type of a method receiver
+// declared as expr, or the empty string if expr is not a type name or a
+// pointer to one.
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
 }
 
-func newObjectCache(pkgs []*packages.Package) *objectCache {
-	if len(pkgs) == 0 {
-		panic("object cache must have packages to draw from")
+// recheckPackage type-checks pkg's syntax together with extra, resolving
+// imports with pkg.Imports and then with loaded, the packages by path. If
+// there are no errors, other than soft ones in extra such as unused
+// imports, it replaces pkg's type information and errors and reports true.
+func recheckPackage(pkg *packages.Package, extra []*ast.File, loaded map[string]*packages.Package) bool {
+	extraFiles := make(map[string]bool)
+	for _, f := range extra {
+		extraFiles[pkg.Fset.Position(f.Pos()).Filename] = true
+	}
+	failed := false
+	conf := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if p := pkg.Imports[path]; p != nil {
+				return p.Types, nil
+			}
+			if p := loaded[path]; p != nil {
+				return p.Types, nil
+			}
+			return nil, fmt.Errorf("package %s is not loaded", path)
+		}),
+		Sizes: pkg.TypesSizes,
+		Error: func(err error) {
+			if e, ok := err.(types.Error); ok && e.Soft && extraFiles[e.Fset.Position(e.Pos).Filename] {
+				return
+			}
+			failed = true
+		},
 	}
-	oc := &objectCache{
-		fset:     pkgs[0].Fset,
-		packages: make(map[string]*packages.Package),
-		objects:  make(map[objRef]objCacheEntry),
-		hasher:   typeutil.MakeHasher(),
+	info := newTypesInfo()
+	files := append(append([]*ast.File(nil), pkg.Syntax...), extra...)
+	tpkg, _ := conf.Check(pkg.PkgPath, pkg.Fset, files, info)
+	if failed {
+		return false
 	}
-	// Depth-first search of all dependencies to gather import path to
-	// packages.Package mapping. go/packages guarantees that for a single
-	// call to packages.Load and an import path X, there will exist only
-	// one *packages.Package value with PkgPath X.
-	stk := append([]*packages.Package(nil), pkgs...)
-	for len(stk) > 0 {
-		p := stk[len(stk)-1]
-		stk = stk[:len(stk)-1]
-		if oc.packages[p.PkgPath] != nil {
+	pkg.Types = tpkg
+	pkg.TypesInfo = info
+	pkg.Errors = nil
+	pkg.IllTyped = false
+	return true
+}
+
+// importerFunc implements types.Importer with a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// noteExcludedDecls records in each type error of errs, the errors of pkg,
+// the file excluded by the wireinject build tag that declares the
+// identifier the error is reported at, if the identifier is undefined.
+// target is the build context without the wireinject tag; only files that
+// it includes are considered. The errors remain if resolveExcludedTypes
+// can't resolve the declarations, for example because they depend on the
+// file's constants.
+func noteExcludedDecls(pkg *packages.Package, target *build.Context, errs []error) {
+	var declared map[string]string // name to file
+	for _, err := range errs {
+		e, ok := err.(*PackageError)
+		if !ok || e.Kind != packages.TypeError {
 			continue
 		}
-		oc.packages[p.PkgPath] = p
-		for _, imp := range p.Imports {
-			stk = append(stk, imp)
+		id := identAt(pkg, e.Pos)
+		if id == nil || pkg.TypesInfo.Uses[id] != nil || pkg.TypesInfo.Defs[id] != nil {
+			continue
+		}
+		if declared == nil {
+			declared = excludedDecls(pkg, target)
 		}
+		e.Excluded = declared[id.Name]
 	}
-	return oc
 }
 
-// get converts a Go object into a Wire structure. It may return a *Provider, an
+// identAt returns the identifier of pkg's syntax at pos, a position of the
+// form "file:line:col", or nil if there is none.
+func identAt(pkg *packages.Package, pos string) *ast.Ident {
+	i := strings.LastIndexByte(pos, ':')
+	if i < 0 {
+		return nil
+	}
+	j := strings.LastIndexByte(pos[:i], ':')
+	if j < 0 {
+		return nil
+	}
+	line, err1 := strconv.Atoi(pos[j+1 : i])
+	col, err2 := strconv.Atoi(pos[i+1:])
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	file := pos[:j]
+	var found *ast.Ident
+	for _, f := range pkg.Syntax {
+		if pkg.Fset.Position(f.Pos()).Filename != file {
+			continue
+		}
+		ast.Inspect(f, func(node ast.Node) bool {
+			if found != nil {
+				return false
+			}
+			if id, ok := node.(*ast.Ident); ok {
+				if p := pkg.Fset.Position(id.Pos()); p.Line == line && p.Column == col {
+					found = id
+				}
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// excludedFiles returns the Go files of pkg, other than test files, that
+// are excluded by the wireinject build tag alone, that is, that target
+// includes.
+func excludedFiles(pkg *packages.Package, target *build.Context) []string {
+	var files []string
+	for _, name := range pkg.IgnoredFiles {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := target.MatchFile(filepath.Dir(name), filepath.Base(name)); err == nil && ok {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// excludedDecls returns the package-level names declared by the Go files
+// of pkg that are excluded by the wireinject build tag alone, mapped to
+// the files declaring them. Test files and files generated by Wire, whose
+// declarations are those of inject files, are skipped.
+func excludedDecls(pkg *packages.Package, target *build.Context) map[string]string {
+	declared := make(map[string]string)
+	for _, name := range excludedFiles(pkg, target) {
+		f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ParseComments)
+		if err != nil || isWireGenerated(f) {
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					declared[decl.Name.Name] = name
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						declared[spec.Name.Name] = name
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							declared[id.Name] = name
+						}
+					}
+				}
+			}
+		}
+	}
+	return declared
+}
+
+// isWireGenerated reports whether f starts with the comment that marks
+// files generated by Wire.
+func isWireGenerated(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "// Code generated by Wire.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildTagsFlag returns the -tags flag that selects the wireinject tag and
+// tags, which may be separated by spaces or commas. The go command reads a
+// list containing a comma as comma-separated, so "wireinject dev,prod" would
+// be read as the tags "wireinject dev" and "prod".
+func buildTagsFlag(tags string) string {
+	list := []string{"wireinject"}
+	list = append(list, strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})...)
+	return "-tags=" + strings.Join(list, ",")
+}
+
+// isTestVariant reports whether pkg was loaded with its _test.go files: it
+// is either a package augmented with its internal test files or an external
+// test package.
+func isTestVariant(pkg *packages.Package) bool {
+	return pkg.ID != pkg.PkgPath
+}
+
+// injectorSyntax returns the files of pkg that may declare injectors. For a
+// test variant, only the _test.go files are considered, since the others
+// are generated for the package itself.
+func injectorSyntax(pkg *packages.Package) []*ast.File {
+	if !isTestVariant(pkg) {
+		return pkg.Syntax
+	}
+	var files []*ast.File
+	for _, f := range pkg.Syntax {
+		if strings.HasSuffix(pkg.Fset.File(f.Pos()).Name(), "_test.go") {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Info holds the result of Load.
+type Info struct {
+	Fset *token.FileSet
+
+	// Sets contains all the provider sets in the initial packages.
+	Sets map[ProviderSetID]*ProviderSet
+
+	// Injectors contains all the injector functions in the initial packages.
+	// The order is undefined.
+	Injectors []*Injector
+}
+
+// A ProviderSetID identifies a named provider set.
+type ProviderSetID struct {
+	ImportPath string
+	VarName    string
+}
+
+// String returns the ID as ""path/to/pkg".Foo".
+func (id ProviderSetID) String() string {
+	return strconv.Quote(id.ImportPath) + "." + id.VarName
+}
+
+// An Injector describes an injector function.
+type Injector struct {
+	ImportPath string
+	FuncName   string
+}
+
+// String returns the injector name as ""path/to/pkg".Foo".
+func (in *Injector) String() string {
+	return strconv.Quote(in.ImportPath) + "." + in.FuncName
+}
+
+// objectCache is a lazily evaluated mapping of objects to Wire structures.
+type objectCache struct {
+	fset     *token.FileSet
+	packages map[string]*packages.Package
+	objects  map[objRef]objCacheEntry
+	hasher   typeutil.Hasher
+	// noValueTypes maps functions passed to wire.NoValue to their
+	// placeholder output types.
+	noValueTypes map[*types.Func]types.Type
+	// groupTypes maps providers passed to wire.Group, keyed by provider
+	// and group, to their placeholder output types.
+	groupTypes map[string]types.Type
+	// factories holds the set factories being evaluated, to detect
+	// factories that call themselves.
+	factories map[*types.Func]bool
+}
+
+type objRef struct {
+	importPath string
+	name       string
+}
+
+type objCacheEntry struct {
+	val  interface{} // *Provider, *ProviderSet, *IfaceBinding, or *Value
+	errs []error
+}
+
+func newObjectCache(pkgs []*packages.Package) *objectCache {
+	if len(pkgs) == 0 {
+		panic("object cache must have packages to draw from")
+	}
+	oc := &objectCache{
+		fset:     pkgs[0].Fset,
+		packages: make(map[string]*packages.Package),
+		objects:  make(map[objRef]objCacheEntry),
+		hasher:   typeutil.MakeHasher(),
+
+		noValueTypes: make(map[*types.Func]types.Type),
+		groupTypes:   make(map[string]types.Type),
+		factories:    make(map[*types.Func]bool),
+	}
+	// Depth-first search of all dependencies to gather import path to
+	// packages.Package mapping. go/packages guarantees that for a single
+	// call to packages.Load and an import path X, there will exist only
+	// one *packages.Package value with PkgPath X.
+	stk := append([]*packages.Package(nil), pkgs...)
+	for len(stk) > 0 {
+		p := stk[len(stk)-1]
+		stk = stk[:len(stk)-1]
+		if oc.packages[p.PkgPath] != nil {
+			continue
+		}
+		oc.packages[p.PkgPath] = p
+		for _, imp := range p.Imports {
+			stk = append(stk, imp)
+		}
+	}
+	return oc
+}
+
+// get converts a Go object into a Wire structure. It may return a *Provider, an
 // *IfaceBinding, a *ProviderSet, a *Value, or a []*Field.
 func (oc *objectCache) get(obj types.Object) (val interface{}, errs []error) {
 	ref := objRef{
@@ -493,7 +1381,11 @@ func (oc *objectCache) get(obj types.Object) (val interface{}, errs []error) {
 		pkgPath := obj.Pkg().Path()
 		return oc.processExpr(oc.packages[pkgPath].TypesInfo, pkgPath, spec.Values[i], obj.Name())
 	case *types.Func:
-		return processFuncProvider(oc.fset, obj)
+		var doc *ast.CommentGroup
+		if decl := oc.funcDecl(obj); decl != nil {
+			doc = decl.Doc
+		}
+		return processFuncProvider(oc.fset, obj, doc)
 	default:
 		return nil, []error{fmt.Errorf("%v is not a provider or a provider set", obj)}
 	}
@@ -519,6 +1411,58 @@ func (oc *objectCache) varDecl(obj *types.Var) *ast.ValueSpec {
 	return nil
 }
 
+// funcDecl finds the declaration that defines the given function.
+func (oc *objectCache) funcDecl(obj *types.Func) *ast.FuncDecl {
+	pkg := oc.packages[obj.Pkg().Path()]
+	if pkg == nil {
+		return nil
+	}
+	pos := obj.Pos()
+	for _, f := range pkg.Syntax {
+		tokenFile := oc.fset.File(f.Pos())
+		if base := tokenFile.Base(); base <= int(pos) && int(pos) < base+tokenFile.Size() {
+			path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+			for _, node := range path {
+				if decl, ok := node.(*ast.FuncDecl); ok {
+					return decl
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fieldDecl finds the declaration that defines the given struct field.
+func (oc *objectCache) fieldDecl(obj *types.Var) *ast.Field {
+	pkg := oc.packages[obj.Pkg().Path()]
+	if pkg == nil {
+		return nil
+	}
+	pos := obj.Pos()
+	for _, f := range pkg.Syntax {
+		tokenFile := oc.fset.File(f.Pos())
+		if base := tokenFile.Base(); base <= int(pos) && int(pos) < base+tokenFile.Size() {
+			path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+			for _, node := range path {
+				if field, ok := node.(*ast.Field); ok {
+					return field
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// noInject reports whether the struct field is annotated with a
+// //wire:noinject comment.
+func (oc *objectCache) noInject(obj *types.Var) bool {
+	field := oc.fieldDecl(obj)
+	if field == nil {
+		return false
+	}
+	return hasDirective(field.Doc, "noinject") || hasDirective(field.Comment, "noinject")
+}
+
 // processExpr converts an expression into a Wire structure. It may return a
 // *Provider, an *IfaceBinding, a *ProviderSet, a *Value or a []*Field.
 func (oc *objectCache) processExpr(info *types.Info, pkgPath string, expr ast.Expr, varName string) (interface{}, []error) {
@@ -530,16 +1474,31 @@ func (oc *objectCache) processExpr(info *types.Info, pkgPath string, expr ast.Ex
 			return notePosition(exprPos, err)
 		})
 	}
+	if fn, sig, typeArgs := funcInstance(info, expr); fn != nil {
+		var doc *ast.CommentGroup
+		if decl := oc.funcDecl(fn); decl != nil {
+			doc = decl.Doc
+		}
+		p, errs := processFuncInstance(oc.fset, fn, sig, typeArgs, doc)
+		return p, notePositionAll(exprPos, errs)
+	}
 	if call, ok := expr.(*ast.CallExpr); ok {
 		fnObj := qualifiedIdentObject(info, call.Fun)
 		if fnObj == nil {
 			return nil, []error{notePosition(exprPos, errors.New("unknown pattern fnObj nil"))}
 		}
+		if fnObj == types.Universe.Lookup("new") {
+			return nil, []error{notePosition(exprPos, errors.New("new(T) may only be used in wire.Build, to declare an injector input"))}
+		}
 		pkg := fnObj.Pkg()
 		if pkg == nil {
 			return nil, []error{notePosition(exprPos, fmt.Errorf("unknown pattern - pkg in fnObj is nil - %s", fnObj))}
 		}
 		if !isWireImport(pkg.Path()) {
+			if fn, ok := fnObj.(*types.Func); ok && isSetFactory(fn) {
+				pset, errs := oc.processSetFactory(info, call, fn)
+				return pset, notePositionAll(exprPos, errs)
+			}
 			return nil, []error{notePosition(exprPos, errors.New("unknown pattern"))}
 		}
 		switch fnObj.Name() {
@@ -552,6 +1511,57 @@ func (oc *objectCache) processExpr(info *types.Info, pkgPath string, expr ast.Ex
 				return nil, []error{notePosition(exprPos, err)}
 			}
 			return b, nil
+		case "InterfaceProvide":
+			pset, errs := oc.processInterfaceProvide(info, pkgPath, call)
+			return pset, notePositionAll(exprPos, errs)
+		case "Decorate":
+			pset, errs := oc.processDecorate(info, pkgPath, call, varName)
+			return pset, notePositionAll(exprPos, errs)
+		case "Rename":
+			pset, errs := oc.processRename(info, pkgPath, call, varName)
+			return pset, notePositionAll(exprPos, errs)
+		case "Copy":
+			pset, errs := oc.processCopy(info, pkgPath, call, varName)
+			return pset, notePositionAll(exprPos, errs)
+		case "Lazy":
+			p, errs := oc.processLazy(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "Annotate":
+			p, errs := oc.processAnnotate(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "NoValue":
+			p, errs := oc.processNoValue(info, call)
+			return p, notePositionAll(exprPos, errs)
+		case "Group":
+			p, errs := oc.processGroup(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "Convert":
+			p, errs := oc.processConvert(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "Wrap":
+			w, errs := oc.processWrap(info, pkgPath, call)
+			return w, notePositionAll(exprPos, errs)
+		case "Context", "ContextFrom":
+			p, errs := oc.processContext(info, pkgPath, call, fnObj)
+			return p, notePositionAll(exprPos, errs)
+		case "BindInterface":
+			p, errs := oc.processBindInterface(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "ProvideFunc":
+			p, errs := oc.processProvideFunc(info, pkgPath, call)
+			return p, notePositionAll(exprPos, errs)
+		case "As":
+			p, err := processAs(oc.fset, info, call)
+			if err != nil {
+				return nil, []error{notePosition(exprPos, err)}
+			}
+			return p, nil
+		case "Assert":
+			pset, errs := oc.processAssert(info, pkgPath, call)
+			return pset, notePositionAll(exprPos, errs)
+		case "Implement":
+			pset, errs := oc.processImplement(info, pkgPath, call)
+			return pset, notePositionAll(exprPos, errs)
 		case "Value":
 			v, err := processValue(oc.fset, info, call)
 			if err != nil {
@@ -564,8 +1574,14 @@ func (oc *objectCache) processExpr(info *types.Info, pkgPath string, expr ast.Ex
 				return nil, []error{notePosition(exprPos, err)}
 			}
 			return v, nil
+		case "EmbedValue":
+			v, err := processEmbedValue(oc.fset, info, call)
+			if err != nil {
+				return nil, []error{notePosition(exprPos, err)}
+			}
+			return v, nil
 		case "Struct":
-			s, err := processStructProvider(oc.fset, info, call)
+			s, err := oc.processStructProvider(info, call)
 			if err != nil {
 				return nil, []error{notePosition(exprPos, err)}
 			}
@@ -601,6 +1617,11 @@ func (oc *objectCache) processNewSet(info *types.Info, pkgPath string, call *ast
 	}
 	ec := new(errorCollector)
 	for _, arg := range call.Args {
+		if args != nil && newCallType(info, arg) != nil {
+			// new(T) in wire.Build declares an injector input, which
+			// buildInputs has added to args.
+			continue
+		}
 		item, errs := oc.processExpr(info, pkgPath, arg, "")
 		if len(errs) > 0 {
 			ec.add(errs...)
@@ -617,6 +1638,8 @@ func (oc *objectCache) processNewSet(info *types.Info, pkgPath string, call *ast
 			pset.Values = append(pset.Values, item)
 		case []*Field:
 			pset.Fields = append(pset.Fields, item...)
+		case *Wrap:
+			pset.Wraps = append(pset.Wraps, item)
 		default:
 			panic("unknown item type")
 		}
@@ -629,10 +1652,1229 @@ func (oc *objectCache) processNewSet(info *types.Info, pkgPath string, call *ast
 	if len(errs) > 0 {
 		return nil, errs
 	}
-	if errs := verifyAcyclic(pset.providerMap, oc.hasher); len(errs) > 0 {
+	pset.wrapMap, pset.wrapSrcMap, errs = buildWrapMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	pset.strategyMap, errs = buildStrategyMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if errs := verifyAcyclic(pset.providerMap, oc.hasher); len(errs) > 0 {
+		return nil, errs
+	}
+	return pset, nil
+}
+
+// processInterfaceProvide creates a provider set from a wire.InterfaceProvide
+// call. The set contains the provider and a binding from the interface to
+// the provider's output type.
+func (oc *objectCache) processInterfaceProvide(info *types.Info, pkgPath string, call *ast.CallExpr) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.InterfaceProvide.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to InterfaceProvide takes exactly two arguments"))}
+	}
+	ifaceArgType := info.TypeOf(call.Args[0])
+	ifacePtr, ok := ifaceArgType.(*types.Pointer)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to InterfaceProvide must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	iface := ifacePtr.Elem()
+	methodSet, ok := iface.Underlying().(*types.Interface)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to InterfaceProvide must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.NoValue {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to InterfaceProvide must be a provider function"))}
+	}
+	provided := provider.Out[0]
+	if types.Identical(iface, provided) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("cannot bind interface to itself"))}
+	}
+	if !types.Implements(provided, methodSet) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("%s does not implement %s", types.TypeString(provided, nil), types.TypeString(iface, nil)))}
+	}
+	pset := &ProviderSet{
+		Pos:       call.Pos(),
+		PkgPath:   pkgPath,
+		Providers: []*Provider{provider},
+		Bindings: []*IfaceBinding{{
+			Pos:      call.Pos(),
+			Iface:    iface,
+			Provided: provided,
+		}},
+	}
+	pset.providerMap, pset.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return pset, nil
+}
+
+// processRename creates a provider set from a wire.Rename call. The set is
+// a copy of the first argument with the old provider function replaced by
+// the new one wherever it appears, including in imported sets.
+func (oc *objectCache) processRename(info *types.Info, pkgPath string, call *ast.CallExpr, varName string) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.Rename.
+
+	if len(call.Args) != 3 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Rename takes exactly three arguments"))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[0], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	set, ok := item.(*ProviderSet)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("first argument to Rename must be a provider set"))}
+	}
+	oldFn, ok := qualifiedIdentObject(info, astutil.Unparen(call.Args[1])).(*types.Func)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to Rename must be a function"))}
+	}
+	newFn, ok := qualifiedIdentObject(info, astutil.Unparen(call.Args[2])).(*types.Func)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("third argument to Rename must be a function"))}
+	}
+	if !types.Identical(oldFn.Type(), newFn.Type()) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("cannot rename %s to %s: signatures differ (%s vs. %s)",
+				oldFn.Name(), newFn.Name(), types.TypeString(oldFn.Type(), nil), types.TypeString(newFn.Type(), nil)))}
+	}
+	renamed, found, errs := oc.renameProvider(set, oldFn, newFn)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if !found {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("%s is not a provider in the set", oldFn.Name()))}
+	}
+	// The copy is a new set, declared here.
+	cp := *renamed
+	cp.Pos = call.Pos()
+	cp.PkgPath = pkgPath
+	cp.VarName = varName
+	return &cp, nil
+}
+
+// processCopy creates a provider set from a wire.Copy call.
+func (oc *objectCache) processCopy(info *types.Info, pkgPath string, call *ast.CallExpr, varName string) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.Copy.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Copy takes exactly two arguments"))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[0], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	set, ok := item.(*ProviderSet)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("first argument to Copy must be a provider set"))}
+	}
+	// The wrapper is generic, so it is passed instantiated, as in
+	// Record[any]. Generated code instantiates it for each provider.
+	expr := astutil.Unparen(call.Args[1])
+	if index, ok := expr.(*ast.IndexExpr); ok {
+		expr = index.X
+	}
+	wrapper, ok := qualifiedIdentObject(info, expr).(*types.Func)
+	if !ok || wrapper.Parent() != wrapper.Pkg().Scope() || !isInstrumentWrapper(wrapper.Type().(*types.Signature)) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to Copy must be a generic function declared at package level of the form func[T any](name string, fn func() (T, error)) func() (T, error)"))}
+	}
+	instrumented, errs := oc.instrumentProviders(set, wrapper)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	// The copy is a new set, declared here.
+	cp := *instrumented
+	cp.Pos = call.Pos()
+	cp.PkgPath = pkgPath
+	cp.VarName = varName
+	return &cp, nil
+}
+
+// isInstrumentWrapper reports whether sig is the signature of a wrapper
+// function for wire.Copy: func[T any](string, func() (T, error)) func() (T, error).
+func isInstrumentWrapper(sig *types.Signature) bool {
+	params, results := sig.Params(), sig.Results()
+	if params.Len() != 2 || results.Len() != 1 || !types.Identical(params.At(0).Type(), types.Typ[types.String]) {
+		return false
+	}
+	fn, ok := params.At(1).Type().(*types.Signature)
+	if !ok || fn.Params().Len() != 0 || fn.Results().Len() != 2 {
+		return false
+	}
+	return isTypeParam(fn.Results().At(0).Type()) &&
+		types.Identical(fn.Results().At(1).Type(), errorType) &&
+		types.Identical(results.At(0).Type(), fn)
+}
+
+// instrumentProviders returns a copy of set in which the provider functions
+// that wire.Copy instruments, including those of the sets set imports, are
+// called through wrapper.
+func (oc *objectCache) instrumentProviders(set *ProviderSet, wrapper *types.Func) (*ProviderSet, []error) {
+	cp := *set
+	cp.Providers = make([]*Provider, len(set.Providers))
+	for i, p := range set.Providers {
+		cp.Providers[i] = p
+		if p.IsStruct || p.Lazy || p.NoValue || p.TypeAssert || p.Select || p.IsGroup || p.Singleton || p.Implement != nil {
+			continue
+		}
+		np := *p
+		np.Instrument = wrapper
+		cp.Providers[i] = &np
+	}
+	cp.Imports = make([]*ProviderSet, len(set.Imports))
+	for i, imp := range set.Imports {
+		instrumented, errs := oc.instrumentProviders(imp, wrapper)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		cp.Imports[i] = instrumented
+	}
+	var errs []error
+	cp.providerMap, cp.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, &cp)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	cp.strategyMap, errs = buildStrategyMap(oc.fset, oc.hasher, &cp)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &cp, nil
+}
+
+// processDecorate creates a provider set from a wire.Decorate call.
+func (oc *objectCache) processDecorate(info *types.Info, pkgPath string, call *ast.CallExpr, varName string) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.Decorate.
+
+	if len(call.Args) < 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Decorate takes a provider set and at least one decorator"))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[0], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	base, ok := item.(*ProviderSet)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("first argument to Decorate must be a provider set"))}
+	}
+	pset := &ProviderSet{
+		Pos:      call.Pos(),
+		PkgPath:  pkgPath,
+		VarName:  varName,
+		Imports:  []*ProviderSet{base},
+		decorate: true,
+	}
+	ec := new(errorCollector)
+	// Decorators may add types, such as the concrete type of an interface
+	// they rebind, but at least one must replace a type from base.
+	overrides := false
+	for _, arg := range call.Args[1:] {
+		argPos := oc.fset.Position(arg.Pos())
+		item, errs := oc.processExpr(info, pkgPath, arg, "")
+		if len(errs) > 0 {
+			ec.add(errs...)
+			continue
+		}
+		var outs []types.Type
+		switch item := item.(type) {
+		case *Provider:
+			pset.Providers = append(pset.Providers, item)
+			outs = item.Out
+		case *IfaceBinding:
+			pset.Bindings = append(pset.Bindings, item)
+			outs = []types.Type{item.Iface}
+		case *Value:
+			pset.Values = append(pset.Values, item)
+			outs = []types.Type{item.Out}
+		case []*Field:
+			pset.Fields = append(pset.Fields, item...)
+			for _, f := range item {
+				outs = append(outs, f.Out...)
+			}
+		case *ProviderSet:
+			ec.add(notePosition(argPos, errors.New("decorator may not be a provider set")))
+			continue
+		case *Wrap:
+			ec.add(notePosition(argPos, errors.New("decorator may not be a wire.Wrap")))
+			continue
+		default:
+			panic("unknown item type")
+		}
+		for _, t := range outs {
+			if base.providerMap.At(t) != nil {
+				overrides = true
+			}
+		}
+	}
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	if !overrides {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("decorators do not override any type provided by the base set"))}
+	}
+	pset.providerMap, pset.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	pset.wrapMap, pset.wrapSrcMap, errs = buildWrapMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	pset.strategyMap, errs = buildStrategyMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if errs := verifyAcyclic(pset.providerMap, oc.hasher); len(errs) > 0 {
+		return nil, errs
+	}
+	return pset, nil
+}
+
+// renameProvider returns a copy of set in which every provider that calls
+// oldFn calls newFn instead. Sets that do not contain oldFn, directly or
+// through their imports, are returned as is. found reports whether oldFn
+// was found.
+func (oc *objectCache) renameProvider(set *ProviderSet, oldFn, newFn *types.Func) (_ *ProviderSet, found bool, _ []error) {
+	cp := *set
+	cp.Providers = make([]*Provider, len(set.Providers))
+	for i, p := range set.Providers {
+		cp.Providers[i] = p
+		if p.IsStruct || p.Pkg != oldFn.Pkg() || p.Name != oldFn.Name() {
+			continue
+		}
+		np := *p
+		np.Pkg = newFn.Pkg()
+		np.Name = newFn.Name()
+		np.Pos = newFn.Pos()
+		cp.Providers[i] = &np
+		found = true
+	}
+	cp.Imports = make([]*ProviderSet, len(set.Imports))
+	for i, imp := range set.Imports {
+		renamed, ok, errs := oc.renameProvider(imp, oldFn, newFn)
+		if len(errs) > 0 {
+			return nil, false, errs
+		}
+		cp.Imports[i] = renamed
+		found = found || ok
+	}
+	if !found {
+		return set, false, nil
+	}
+	var errs []error
+	cp.providerMap, cp.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, &cp)
+	if len(errs) > 0 {
+		return nil, false, errs
+	}
+	cp.strategyMap, errs = buildStrategyMap(oc.fset, oc.hasher, &cp)
+	if len(errs) > 0 {
+		return nil, false, errs
+	}
+	return &cp, true, nil
+}
+
+// processLazy creates a provider from a wire.Lazy call. The provider
+// produces a function that calls the wrapped provider on first use.
+func (oc *objectCache) processLazy(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.Lazy.
+
+	if len(call.Args) != 1 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Lazy takes exactly one argument"))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[0], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.Lazy || provider.NoValue || provider.Select {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("argument to Lazy must be a provider function"))}
+	}
+	if provider.Singleton {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("singleton provider %s cannot be lazy", provider.Name))}
+	}
+	if provider.Closes {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("provider %s marked //wire:closes cannot be lazy", provider.Name))}
+	}
+	if provider.Eager {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("provider %s marked //wire:eager cannot be lazy", provider.Name))}
+	}
+	results := []*types.Var{types.NewVar(token.NoPos, nil, "", provider.Out[0])}
+	if provider.HasErr {
+		results = append(results, types.NewVar(token.NoPos, nil, "", errorType))
+	}
+	lazy := *provider
+	lazy.Out = []types.Type{types.NewSignature(nil, nil, types.NewTuple(results...), false)}
+	lazy.Lazy = true
+	return &lazy, nil
+}
+
+// processAnnotate creates a provider from a wire.Annotate call. The provider
+// is a copy of the wrapped provider with the metadata appended to its
+// annotations.
+func (oc *objectCache) processAnnotate(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.Annotate.
+
+	if len(call.Args) == 0 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Annotate takes at least one argument"))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[0], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("first argument to Annotate must be a provider"))}
+	}
+	annotated := *provider
+	annotated.Annotations = append([]Annotation(nil), provider.Annotations...)
+	for _, arg := range call.Args[1:] {
+		var src strings.Builder
+		if err := printer.Fprint(&src, oc.fset, arg); err != nil {
+			return nil, []error{notePosition(oc.fset.Position(arg.Pos()), err)}
+		}
+		a := Annotation{Expr: src.String()}
+		tv := info.Types[arg]
+		if tv.Type != nil {
+			a.Type = types.TypeString(tv.Type, nil)
+		}
+		if tv.Value != nil {
+			if tv.Value.Kind() == constant.String {
+				a.Value = constant.StringVal(tv.Value)
+			} else {
+				a.Value = tv.Value.ExactString()
+			}
+		}
+		annotated.Annotations = append(annotated.Annotations, a)
+	}
+	return &annotated, nil
+}
+
+// processGroup creates a group member from a wire.Group call.
+func (oc *objectCache) processGroup(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.Group.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Group takes exactly two arguments"))}
+	}
+	groupArgType := info.TypeOf(call.Args[0])
+	ptr, ok := groupArgType.(*types.Pointer)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Group must be a pointer to a slice type; found %s", types.TypeString(groupArgType, nil)))}
+	}
+	group := ptr.Elem()
+	slice, ok := group.Underlying().(*types.Slice)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Group must be a pointer to a slice type; found %s", types.TypeString(groupArgType, nil)))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.Lazy || provider.NoValue || provider.TypeAssert || provider.Select || provider.Group != nil {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to Group must be a provider function"))}
+	}
+	if provider.Singleton {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("singleton provider %s cannot be a group member", provider.Name))}
+	}
+	if !types.AssignableTo(provider.Out[0], slice.Elem()) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("%s returns %s, which is not assignable to the element type of %s", provider.Name, types.TypeString(provider.Out[0], nil), types.TypeString(group, nil)))}
+	}
+	// The placeholder type is keyed by provider and group so that including
+	// the same wire.Group call in two sets is reported as a conflict.
+	key := provider.Pkg.Path() + "." + provider.Name + " " + types.TypeString(group, nil)
+	out := oc.groupTypes[key]
+	if out == nil {
+		out = types.NewNamed(types.NewTypeName(provider.Pos, provider.Pkg, provider.Name, nil), types.NewStruct(nil, nil), nil)
+		oc.groupTypes[key] = out
+	}
+	member := *provider
+	member.Out = []types.Type{out}
+	member.Group = group
+	member.GroupOut = provider.Out[0]
+	return &member, nil
+}
+
+// processConvert creates a provider from a wire.Convert call.
+func (oc *objectCache) processConvert(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.Convert.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Convert takes exactly two arguments"))}
+	}
+	typArgType := info.TypeOf(call.Args[0])
+	ptr, ok := typArgType.(*types.Pointer)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Convert must be a pointer to a type; found %s", types.TypeString(typArgType, nil)))}
+	}
+	target := ptr.Elem()
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.Lazy || provider.NoValue || provider.TypeAssert || provider.Select {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to Convert must be a conversion function"))}
+	}
+	if len(provider.Args) != 1 || provider.Varargs {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("conversion function %s must take exactly one argument; found %d", provider.Name, len(provider.Args)))}
+	}
+	if provider.HasCleanup {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("conversion function %s may not return a cleanup function", provider.Name))}
+	}
+	if !types.Identical(provider.Out[0], target) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("conversion function %s returns %s; want %s", provider.Name, types.TypeString(provider.Out[0], nil), types.TypeString(target, nil)))}
+	}
+	if types.Identical(provider.Args[0].Type, target) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("conversion function %s converts %s to itself", provider.Name, types.TypeString(target, nil)))}
+	}
+	return provider, nil
+}
+
+// processWrap creates a Wrap from a wire.Wrap call.
+func (oc *objectCache) processWrap(info *types.Info, pkgPath string, call *ast.CallExpr) (*Wrap, []error) {
+	// Assumes that call.Fun is wire.Wrap.
+
+	if len(call.Args) < 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Wrap takes a pointer to a type and at least one wrapper function"))}
+	}
+	typArgType := info.TypeOf(call.Args[0])
+	ptr, ok := typArgType.(*types.Pointer)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Wrap must be a pointer to a type; found %s", types.TypeString(typArgType, nil)))}
+	}
+	w := &Wrap{Type: ptr.Elem(), Pos: call.Pos()}
+	ts := types.TypeString(w.Type, nil)
+	ec := new(errorCollector)
+	for _, arg := range call.Args[1:] {
+		argPos := oc.fset.Position(arg.Pos())
+		item, errs := oc.processExpr(info, pkgPath, arg, "")
+		if len(errs) > 0 {
+			ec.add(errs...)
+			continue
+		}
+		p, ok := item.(*Provider)
+		if !ok || p.IsStruct || p.Lazy || p.NoValue || p.TypeAssert || p.Select || p.Group != nil || p.Singleton || p.Eager {
+			ec.add(notePosition(argPos, fmt.Errorf("wrapper of %s must be a function", ts)))
+			continue
+		}
+		if !types.Identical(p.Out[0], w.Type) {
+			ec.add(notePosition(argPos, fmt.Errorf("wrapper %s returns %s; want %s", p.Name, types.TypeString(p.Out[0], nil), ts)))
+			continue
+		}
+		if wrappedArg(p, w.Type) < 0 {
+			// Providers never take two arguments of the same type.
+			ec.add(notePosition(argPos, fmt.Errorf("wrapper %s does not take an argument of type %s", p.Name, ts)))
+			continue
+		}
+		w.Funcs = append(w.Funcs, p)
+	}
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	return w, nil
+}
+
+// processContext creates a provider of context.Context from a wire.Context
+// or wire.ContextFrom call. Its result is named ctx in generated injectors.
+func (oc *objectCache) processContext(info *types.Info, pkgPath string, call *ast.CallExpr, fnObj types.Object) (*Provider, []error) {
+	// Assumes that call.Fun is wire.Context or wire.ContextFrom.
+
+	var fn *types.Func
+	if fnObj.Name() == "Context" {
+		for _, imp := range fnObj.Pkg().Imports() {
+			if imp.Path() == "context" {
+				fn, _ = imp.Scope().Lookup("Background").(*types.Func)
+			}
+		}
+		if fn == nil {
+			return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+				errors.New("wire package does not import context"))}
+		}
+	} else {
+		fn, _ = qualifiedIdentObject(info, call.Args[0]).(*types.Func)
+		if fn == nil || fn.Type().(*types.Signature).Recv() != nil {
+			return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+				errors.New("argument to ContextFrom must be a function declared at package level"))}
+		}
+	}
+	item, errs := oc.get(fn)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	p := *item.(*Provider)
+	if fnObj.Name() == "Context" {
+		// Point errors at the call rather than into the context package.
+		p.Pos = call.Pos()
+	}
+	if p.ResultName == "" {
+		p.ResultName = "ctx"
+	}
+	return &p, nil
+}
+
+// processNoValue creates a provider from a wire.NoValue call.
+func (oc *objectCache) processNoValue(info *types.Info, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.NoValue.
+
+	if len(call.Args) != 1 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to NoValue takes exactly one argument"))}
+	}
+	fn, ok := qualifiedIdentObject(info, astutil.Unparen(call.Args[0])).(*types.Func)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("argument to NoValue must be a function"))}
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Results().Len() > 0 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("argument to NoValue must be a function with no results; %s returns %s", fn.Name(), types.TypeString(sig.Results(), nil)))}
+	}
+	args, err := providerArgs(sig.Params())
+	if err != nil {
+		return nil, []error{notePosition(oc.fset.Position(fn.Pos()), err)}
+	}
+	// The placeholder type is keyed by function so that including the same
+	// wire.NoValue call in two sets is reported as a conflict.
+	out := oc.noValueTypes[fn]
+	if out == nil {
+		out = types.NewNamed(types.NewTypeName(fn.Pos(), fn.Pkg(), fn.Name(), nil), types.NewStruct(nil, nil), nil)
+		oc.noValueTypes[fn] = out
+	}
+	return &Provider{
+		Pkg:     fn.Pkg(),
+		Name:    fn.Name(),
+		Pos:     fn.Pos(),
+		Args:    args,
+		Varargs: sig.Variadic(),
+		Out:     []types.Type{out},
+		NoValue: true,
+	}, nil
+}
+
+// isSetFactory reports whether fn is a function that returns a provider set,
+// like func StoreSet(prefix string) wire.ProviderSet.
+func isSetFactory(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	return sig.Recv() == nil && sig.Results().Len() == 1 && isProviderSetType(sig.Results().At(0).Type())
+}
+
+// processSetFactory evaluates a call to a set factory. The factory's body
+// must be a single return statement, which is processed with each of the
+// factory's parameters replaced by the corresponding argument of the call.
+// Arguments must be boolean, string, or numeric constants, so the result
+// does not depend on anything that happens at run time.
+func (oc *objectCache) processSetFactory(info *types.Info, call *ast.CallExpr, fn *types.Func) (*ProviderSet, []error) {
+	// Assumes that isSetFactory(fn) is true.
+
+	callPos := oc.fset.Position(call.Pos())
+	sig := fn.Type().(*types.Signature)
+	if sig.Variadic() {
+		return nil, []error{notePosition(callPos,
+			fmt.Errorf("set factory %s cannot be variadic", fn.Name()))}
+	}
+	if oc.factories[fn] {
+		return nil, []error{notePosition(callPos,
+			fmt.Errorf("set factory %s calls itself", fn.Name()))}
+	}
+	decl := oc.funcDecl(fn)
+	if decl == nil || decl.Body == nil {
+		return nil, []error{notePosition(callPos,
+			fmt.Errorf("cannot find the declaration of set factory %s", fn.Name()))}
+	}
+	var ret *ast.ReturnStmt
+	if len(decl.Body.List) == 1 {
+		ret, _ = decl.Body.List[0].(*ast.ReturnStmt)
+	}
+	if ret == nil || len(ret.Results) != 1 {
+		return nil, []error{notePosition(oc.fset.Position(decl.Pos()),
+			fmt.Errorf("set factory %s must consist of a single return statement", fn.Name()))}
+	}
+	params := make(map[types.Object]constant.Value, len(call.Args))
+	for i, arg := range call.Args {
+		val := info.Types[arg].Value
+		if val == nil {
+			return nil, []error{notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("argument to set factory %s must be a constant; found %s", fn.Name(), types.ExprString(arg)))}
+		}
+		switch val.Kind() {
+		case constant.Bool, constant.String, constant.Int, constant.Float:
+		default:
+			return nil, []error{notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("argument to set factory %s must be a boolean, string, or numeric constant; found %s", fn.Name(), types.ExprString(arg)))}
+		}
+		param := sig.Params().At(i)
+		if !isConstantType(param.Type()) {
+			return nil, []error{notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("parameter %s of set factory %s must have a boolean, string, or numeric type; found %s", param.Name(), fn.Name(), types.TypeString(param.Type(), nil)))}
+		}
+		params[param] = val
+	}
+
+	oc.factories[fn] = true
+	defer delete(oc.factories, fn)
+	pkgPath := fn.Pkg().Path()
+	fnInfo, expr, err := substituteParams(oc.packages[pkgPath].TypesInfo, ret.Results[0], params)
+	if err != nil {
+		return nil, []error{notePosition(callPos, err)}
+	}
+	item, errs := oc.processExpr(fnInfo, pkgPath, expr, "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	pset, ok := item.(*ProviderSet)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(ret.Pos()),
+			fmt.Errorf("set factory %s must return a provider set", fn.Name()))}
+	}
+	return pset, nil
+}
+
+// isConstantType reports whether t is a boolean, string, or numeric type,
+// whose values a set factory can substitute for its parameters.
+func isConstantType(t types.Type) bool {
+	switch t.(type) {
+	case *types.Basic, *types.Named:
+		b, ok := t.Underlying().(*types.Basic)
+		return ok && b.Info()&types.IsConstType != 0
+	}
+	return false
+}
+
+// substituteParams returns a copy of expr with each identifier that refers
+// to a key of params replaced by an expression for its constant value,
+// along with type information for the copy. The returned info holds the
+// entries of info for the nodes of expr, keyed by their copies, and those
+// of the new nodes. It shares the other maps of info, which it does not
+// modify, so the package's own type information is left unchanged.
+func substituteParams(info *types.Info, expr ast.Expr, params map[types.Object]constant.Value) (*types.Info, ast.Expr, error) {
+	orig := info
+	info = new(types.Info)
+	*info = *orig
+	info.Types = make(map[ast.Expr]types.TypeAndValue)
+	info.Uses = make(map[*ast.Ident]types.Object)
+	info.Selections = make(map[*ast.SelectorExpr]*types.Selection)
+	info.Scopes = make(map[ast.Node]*types.Scope)
+	cp := copyAST(expr)
+	// copyAST preserves the shape of the tree, so visiting both trees in the
+	// same order pairs each node with its copy.
+	var nodes []ast.Node
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	i := 0
+	ast.Inspect(cp, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+		o := nodes[i]
+		i++
+		if e, ok := o.(ast.Expr); ok {
+			if tv, ok := orig.Types[e]; ok {
+				info.Types[node.(ast.Expr)] = tv
+			}
+		}
+		if id, ok := o.(*ast.Ident); ok {
+			if obj := orig.Uses[id]; obj != nil {
+				info.Uses[id] = obj
+			}
+		}
+		if sel, ok := o.(*ast.SelectorExpr); ok {
+			if s := orig.Selections[sel]; s != nil {
+				info.Selections[node.(*ast.SelectorExpr)] = s
+			}
+		}
+		if scope := orig.Scopes[o]; scope != nil {
+			info.Scopes[node] = scope
+		}
+		return true
+	})
+	var err error
+	expr = astutil.Apply(cp, func(c *astutil.Cursor) bool {
+		id, ok := c.Node().(*ast.Ident)
+		if !ok || err != nil {
+			return err == nil
+		}
+		obj := info.Uses[id]
+		if val, ok := params[obj]; ok {
+			var lit ast.Expr
+			lit, err = constantExpr(info, id.Pos(), val, obj.Type())
+			if err == nil {
+				c.Replace(lit)
+			}
+		}
+		return false
+	}, nil).(ast.Expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, expr, nil
+}
+
+// constantExpr returns an expression of type t with the constant value val,
+// recording its type information in info. The literal is converted to t
+// unless t is the literal's default type.
+func constantExpr(info *types.Info, pos token.Pos, val constant.Value, t types.Type) (ast.Expr, error) {
+	var lit ast.Expr
+	var litType types.Type
+	switch val.Kind() {
+	case constant.Bool:
+		id := ast.NewIdent(val.String())
+		id.NamePos = pos
+		info.Uses[id] = types.Universe.Lookup(id.Name)
+		lit, litType = id, types.Typ[types.Bool]
+	case constant.String:
+		lit, litType = &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: val.ExactString()}, types.Typ[types.String]
+	case constant.Int:
+		lit, litType = &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: val.ExactString()}, types.Typ[types.Int]
+	case constant.Float:
+		// The exact value is an integer or a fraction, which is written as
+		// a constant division so that no precision is lost.
+		litType = types.Typ[types.Float64]
+		s := val.ExactString()
+		if i := strings.Index(s, "/"); i >= 0 {
+			num := &ast.BasicLit{ValuePos: pos, Kind: token.FLOAT, Value: s[:i] + ".0"}
+			den := &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: s[i+1:]}
+			info.Types[num] = types.TypeAndValue{Type: types.Typ[types.UntypedFloat], Value: constant.MakeFromLiteral(num.Value, token.FLOAT, 0)}
+			info.Types[den] = types.TypeAndValue{Type: types.Typ[types.UntypedInt], Value: constant.MakeFromLiteral(den.Value, token.INT, 0)}
+			lit = &ast.BinaryExpr{X: num, OpPos: pos, Op: token.QUO, Y: den}
+		} else {
+			lit = &ast.BasicLit{ValuePos: pos, Kind: token.FLOAT, Value: s + ".0"}
+		}
+	default:
+		return nil, fmt.Errorf("cannot substitute constant %s of type %s", val, types.TypeString(t, nil))
+	}
+	info.Types[lit] = types.TypeAndValue{Type: litType, Value: val}
+	if types.Identical(t, litType) {
+		return lit, nil
+	}
+	var typeID *ast.Ident
+	switch t := t.(type) {
+	case *types.Named:
+		typeID = &ast.Ident{NamePos: pos, Name: t.Obj().Name()}
+		info.Uses[typeID] = t.Obj()
+	case *types.Basic:
+		typeID = &ast.Ident{NamePos: pos, Name: t.Name()}
+		info.Uses[typeID] = types.Universe.Lookup(t.Name())
+	default:
+		return nil, fmt.Errorf("cannot substitute constant %s of type %s", val, types.TypeString(t, nil))
+	}
+	conv := &ast.CallExpr{Fun: typeID, Lparen: pos, Args: []ast.Expr{lit}, Rparen: pos}
+	info.Types[typeID] = types.TypeAndValue{Type: t}
+	info.Types[conv] = types.TypeAndValue{Type: t, Value: val}
+	return conv, nil
+}
+
+// processAs creates a provider from a wire.As call.
+func processAs(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*Provider, error) {
+	// Assumes that call.Fun is wire.As.
+
+	if len(call.Args) != 1 && len(call.Args) != 2 {
+		return nil, notePosition(fset.Position(call.Pos()),
+			errors.New("call to As takes one or two arguments"))
+	}
+	targetArgType := info.TypeOf(call.Args[0])
+	targetPtr, ok := targetArgType.(*types.Pointer)
+	if !ok {
+		return nil, notePosition(fset.Position(call.Pos()),
+			fmt.Errorf("first argument to As must be a pointer to a type; found %s", types.TypeString(targetArgType, nil)))
+	}
+	target := targetPtr.Elem()
+	var from types.Type = types.NewInterfaceType(nil, nil).Complete()
+	if len(call.Args) == 2 {
+		fromArgType := info.TypeOf(call.Args[1])
+		fromPtr, ok := fromArgType.(*types.Pointer)
+		if !ok || !types.IsInterface(fromPtr.Elem()) {
+			return nil, notePosition(fset.Position(call.Pos()),
+				fmt.Errorf("second argument to As must be a pointer to an interface type; found %s", types.TypeString(fromArgType, nil)))
+		}
+		from = fromPtr.Elem()
+	}
+	return typeAssertion(fset, call.Pos(), qualifiedIdentObject(info, call.Fun), target, from)
+}
+
+// typeAssertion creates the provider that asserts values of the interface
+// type from to target for a wire.As or wire.Assert call at pos. fnObj is the
+// wire function called.
+func typeAssertion(fset *token.FileSet, pos token.Pos, fnObj types.Object, target, from types.Type) (*Provider, error) {
+	switch {
+	case types.Identical(target, from):
+		return nil, notePosition(fset.Position(pos),
+			fmt.Errorf("cannot assert %s to itself", types.TypeString(target, nil)))
+	case types.AssignableTo(from, target):
+		return nil, notePosition(fset.Position(pos),
+			fmt.Errorf("%s is assignable to %s without an assertion; use Bind instead", types.TypeString(from, nil), types.TypeString(target, nil)))
+	case !types.AssertableTo(from.Underlying().(*types.Interface), target):
+		return nil, notePosition(fset.Position(pos),
+			fmt.Errorf("impossible type assertion: %s does not implement %s", types.TypeString(target, nil), types.TypeString(from, nil)))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", notePosition(fset.Position(pos),
+		fmt.Errorf("wire.%s cannot verify that %s values are %s; the generated injector checks at run time", fnObj.Name(), types.TypeString(from, nil), types.TypeString(target, nil))))
+	return &Provider{
+		Pkg:        fnObj.Pkg(),
+		Name:       fnObj.Name(),
+		Pos:        pos,
+		Args:       []ProviderInput{{Type: from}},
+		Out:        []types.Type{target},
+		HasErr:     true,
+		TypeAssert: true,
+	}, nil
+}
+
+// processAssert creates a provider set from a wire.Assert call. The set
+// contains the provider and an assertion of its output, which must be of
+// interface type, to the target type.
+func (oc *objectCache) processAssert(info *types.Info, pkgPath string, call *ast.CallExpr) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.Assert.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Assert takes exactly two arguments"))}
+	}
+	targetArgType := info.TypeOf(call.Args[0])
+	targetPtr, ok := targetArgType.(*types.Pointer)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Assert must be a pointer to a type; found %s", types.TypeString(targetArgType, nil)))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.NoValue || provider.IsGroup || provider.Lazy || provider.Select || provider.TypeAssert {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to Assert must be a provider function"))}
+	}
+	from := provider.Out[0]
+	if !types.IsInterface(from) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("%s returns %s, which is not an interface type", provider.Name, types.TypeString(from, nil)))}
+	}
+	assertion, err := typeAssertion(oc.fset, call.Pos(), qualifiedIdentObject(info, call.Fun), targetPtr.Elem(), from)
+	if err != nil {
+		return nil, []error{err}
+	}
+	pset := &ProviderSet{
+		Pos:       call.Pos(),
+		PkgPath:   pkgPath,
+		Providers: []*Provider{provider, assertion},
+	}
+	pset.providerMap, pset.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return pset, nil
+}
+
+// processImplement creates a provider set from a wire.Implement call. The
+// set contains a provider of the interface whose arguments are the
+// components, each of which implements some of the interface's methods.
+// Every method must be implemented by exactly one component, and every
+// component must implement at least one method.
+func (oc *objectCache) processImplement(info *types.Info, pkgPath string, call *ast.CallExpr) (*ProviderSet, []error) {
+	// Assumes that call.Fun is wire.Implement.
+
+	if len(call.Args) < 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to Implement must specify the interface and at least one component"))}
+	}
+	ifaceArgType := info.TypeOf(call.Args[0])
+	ifacePtr, ok := ifaceArgType.(*types.Pointer)
+	if !ok || !types.IsInterface(ifacePtr.Elem()) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Implement must be a pointer to a named interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	iface := ifacePtr.Elem()
+	if _, ok := iface.(*types.Named); !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to Implement must be a pointer to a named interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	provider := &Provider{
+		Pkg:  qualifiedIdentObject(info, call.Fun).Pkg(),
+		Name: "Implement",
+		Pos:  call.Pos(),
+		Out:  []types.Type{iface},
+	}
+	ec := new(errorCollector)
+	for _, arg := range call.Args[1:] {
+		argType := info.TypeOf(arg)
+		ptr, ok := argType.(*types.Pointer)
+		if !ok {
+			ec.add(notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("component argument to Implement must be a pointer to a type; found %s", types.TypeString(argType, nil))))
+			continue
+		}
+		t := ptr.Elem()
+		switch {
+		case types.Identical(t, iface):
+			ec.add(notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("%s cannot be a component of itself", types.TypeString(iface, nil))))
+			continue
+		case providerArg(provider, t):
+			ec.add(notePosition(oc.fset.Position(arg.Pos()),
+				fmt.Errorf("component %s is passed to Implement more than once", types.TypeString(t, nil))))
+			continue
+		}
+		provider.Args = append(provider.Args, ProviderInput{Type: t})
+	}
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	methods := iface.Underlying().(*types.Interface)
+	implemented := make([]bool, len(provider.Args))
+	for i := 0; i < methods.NumMethods(); i++ {
+		m := methods.Method(i)
+		sig := m.Type().(*types.Signature)
+		var found []int
+		var mismatch *types.Func
+		for j, arg := range provider.Args {
+			obj, _, _ := types.LookupFieldOrMethod(arg.Type, true, m.Pkg(), m.Name())
+			fn, ok := obj.(*types.Func)
+			switch {
+			case ok && types.Identical(fn.Type(), sig):
+				found = append(found, j)
+				implemented[j] = true
+			case ok && mismatch == nil:
+				mismatch = fn
+			}
+		}
+		switch {
+		case len(found) == 0 && mismatch != nil:
+			ec.add(notePosition(oc.fset.Position(call.Pos()),
+				fmt.Errorf("method %s of %s is not implemented by any component; %s has signature %s, want %s", m.Name(), types.TypeString(iface, nil), mismatch.FullName(), types.TypeString(mismatch.Type(), nil), types.TypeString(sig, nil))))
+			continue
+		case len(found) == 0:
+			ec.add(notePosition(oc.fset.Position(call.Pos()),
+				fmt.Errorf("method %s of %s is not implemented by any component", m.Name(), types.TypeString(iface, nil))))
+			continue
+		case len(found) > 1:
+			ec.add(notePosition(oc.fset.Position(call.Pos()),
+				fmt.Errorf("method %s of %s is implemented by both %s and %s", m.Name(), types.TypeString(iface, nil), types.TypeString(provider.Args[found[0]].Type, nil), types.TypeString(provider.Args[found[1]].Type, nil))))
+			continue
+		}
+		provider.Implement = append(provider.Implement, ImplementMethod{
+			Name: m.Name(),
+			Sig:  sig,
+			Arg:  found[0],
+		})
+	}
+	for j, ok := range implemented {
+		if !ok {
+			ec.add(notePosition(oc.fset.Position(call.Args[j+1].Pos()),
+				fmt.Errorf("component %s implements no method of %s", types.TypeString(provider.Args[j].Type, nil), types.TypeString(iface, nil))))
+		}
+	}
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	pset := &ProviderSet{
+		Pos:       call.Pos(),
+		PkgPath:   pkgPath,
+		Providers: []*Provider{provider},
+	}
+	var errs []error
+	pset.providerMap, pset.srcMap, errs = buildProviderMap(oc.fset, oc.hasher, pset)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return pset, nil
+}
+
+// providerArg reports whether t is the type of one of p's arguments.
+func providerArg(p *Provider, t types.Type) bool {
+	for _, a := range p.Args {
+		if types.Identical(a.Type, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// processProvideFunc creates a provider from a wire.ProvideFunc call. It is
+// a copy of the provider function passed to ProvideFunc whose output is the
+// interface type.
+func (oc *objectCache) processProvideFunc(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.ProvideFunc.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to ProvideFunc takes exactly two arguments"))}
+	}
+	ifaceArgType := info.TypeOf(call.Args[0])
+	ifacePtr, ok := ifaceArgType.(*types.Pointer)
+	if !ok || !types.IsInterface(ifacePtr.Elem()) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to ProvideFunc must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	iface := ifacePtr.Elem()
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
 		return nil, errs
 	}
-	return pset, nil
+	provider, ok := item.(*Provider)
+	if !ok || provider.IsStruct || provider.NoValue || provider.IsGroup || provider.Lazy || provider.Select || provider.TypeAssert {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to ProvideFunc must be a provider function"))}
+	}
+	if provided := provider.Out[0]; !types.Implements(provided, iface.Underlying().(*types.Interface)) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("%s returns %s, which does not implement %s", provider.Name, types.TypeString(provided, nil), types.TypeString(iface, nil)))}
+	}
+	p := *provider
+	p.Out = []types.Type{iface}
+	return &p, nil
+}
+
+// processBindInterface creates a provider from a wire.BindInterface call.
+func (oc *objectCache) processBindInterface(info *types.Info, pkgPath string, call *ast.CallExpr) (*Provider, []error) {
+	// Assumes that call.Fun is wire.BindInterface.
+
+	if len(call.Args) != 2 {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("call to BindInterface takes exactly two arguments"))}
+	}
+	ifaceArgType := info.TypeOf(call.Args[0])
+	ifacePtr, ok := ifaceArgType.(*types.Pointer)
+	if !ok || !types.IsInterface(ifacePtr.Elem()) {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("first argument to BindInterface must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))}
+	}
+	iface := ifacePtr.Elem()
+	fn, ok := qualifiedIdentObject(info, call.Args[1]).(*types.Func)
+	if !ok {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			errors.New("second argument to BindInterface must be a selector function"))}
+	}
+	item, errs := oc.get(fn)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	provider := item.(*Provider)
+	if provider.HasCleanup || provider.Singleton || provider.Closes || provider.Eager {
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("selector %s may not return a cleanup function or be marked with a directive", fn.Name()))}
+	}
+	result := provider.Out[0]
+	switch {
+	case !types.IsInterface(result):
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("selector %s returns %s; want an interface type such as interface{}", fn.Name(), types.TypeString(result, nil)))}
+	case types.AssignableTo(result, iface):
+		return nil, []error{notePosition(oc.fset.Position(call.Pos()),
+			fmt.Errorf("selector %s returns %s, which is assignable to %s; use %s as a provider instead", fn.Name(), types.TypeString(result, nil), types.TypeString(iface, nil), fn.Name()))}
+	}
+	if errs := oc.checkSelectorReturns(fn, iface); len(errs) > 0 {
+		return nil, errs
+	}
+	sel := *provider
+	sel.Out = []types.Type{iface}
+	sel.Select = true
+	sel.SelectErr = provider.HasErr
+	sel.HasErr = true
+	return &sel, nil
+}
+
+// checkSelectorReturns verifies that the values returned by the return
+// statements of the selector function fn implement iface. Values of
+// interface type, and those of selectors whose source is not available, are
+// checked by the injector at run time.
+func (oc *objectCache) checkSelectorReturns(fn *types.Func, iface types.Type) []error {
+	pkg := oc.packages[fn.Pkg().Path()]
+	if pkg == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	var body *ast.BlockStmt
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && pkg.TypesInfo.Defs[fd.Name] == fn {
+				body = fd.Body
+			}
+		}
+	}
+	if body == nil {
+		return nil
+	}
+	methods := iface.Underlying().(*types.Interface)
+	ec := new(errorCollector)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Returns in function literals do not return from fn.
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) == 0 {
+				return true
+			}
+			t := pkg.TypesInfo.TypeOf(n.Results[0])
+			if t == nil || types.IsInterface(t) {
+				return true
+			}
+			if _, ok := t.(*types.Tuple); ok {
+				return true
+			}
+			if b, ok := t.(*types.Basic); ok && b.Kind() == types.UntypedNil {
+				return true
+			}
+			if !types.Implements(t, methods) {
+				ec.add(notePosition(oc.fset.Position(n.Results[0].Pos()),
+					fmt.Errorf("selector %s returns %s, which does not implement %s", fn.Name(), types.TypeString(t, nil), types.TypeString(iface, nil))))
+			}
+		}
+		return true
+	})
+	return ec.errors
 }
 
 // structArgType attempts to interpret an expression as a simple struct type.
@@ -673,43 +2915,275 @@ func qualifiedIdentObject(info *types.Info, expr ast.Expr) types.Object {
 }
 
 // processFuncProvider creates a provider for a function declaration.
-func processFuncProvider(fset *token.FileSet, fn *types.Func) (*Provider, []error) {
-	sig := fn.Type().(*types.Signature)
+// doc is the function's doc comment, which may hold Wire directives.
+func processFuncProvider(fset *token.FileSet, fn *types.Func, doc *ast.CommentGroup) (*Provider, []error) {
+	return processFuncInstance(fset, fn, fn.Type().(*types.Signature), nil, doc)
+}
+
+// processFuncInstance creates a provider for a function with the given
+// signature. For a generic function, sig is the signature of its
+// instantiation with typeArgs.
+func processFuncInstance(fset *token.FileSet, fn *types.Func, sig *types.Signature, typeArgs []types.Type, doc *ast.CommentGroup) (*Provider, []error) {
 	fpos := fn.Pos()
+	if len(typeArgs) > 1 {
+		return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s has %d type parameters; only one is supported", fn.Name(), len(typeArgs)))}
+	}
 	providerSig, err := funcOutput(sig)
 	if err != nil {
-		return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("wrong signature for provider %s: %v", fn.Name(), err))}
+		return nil, []error{notePosition(fset.Position(fpos), &SignatureError{Func: fn.Name(), Err: err})}
+	}
+	args, err := providerArgs(sig.Params())
+	if err != nil {
+		return nil, []error{notePosition(fset.Position(fpos), err)}
 	}
-	params := sig.Params()
 	provider := &Provider{
 		Pkg:        fn.Pkg(),
 		Name:       fn.Name(),
+		TypeArgs:   typeArgs,
 		Pos:        fn.Pos(),
-		Args:       make([]ProviderInput, params.Len()),
+		Args:       args,
 		Varargs:    sig.Variadic(),
 		Out:        []types.Type{providerSig.out},
 		HasCleanup: providerSig.cleanup,
 		HasErr:     providerSig.err,
 	}
-	for i := 0; i < params.Len(); i++ {
-		provider.Args[i] = ProviderInput{
+	if name := sig.Results().At(0).Name(); name != "_" {
+		provider.ResultName = name
+	}
+	if hasDirective(doc, "closes") {
+		switch {
+		case provider.HasCleanup:
+			return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s marked //wire:closes may not also return a cleanup function", fn.Name()))}
+		case !isCloser(providerSig.out) && isRecvChan(providerSig.out):
+			return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s marked //wire:closes returns %s, which is a receive-only channel", fn.Name(), types.TypeString(providerSig.out, nil)))}
+		case !isCloser(providerSig.out) && !isChan(providerSig.out):
+			return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s marked //wire:closes returns %s, which does not implement io.Closer", fn.Name(), types.TypeString(providerSig.out, nil)))}
+		}
+		provider.Closes = true
+	}
+	if hasDirective(doc, "singleton") {
+		if provider.HasCleanup {
+			return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("singleton provider %s may not return a cleanup function", fn.Name()))}
+		}
+		if provider.Closes {
+			return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("singleton provider %s may not be marked //wire:closes", fn.Name()))}
+		}
+		provider.Singleton = true
+	}
+	provider.Eager = hasDirective(doc, "eager")
+	strategy, err := strategyDirective(doc)
+	if err != nil {
+		return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s: %v", fn.Name(), err))}
+	}
+	provider.Strategy = strategy
+	constraint, err := constraintDirective(doc)
+	if err != nil {
+		return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider %s: %v", fn.Name(), err))}
+	}
+	provider.Constraint = constraint
+	return provider, nil
+}
+
+// isCloser reports whether t implements io.Closer.
+func isCloser(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "Close")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), errorType)
+}
+
+// isChan reports whether the values of t are channels that can be closed
+// with the builtin close.
+func isChan(t types.Type) bool {
+	ch, ok := t.Underlying().(*types.Chan)
+	return ok && ch.Dir() != types.RecvOnly
+}
+
+// isRecvChan reports whether the values of t are receive-only channels.
+func isRecvChan(t types.Type) bool {
+	ch, ok := t.Underlying().(*types.Chan)
+	return ok && ch.Dir() == types.RecvOnly
+}
+
+// isNilExpr reports whether expr is the predeclared nil, possibly converted
+// to another type.
+func isNilExpr(info *types.Info, expr ast.Expr) bool {
+	expr = astutil.Unparen(expr)
+	if info.Types[expr].IsNil() {
+		return true
+	}
+	conv, ok := expr.(*ast.CallExpr)
+	return ok && len(conv.Args) == 1 && info.Types[conv.Fun].IsType() && isNilExpr(info, conv.Args[0])
+}
+
+// providerArgs returns the inputs of a provider function with the given
+// parameters. A provider may not have two parameters of the same type.
+func providerArgs(params *types.Tuple) ([]ProviderInput, error) {
+	args := make([]ProviderInput, params.Len())
+	for i := range args {
+		args[i] = ProviderInput{
 			Type: params.At(i).Type(),
 		}
 		for j := 0; j < i; j++ {
-			if types.Identical(provider.Args[i].Type, provider.Args[j].Type) {
-				return nil, []error{notePosition(fset.Position(fpos), fmt.Errorf("provider has multiple parameters of type %s", types.TypeString(provider.Args[j].Type, nil)))}
+			if types.Identical(args[i].Type, args[j].Type) {
+				return nil, fmt.Errorf("provider has multiple parameters of type %s", types.TypeString(args[j].Type, nil))
 			}
 		}
 	}
-	return provider, nil
+	return args, nil
+}
+
+// hasDirective reports whether doc contains a comment line of the form
+// //wire:name.
+func hasDirective(doc *ast.CommentGroup, name string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == "//wire:"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// strategyDirective returns the name given by a comment line of the form
+// //wire:strategy name in doc, or the empty string if there is none.
+func strategyDirective(doc *ast.CommentGroup) (string, error) {
+	return identDirective(doc, "strategy")
+}
+
+// containerDirective returns the type name given by a comment line of the
+// form //wire:container Name in doc, or the empty string if there is none.
+func containerDirective(doc *ast.CommentGroup) (string, error) {
+	return identDirective(doc, "container")
+}
+
+// identDirective returns the identifier given by a comment line of the form
+// //wire:directive ident in doc, or the empty string if there is none.
+func identDirective(doc *ast.CommentGroup, directive string) (string, error) {
+	if doc == nil {
+		return "", nil
+	}
+	prefix := "//wire:" + directive
+	name := ""
+	for _, c := range doc.List {
+		text := strings.TrimSpace(c.Text)
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		arg := text[len(prefix):]
+		if arg != "" && arg[0] != ' ' && arg[0] != '\t' {
+			// Another directive, such as //wire:strategyfoo.
+			continue
+		}
+		arg = strings.TrimSpace(arg)
+		if !isIdentifier(arg) {
+			return "", fmt.Errorf("%s must be followed by an identifier; found %q", prefix, arg)
+		}
+		if name != "" {
+			return "", fmt.Errorf("multiple %s directives", prefix)
+		}
+		name = arg
+	}
+	return name, nil
+}
+
+// ambientDirective returns the parameter names listed by comment lines of
+// the form //wire:ambient name... in doc, or nil if there are none.
+func ambientDirective(doc *ast.CommentGroup) ([]string, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	const prefix = "//wire:ambient"
+	var names []string
+	for _, c := range doc.List {
+		text := strings.TrimSpace(c.Text)
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		arg := text[len(prefix):]
+		if arg != "" && arg[0] != ' ' && arg[0] != '\t' {
+			continue
+		}
+		fields := strings.Fields(arg)
+		if len(fields) == 0 {
+			return nil, errors.New("//wire:ambient must be followed by parameter names")
+		}
+		for _, name := range fields {
+			if !isIdentifier(name) {
+				return nil, fmt.Errorf("//wire:ambient must be followed by parameter names; found %q", name)
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
 }
 
-func injectorFuncSignature(sig *types.Signature) (*types.Tuple, outputSignature, error) {
-	out, err := funcOutput(sig)
+// isIdentifier reports whether s is a Go identifier, as required of the
+// names given to directives such as //wire:strategy.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// injectorFuncSignature returns the inputs and the output signature of an
+// injector. use is true if the injector calls wire.Use, so that it returns
+// nothing or only an error.
+func injectorFuncSignature(sig *types.Signature, use bool) (*types.Tuple, outputSignature, error) {
+	out, err := injectorOutput(sig, use)
 	if err != nil {
 		return nil, outputSignature{}, err
 	}
-	return sig.Params(), out, nil
+	return injectorInputs(sig), out, nil
+}
+
+// injectorInputs returns the values given to an injector. If the injector
+// is a method, its receiver is the first input.
+func injectorInputs(sig *types.Signature) *types.Tuple {
+	params := sig.Params()
+	if sig.Recv() == nil {
+		return params
+	}
+	vars := make([]*types.Var, 0, params.Len()+1)
+	vars = append(vars, sig.Recv())
+	for i := 0; i < params.Len(); i++ {
+		vars = append(vars, params.At(i))
+	}
+	return types.NewTuple(vars...)
+}
+
+// injectorOutput validates an injector's return signature. use is true if
+// the injector calls wire.Use.
+func injectorOutput(sig *types.Signature, use bool) (outputSignature, error) {
+	if use {
+		return useOutput(sig)
+	}
+	return funcOutput(sig)
+}
+
+// useOutput validates the return signature of an injector that calls
+// wire.Use. Such an injector has no output; it may only return an error.
+func useOutput(sig *types.Signature) (outputSignature, error) {
+	results := sig.Results()
+	switch {
+	case results.Len() == 0:
+		return outputSignature{}, nil
+	case results.Len() == 1 && types.Identical(results.At(0).Type(), errorType):
+		return outputSignature{err: true}, nil
+	default:
+		return outputSignature{}, fmt.Errorf("injectors using wire.Use may only return an error; found %s", types.TypeString(results, nil))
+	}
 }
 
 type outputSignature struct {
@@ -719,6 +3193,11 @@ type outputSignature struct {
 }
 
 // funcOutput validates an injector or provider function's return signature.
+//
+// Only a last result of exactly the built-in error type marks a function
+// that can fail. Other error types, including named types that implement
+// error or whose underlying type is error, are ordinary values: they may be
+// a provider's first result or its inputs like any other type.
 func funcOutput(sig *types.Signature) (outputSignature, error) {
 	results := sig.Results()
 	switch results.Len() {
@@ -734,14 +3213,14 @@ func funcOutput(sig *types.Signature) (outputSignature, error) {
 		case types.Identical(t, cleanupType):
 			return outputSignature{out: out, cleanup: true}, nil
 		default:
-			return outputSignature{}, fmt.Errorf("second return type is %s; must be error or func()", types.TypeString(t, nil))
+			return outputSignature{}, fmt.Errorf("second return type is %s; must be error or func()%s", types.TypeString(t, nil), namedErrorHint(t))
 		}
 	case 3:
 		if t := results.At(1).Type(); !types.Identical(t, cleanupType) {
 			return outputSignature{}, fmt.Errorf("second return type is %s; must be func()", types.TypeString(t, nil))
 		}
 		if t := results.At(2).Type(); !types.Identical(t, errorType) {
-			return outputSignature{}, fmt.Errorf("third return type is %s; must be error", types.TypeString(t, nil))
+			return outputSignature{}, fmt.Errorf("third return type is %s; must be error%s", types.TypeString(t, nil), namedErrorHint(t))
 		}
 		return outputSignature{
 			out:     results.At(0).Type(),
@@ -753,6 +3232,15 @@ func funcOutput(sig *types.Signature) (outputSignature, error) {
 	}
 }
 
+// namedErrorHint returns a note to append to a return signature error if t
+// is an error type other than the built-in error.
+func namedErrorHint(t types.Type) string {
+	if !types.Implements(t, errorType.Underlying().(*types.Interface)) {
+		return ""
+	}
+	return " (only the built-in error type is treated as a failure result)"
+}
+
 // processStructLiteralProvider creates a provider for a named struct type.
 // It produces pointer and non-pointer variants via two values in Out.
 //
@@ -797,24 +3285,24 @@ func processStructLiteralProvider(fset *token.FileSet, typeName *types.TypeName)
 
 // processStructProvider creates a provider for a named struct type.
 // It produces pointer and non-pointer variants via two values in Out.
-func processStructProvider(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*Provider, error) {
+func (oc *objectCache) processStructProvider(info *types.Info, call *ast.CallExpr) (*Provider, error) {
 	// Assumes that call.Fun is wire.Struct.
 
 	if len(call.Args) < 1 {
-		return nil, notePosition(fset.Position(call.Pos()),
+		return nil, notePosition(oc.fset.Position(call.Pos()),
 			errors.New("call to Struct must specify the struct to be injected"))
 	}
 	const firstArgReqFormat = "first argument to Struct must be a pointer to a named struct; found %s"
 	structType := info.TypeOf(call.Args[0])
 	structPtr, ok := structType.(*types.Pointer)
 	if !ok {
-		return nil, notePosition(fset.Position(call.Pos()),
+		return nil, notePosition(oc.fset.Position(call.Pos()),
 			fmt.Errorf(firstArgReqFormat, types.TypeString(structType, nil)))
 	}
 
 	st, ok := structPtr.Elem().Underlying().(*types.Struct)
 	if !ok {
-		return nil, notePosition(fset.Position(call.Pos()),
+		return nil, notePosition(oc.fset.Position(call.Pos()),
 			fmt.Errorf(firstArgReqFormat, types.TypeString(structPtr, nil)))
 	}
 
@@ -829,33 +3317,44 @@ func processStructProvider(fset *token.FileSet, info *types.Info, call *ast.Call
 	}
 	if allFields(call) {
 		for i := 0; i < st.NumFields(); i++ {
-			if isPrevented(st.Tag(i)) {
+			if isPrevented(st.Tag(i)) || oc.noInject(st.Field(i)) {
 				continue
 			}
-			f := st.Field(i)
-			provider.Args = append(provider.Args, ProviderInput{
-				Type:      f.Type(),
-				FieldName: f.Name(),
-			})
+			in, err := structFieldInput(st.Field(i), st.Tag(i))
+			if err != nil {
+				return nil, notePosition(oc.fset.Position(st.Field(i).Pos()), err)
+			}
+			provider.Args = append(provider.Args, in)
 		}
 	} else {
 		provider.Args = make([]ProviderInput, len(call.Args)-1)
 		for i := 1; i < len(call.Args); i++ {
 			v, err := checkField(call.Args[i], st)
 			if err != nil {
-				return nil, notePosition(fset.Position(call.Pos()), err)
+				return nil, notePosition(oc.fset.Position(call.Pos()), err)
+			}
+			if oc.noInject(v) {
+				return nil, notePosition(oc.fset.Position(call.Pos()), fmt.Errorf("%s is prevented from injecting by wire", call.Args[i].(*ast.BasicLit).Value))
 			}
-			provider.Args[i-1] = ProviderInput{
-				Type:      v.Type(),
-				FieldName: v.Name(),
+			in, err := structFieldInput(v, fieldTag(st, v))
+			if err != nil {
+				return nil, notePosition(oc.fset.Position(v.Pos()), err)
 			}
+			provider.Args[i-1] = in
 		}
 	}
 	for i := 0; i < len(provider.Args); i++ {
+		if provider.Args[i].ConfigKey != "" {
+			provider.HasErr = true
+			continue
+		}
 		for j := 0; j < i; j++ {
+			if provider.Args[j].ConfigKey != "" {
+				continue
+			}
 			if types.Identical(provider.Args[i].Type, provider.Args[j].Type) {
 				f := st.Field(j)
-				return nil, notePosition(fset.Position(f.Pos()), fmt.Errorf("provider struct has multiple fields of type %s", types.TypeString(provider.Args[j].Type, nil)))
+				return nil, notePosition(oc.fset.Position(f.Pos()), fmt.Errorf("provider struct has multiple fields of type %s", types.TypeString(provider.Args[j].Type, nil)))
 			}
 		}
 	}
@@ -880,6 +3379,70 @@ func isPrevented(tag string) bool {
 	return reflect.StructTag(tag).Get("wire") == "-"
 }
 
+// configMapType is the type of the config map that struct fields tagged
+// `wire:"key=..."` are read from.
+var configMapType = types.NewMap(types.Typ[types.String], types.NewInterfaceType(nil, nil).Complete())
+
+// structFieldInput returns the input that fills field f of a struct
+// provider, where tag is the field's tag. A field tagged `wire:"key=..."`
+// is read from the config map, so it must be a string, int or bool.
+func structFieldInput(f *types.Var, tag string) (ProviderInput, error) {
+	key, ok := configKey(tag)
+	if !ok {
+		return ProviderInput{Type: f.Type(), FieldName: f.Name()}, nil
+	}
+	if key == "" {
+		return ProviderInput{}, fmt.Errorf("field %s has an empty config key", f.Name())
+	}
+	if configFunc(f.Type()) == "" {
+		return ProviderInput{}, fmt.Errorf("field %s has config key %q but type %s; config fields must be string, int or bool", f.Name(), key, types.TypeString(f.Type(), nil))
+	}
+	return ProviderInput{
+		Type:      configMapType,
+		FieldName: f.Name(),
+		ConfigKey: key,
+		FieldType: f.Type(),
+	}, nil
+}
+
+// configKey returns the key named by a `wire:"key=..."` struct tag.
+func configKey(tag string) (string, bool) {
+	v := reflect.StructTag(tag).Get("wire")
+	if !strings.HasPrefix(v, "key=") {
+		return "", false
+	}
+	return strings.TrimPrefix(v, "key="), true
+}
+
+// configFunc returns the name of the function in package wire that reads a
+// config value of type t, or the empty string if t cannot be read from the
+// config map.
+func configFunc(t types.Type) string {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+	switch b.Kind() {
+	case types.String:
+		return "ConfigString"
+	case types.Int:
+		return "ConfigInt"
+	case types.Bool:
+		return "ConfigBool"
+	}
+	return ""
+}
+
+// fieldTag returns the tag of field f of st.
+func fieldTag(st *types.Struct, f *types.Var) string {
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i) == f {
+			return st.Tag(i)
+		}
+	}
+	return ""
+}
+
 // processBind creates an interface binding from a wire.Bind call.
 func processBind(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*IfaceBinding, error) {
 	// Assumes that call.Fun is wire.Bind.
@@ -896,11 +3459,7 @@ func processBind(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*If
 			fmt.Errorf("first argument to Bind must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))
 	}
 	iface := ifacePtr.Elem()
-	methodSet, ok := iface.Underlying().(*types.Interface)
-	if !ok {
-		return nil, notePosition(fset.Position(call.Pos()),
-			fmt.Errorf("first argument to Bind must be a pointer to an interface type; found %s", types.TypeString(ifaceArgType, nil)))
-	}
+	methodSet, isIface := iface.Underlying().(*types.Interface)
 
 	provided := info.TypeOf(call.Args[1])
 	if bindShouldUsePointer(info, call) {
@@ -915,9 +3474,17 @@ func processBind(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*If
 		return nil, notePosition(fset.Position(call.Pos()),
 			errors.New("cannot bind interface to itself"))
 	}
-	if !types.Implements(provided, methodSet) {
+	switch {
+	case isIface:
+		if !types.Implements(provided, methodSet) {
+			return nil, notePosition(fset.Position(call.Pos()),
+				fmt.Errorf("%s does not implement %s", types.TypeString(provided, nil), types.TypeString(iface, nil)))
+		}
+	case types.Identical(iface.Underlying(), provided.Underlying()):
+		// The generated code converts the provided value to iface.
+	default:
 		return nil, notePosition(fset.Position(call.Pos()),
-			fmt.Errorf("%s does not implement %s", types.TypeString(provided, nil), types.TypeString(iface, nil)))
+			fmt.Errorf("first argument to Bind must be a pointer to an interface type or to a type with the same underlying type as %s; found %s", types.TypeString(provided, nil), types.TypeString(ifaceArgType, nil)))
 	}
 	return &IfaceBinding{
 		Pos:      call.Pos(),
@@ -933,7 +3500,7 @@ func processValue(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*V
 	if len(call.Args) != 1 {
 		return nil, notePosition(fset.Position(call.Pos()), errors.New("call to Value takes exactly one argument"))
 	}
-	ok := true
+	ok, isCall := true, false
 	ast.Inspect(call.Args[0], func(node ast.Node) bool {
 		switch expr := node.(type) {
 		case nil, *ast.ArrayType, *ast.BasicLit, *ast.BinaryExpr, *ast.ChanType, *ast.CompositeLit, *ast.FuncType, *ast.Ident, *ast.IndexExpr, *ast.InterfaceType, *ast.KeyValueExpr, *ast.MapType, *ast.ParenExpr, *ast.SelectorExpr, *ast.SliceExpr, *ast.StarExpr, *ast.StructType, *ast.TypeAssertExpr:
@@ -944,10 +3511,10 @@ func processValue(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*V
 				return false
 			}
 		case *ast.CallExpr:
-			// Only acceptable if it's a type conversion.
+			// Function calls, unlike type conversions, are made each time
+			// the injector is called.
 			if _, isFunc := info.TypeOf(expr.Fun).(*types.Signature); isFunc {
-				ok = false
-				return false
+				isCall = true
 			}
 		default:
 			ok = false
@@ -963,11 +3530,17 @@ func processValue(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*V
 	if _, isInterfaceType := argType.Underlying().(*types.Interface); isInterfaceType {
 		return nil, notePosition(fset.Position(call.Pos()), fmt.Errorf("argument to Value may not be an interface value (found %s); use InterfaceValue instead", types.TypeString(argType, nil)))
 	}
+	// A nil channel blocks forever, so it is never a useful dependency.
+	if _, isChanType := argType.Underlying().(*types.Chan); isChanType && isNilExpr(info, call.Args[0]) {
+		return nil, notePosition(fset.Position(call.Pos()), fmt.Errorf("argument to Value is a nil %s; use make to create the channel", types.TypeString(argType, nil)))
+	}
 	return &Value{
-		Pos:  call.Args[0].Pos(),
-		Out:  info.TypeOf(call.Args[0]),
-		expr: call.Args[0],
-		info: info,
+		Pos:      call.Args[0].Pos(),
+		Out:      info.TypeOf(call.Args[0]),
+		expr:     call.Args[0],
+		info:     info,
+		isCall:   isCall,
+		constant: info.Types[call.Args[0]].Value,
 	}, nil
 }
 
@@ -1000,6 +3573,34 @@ func processInterfaceValue(fset *token.FileSet, info *types.Info, call *ast.Call
 	}, nil
 }
 
+// processEmbedValue creates a value from a wire.EmbedValue call.
+func processEmbedValue(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (*Value, error) {
+	// Assumes that call.Fun is wire.EmbedValue.
+
+	if len(call.Args) != 2 {
+		return nil, notePosition(fset.Position(call.Pos()), errors.New("call to EmbedValue takes exactly two arguments"))
+	}
+	typArgType := info.TypeOf(call.Args[0])
+	ptr, ok := typArgType.(*types.Pointer)
+	if !ok {
+		return nil, notePosition(fset.Position(call.Pos()), fmt.Errorf("first argument to EmbedValue must be a pointer to a type; found %s", types.TypeString(typArgType, nil)))
+	}
+	v, ok := qualifiedIdentObject(info, astutil.Unparen(call.Args[1])).(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil, notePosition(fset.Position(call.Pos()), fmt.Errorf("second argument to EmbedValue must be a package-level variable; found %s", types.ExprString(call.Args[1])))
+	}
+	if !types.AssignableTo(v.Type(), ptr.Elem()) {
+		return nil, notePosition(fset.Position(call.Pos()), fmt.Errorf("%s of type %s is not assignable to %s", v.Name(), types.TypeString(v.Type(), nil), types.TypeString(ptr.Elem(), nil)))
+	}
+	return &Value{
+		Pos:   call.Args[1].Pos(),
+		Out:   ptr.Elem(),
+		expr:  astutil.Unparen(call.Args[1]),
+		info:  info,
+		isVar: true,
+	}, nil
+}
+
 // processFieldsOf creates a slice of fields from a wire.FieldsOf call.
 func processFieldsOf(fset *token.FileSet, info *types.Info, call *ast.CallExpr) ([]*Field, error) {
 	// Assumes that call.Fun is wire.FieldsOf.
@@ -1078,6 +3679,42 @@ func checkField(f ast.Expr, st *types.Struct) (*types.Var, error) {
 	return nil, fmt.Errorf("%s is not a field of %s", b.Value, st.String())
 }
 
+// findCheck returns the wire.Check call if spec is a declaration of the
+// form var _ = wire.Check(...). It returns nil otherwise.
+func findCheck(info *types.Info, spec ast.Spec) *ast.CallExpr {
+	return findBlankCall(info, spec, "Check")
+}
+
+// findOverride returns the wire.Override call if spec is a declaration of
+// the form var _ = wire.Override(...). It returns nil otherwise.
+func findOverride(info *types.Info, spec ast.Spec) *ast.CallExpr {
+	return findBlankCall(info, spec, "Override")
+}
+
+// findTestKit returns the wire.TestKit call if spec is a declaration of
+// the form var _ = wire.TestKit(...). It returns nil otherwise.
+func findTestKit(info *types.Info, spec ast.Spec) *ast.CallExpr {
+	return findBlankCall(info, spec, "TestKit")
+}
+
+// findBlankCall returns the call of the wire function named name if spec
+// assigns the call to the blank identifier. It returns nil otherwise.
+func findBlankCall(info *types.Info, spec ast.Spec, name string) *ast.CallExpr {
+	vs, ok := spec.(*ast.ValueSpec)
+	if !ok || len(vs.Names) != 1 || vs.Names[0].Name != "_" || len(vs.Values) != 1 {
+		return nil
+	}
+	call, ok := vs.Values[0].(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	obj := qualifiedIdentObject(info, call.Fun)
+	if obj == nil || obj.Pkg() == nil || !isWireImport(obj.Pkg().Path()) || obj.Name() != name {
+		return nil
+	}
+	return call
+}
+
 // findInjectorBuild returns the wire.Build call if fn is an injector template.
 // It returns nil if the function is not an injector template.
 func findInjectorBuild(info *types.Info, fn *ast.FuncDecl) (*ast.CallExpr, error) {
@@ -1108,7 +3745,7 @@ func findInjectorBuild(info *types.Info, fn *ast.FuncDecl) (*ast.CallExpr, error
 				}
 			}
 			buildObj := qualifiedIdentObject(info, call.Fun)
-			if buildObj == nil || buildObj.Pkg() == nil || !isWireImport(buildObj.Pkg().Path()) || buildObj.Name() != "Build" {
+			if buildObj == nil || buildObj.Pkg() == nil || !isWireImport(buildObj.Pkg().Path()) || (buildObj.Name() != "Build" && buildObj.Name() != "BuildOnce" && buildObj.Name() != "Use") {
 				continue
 			}
 			wireBuildCall = call
@@ -1133,6 +3770,86 @@ func findInjectorBuild(info *types.Info, fn *ast.FuncDecl) (*ast.CallExpr, error
 	return wireBuildCall, nil
 }
 
+// buildInputs returns the signature of an injector with a parameter added
+// for each new(T) argument of buildCall whose type T is not already an input
+// of the injector. Such arguments declare T as a value given to the
+// injector, rather than one built by a provider.
+func buildInputs(info *types.Info, sig *types.Signature, buildCall *ast.CallExpr) (*types.Signature, error) {
+	ins := injectorInputs(sig)
+	var added []*types.Var
+	for _, arg := range buildCall.Args {
+		t := newCallType(info, arg)
+		if t == nil {
+			continue
+		}
+		found := false
+		for i := 0; i < ins.Len() && !found; i++ {
+			found = types.Identical(ins.At(i).Type(), t)
+		}
+		for _, v := range added {
+			found = found || types.Identical(v.Type(), t)
+		}
+		if found {
+			continue
+		}
+		if sig.Variadic() {
+			return nil, fmt.Errorf("cannot add input %s declared by new to a variadic injector", types.TypeString(t, nil))
+		}
+		added = append(added, types.NewParam(token.NoPos, nil, "", t))
+	}
+	if len(added) == 0 {
+		return sig, nil
+	}
+	params := make([]*types.Var, 0, sig.Params().Len()+len(added))
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+	params = append(params, added...)
+	return types.NewSignature(sig.Recv(), types.NewTuple(params...), sig.Results(), false), nil
+}
+
+// newCallType returns T if expr is a call to the built-in new(T), and nil
+// otherwise.
+func newCallType(info *types.Info, expr ast.Expr) types.Type {
+	call, ok := astutil.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	if qualifiedIdentObject(info, astutil.Unparen(call.Fun)) != types.Universe.Lookup("new") {
+		return nil
+	}
+	return info.TypeOf(call.Args[0])
+}
+
+// isBuildOnce reports whether the injector fn, whose call to wire.Build or
+// wire.BuildOnce is buildCall, builds its result once and shares it: it
+// either calls wire.BuildOnce or is marked //wire:lazy.
+func isBuildOnce(info *types.Info, fn *ast.FuncDecl, buildCall *ast.CallExpr) bool {
+	return qualifiedIdentObject(info, buildCall.Fun).Name() == "BuildOnce" || hasDirective(fn.Doc, "lazy")
+}
+
+// isUse reports whether buildCall, the call that marks an injector, is a
+// call to wire.Use.
+func isUse(info *types.Info, buildCall *ast.CallExpr) bool {
+	return qualifiedIdentObject(info, buildCall.Fun).Name() == "Use"
+}
+
+// verifyBuildOnce checks that an injector using wire.BuildOnce or marked
+// //wire:lazy can be called once and shared: it must take no inputs, must
+// not return a cleanup function and must not call wire.Use.
+func verifyBuildOnce(ins *types.Tuple, out outputSignature, use bool) error {
+	if use {
+		return errors.New("injectors using wire.Use may not be marked //wire:lazy")
+	}
+	if ins.Len() > 0 {
+		return errors.New("injectors using wire.BuildOnce or //wire:lazy may not have parameters or a receiver")
+	}
+	if out.cleanup {
+		return errors.New("injectors using wire.BuildOnce or //wire:lazy may not return a cleanup function")
+	}
+	return nil
+}
+
 func isWireImport(path string) bool {
 	// TODO(light): This is depending on details of the current loader.
 	const vendorPart = "vendor/"
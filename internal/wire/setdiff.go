@@ -0,0 +1,214 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// A ProviderSetDiff describes how the providers in a provider set changed
+// between two versions of the package that declares it.
+type ProviderSetDiff struct {
+	// Added holds the providers only in the new version of the set.
+	Added []ProviderSummary
+	// Removed holds the providers only in the old version of the set.
+	Removed []ProviderSummary
+	// Changed holds the providers in both versions whose signature differs.
+	Changed []ProviderChange
+}
+
+// A ProviderSummary identifies a provider in a ProviderSetDiff.
+type ProviderSummary struct {
+	// Name is the name of the provider function or struct type. It is
+	// qualified with its import path if the provider is declared outside
+	// the set's package.
+	Name string
+
+	// Signature describes the provider's inputs and outputs, such as
+	// "func(*Config) (*Server, func(), error)" for a function or
+	// "struct{DB *DB}" for the fields injected by wire.Struct. Providers
+	// wrapped in wire.Lazy are prefixed with "lazy ", and members of a
+	// wire.Group with "in " and the group's slice type. Types from
+	// the set's package are not qualified, so that signatures from both
+	// versions can be compared.
+	Signature string
+
+	Pos token.Position
+}
+
+// A ProviderChange is a provider whose signature differs between versions.
+type ProviderChange struct {
+	Old ProviderSummary
+	New ProviderSummary
+}
+
+// DiffProviderSets loads the packages oldPkgPath and newPkgPath, which hold
+// two versions of the same code, and compares the providers in the provider
+// set variable named setName in each, including providers in the sets it
+// includes. Providers are matched by name. The other arguments are
+// interpreted as they are by Load.
+func DiffProviderSets(ctx context.Context, wd string, env []string, tags string, oldPkgPath, newPkgPath, setName string) (*ProviderSetDiff, []error) {
+	pkgs, errs := load(ctx, wd, env, tags, []string{oldPkgPath, newPkgPath}, false)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	oc := newObjectCache(pkgs)
+	summarize := func(pkgPath string) (map[string]ProviderSummary, []error) {
+		pkg := oc.packages[pkgPath]
+		if pkg == nil {
+			return nil, []error{fmt.Errorf("package %s not loaded", pkgPath)}
+		}
+		obj := pkg.Types.Scope().Lookup(setName)
+		if obj == nil || !isProviderSetType(obj.Type()) {
+			return nil, []error{fmt.Errorf("%s.%s is not a provider set", pkgPath, setName)}
+		}
+		item, errs := oc.get(obj)
+		if len(errs) > 0 {
+			return nil, notePositionAll(oc.fset.Position(obj.Pos()), errs)
+		}
+		return summarizeProviders(oc.fset, pkgPath, item.(*ProviderSet)), nil
+	}
+	oldSet, errs := summarize(oldPkgPath)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	newSet, errs := summarize(newPkgPath)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	diff := new(ProviderSetDiff)
+	for _, name := range sortedKeys(oldSet) {
+		o := oldSet[name]
+		n, ok := newSet[name]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, o)
+		case o.Signature != n.Signature:
+			diff.Changed = append(diff.Changed, ProviderChange{Old: o, New: n})
+		}
+	}
+	for _, name := range sortedKeys(newSet) {
+		if _, ok := oldSet[name]; !ok {
+			diff.Added = append(diff.Added, newSet[name])
+		}
+	}
+	return diff, nil
+}
+
+// summarizeProviders returns the providers in set and the sets it includes,
+// keyed by name. pkgPath is the path of the package declaring set.
+func summarizeProviders(fset *token.FileSet, pkgPath string, set *ProviderSet) map[string]ProviderSummary {
+	q := func(pkg *types.Package) string {
+		if pkg.Path() == pkgPath {
+			return ""
+		}
+		return pkg.Path()
+	}
+	summaries := make(map[string]ProviderSummary)
+	stk := []*ProviderSet{set}
+	for len(stk) > 0 {
+		s := stk[len(stk)-1]
+		stk = stk[:len(stk)-1]
+		for _, p := range s.Providers {
+			name := p.Name
+			switch {
+			case p.TypeAssert || p.Implement != nil:
+				// A set may hold several wire.As or wire.Implement calls,
+				// one per type.
+				name = "wire." + p.Name + "(" + types.TypeString(p.Out[0], q) + ")"
+			case p.Pkg.Path() != pkgPath:
+				name = p.Pkg.Path() + "." + name
+			}
+			summaries[name] = ProviderSummary{
+				Name:      name,
+				Signature: providerSignature(p, q),
+				Pos:       fset.Position(p.Pos),
+			}
+		}
+		stk = append(stk, s.Imports...)
+	}
+	return summaries
+}
+
+// providerSignature describes the inputs and outputs of p.
+func providerSignature(p *Provider, q types.Qualifier) string {
+	var sb strings.Builder
+	if p.IsStruct {
+		sb.WriteString("struct{")
+		for i, arg := range p.Args {
+			if i > 0 {
+				sb.WriteString("; ")
+			}
+			sb.WriteString(arg.FieldName + " " + types.TypeString(arg.Type, q))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	}
+	if p.Lazy {
+		sb.WriteString("lazy ")
+	}
+	if p.Group != nil {
+		sb.WriteString("in " + types.TypeString(p.Group, q) + " ")
+	}
+	sb.WriteString("func(")
+	for i, arg := range p.Args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if p.Varargs && i == len(p.Args)-1 {
+			sb.WriteString("..." + types.TypeString(arg.Type.(*types.Slice).Elem(), q))
+			continue
+		}
+		sb.WriteString(types.TypeString(arg.Type, q))
+	}
+	sb.WriteString(")")
+	if p.NoValue {
+		return sb.String()
+	}
+	out := p.Out[0]
+	if p.Group != nil {
+		out = p.GroupOut
+	}
+	if p.Lazy {
+		// Describe the provider function rather than its accessor.
+		out = out.(*types.Signature).Results().At(0).Type()
+	}
+	results := []string{types.TypeString(out, q)}
+	if p.HasCleanup {
+		results = append(results, "func()")
+	}
+	if p.HasErr {
+		results = append(results, "error")
+	}
+	if len(results) == 1 {
+		return sb.String() + " " + results[0]
+	}
+	return sb.String() + " (" + strings.Join(results, ", ") + ")"
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]ProviderSummary) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	tracer := new(Tracer)
+	app := initApp(tracer)
+	app.Run()
+	fmt.Println(strings.Join(tracer.events, "\n"))
+}
+
+// Tracer records events. Every injector passes its tracer to the providers
+// that need one.
+type Tracer struct {
+	events []string
+}
+
+func (t *Tracer) Event(msg string) {
+	t.events = append(t.events, msg)
+}
+
+type Config struct {
+	Name string
+}
+
+type DB struct {
+	tracer *Tracer
+}
+
+type Cache struct {
+	db     *DB
+	tracer *Tracer
+}
+
+type App struct {
+	cfg    Config
+	cache  *Cache
+	tracer *Tracer
+}
+
+func (a *App) Run() {
+	a.tracer.Event("running " + a.cfg.Name)
+}
+
+func NewConfig() Config {
+	return Config{Name: "app"}
+}
+
+func NewDB(tracer *Tracer) *DB {
+	tracer.Event("opened db")
+	return &DB{tracer: tracer}
+}
+
+func NewCache(db *DB, tracer *Tracer) *Cache {
+	tracer.Event("created cache")
+	return &Cache{db: db, tracer: tracer}
+}
+
+func NewApp(cfg Config, cache *Cache, tracer *Tracer) *App {
+	tracer.Event("created app")
+	return &App{cfg: cfg, cache: cache, tracer: tracer}
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectFooBar())
+}
+
+type Foo int
+type FooBar int
+
+// Cost is metadata for tools that analyze provider sets.
+type Cost struct {
+	Latency time.Duration
+}
+
+const expensive = "expensive"
+
+var Set = wire.NewSet(
+	wire.Annotate(provideFoo, expensive, 2*time.Second),
+	wire.Annotate(wire.Annotate(provideFooBar, Cost{Latency: time.Millisecond}), "test-only"))
+
+func provideFoo() Foo {
+	return 41
+}
+
+func provideFooBar(foo Foo) FooBar {
+	return FooBar(foo) + 1
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func main() {
+	doc, err := injectDoc([]byte(`{"title": "wire"}`))
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(doc.Title)
+}
+
+// Transform is a named function type. A provider of the unnamed type
+// func(string) string is assignable to it.
+type Transform func(string) string
+
+func provideUpper() func(string) string {
+	return strings.ToUpper
+}
+
+type Doc struct {
+	Title string
+}
+
+// NewDoc takes a json.RawMessage, which the injector's []byte parameter is
+// assignable to.
+func NewDoc(raw json.RawMessage, transform Transform) (*Doc, error) {
+	doc := new(Doc)
+	if err := json.Unmarshal(raw, doc); err != nil {
+		return nil, err
+	}
+	doc.Title = transform(doc.Title)
+	return doc, nil
+}
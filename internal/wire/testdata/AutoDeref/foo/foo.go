@@ -0,0 +1,51 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	client, err := injectClient()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(client.conn.Addr)
+}
+
+type Config struct {
+	Addr string
+}
+
+func NewConfig() Config {
+	return Config{Addr: "localhost:8080"}
+}
+
+type Conn struct {
+	Addr string
+}
+
+// Dial returns a pointer, while NewClient takes a value.
+func Dial(cfg *Config) (*Conn, error) {
+	return &Conn{Addr: cfg.Addr}, nil
+}
+
+type Client struct {
+	conn Conn
+}
+
+func NewClient(conn Conn) Client {
+	return Client{conn: conn}
+}
@@ -9,6 +9,5 @@ package main
 // Injectors from wire.go:
 
 func inject(foo *Foo) *Bar {
-	bar := NewBar(foo)
-	return bar
+	return NewBar(foo)
 }
@@ -0,0 +1,63 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+type Fooer interface {
+	Foo() string
+}
+
+type Foo string
+
+func (f *Foo) Foo() string {
+	return string(*f)
+}
+
+type Bar struct {
+	Fooer Fooer
+}
+
+type Baz struct {
+	Fooer Fooer
+}
+
+type App struct {
+	Bar *Bar
+	Baz *Baz
+	Foo *Foo
+}
+
+type Name string
+
+func NewBar(f Fooer) *Bar {
+	return &Bar{Fooer: f}
+}
+
+func NewBaz(f Fooer) *Baz {
+	return &Baz{Fooer: f}
+}
+
+func NewApp(bar *Bar, baz *Baz, foo *Foo) *App {
+	return &App{Bar: bar, Baz: baz, Foo: foo}
+}
+
+func main() {
+	foo := Foo("hello")
+	fmt.Println(injectFooer(&foo).Foo())
+	app := injectApp(&foo)
+	fmt.Println(app.Bar.Fooer.Foo(), app.Baz.Fooer.Foo(), app.Bar.Fooer == app.Baz.Fooer, app.Foo == &foo)
+	fmt.Println(injectName("gopher"))
+}
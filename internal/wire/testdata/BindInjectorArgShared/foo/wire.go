@@ -0,0 +1,40 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+)
+
+// injectFooer returns its parameter through the binding.
+func injectFooer(foo *Foo) Fooer {
+	wire.Build(wire.Bind(new(Fooer), new(*Foo)))
+	return nil
+}
+
+// injectApp passes its parameter both as a Fooer, to several providers,
+// and as itself.
+func injectApp(foo *Foo) *App {
+	wire.Build(NewBar, NewBaz, NewApp, wire.Bind(new(Fooer), new(*Foo)))
+	return nil
+}
+
+// injectName converts its parameter to the bound named type.
+func injectName(s string) Name {
+	wire.Build(wire.Bind(new(Name), new(string)))
+	return ""
+}
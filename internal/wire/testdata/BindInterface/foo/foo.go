@@ -0,0 +1,76 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	c, err := injectCache(&Config{Backend: "redis"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(c.Get("greeting"))
+	c, err = injectCache(&Config{Backend: "memcache"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(c.Get("greeting"))
+	_, err = injectLegacyCache()
+	fmt.Println(err)
+}
+
+type Config struct {
+	Backend string
+}
+
+type Cache interface {
+	Get(key string) string
+}
+
+type RedisCache struct{}
+
+func (*RedisCache) Get(key string) string {
+	return "redis: " + key
+}
+
+type Memcache struct{}
+
+func (Memcache) Get(key string) string {
+	return "memcache: " + key
+}
+
+func selectCache(cfg *Config) (interface{}, error) {
+	switch cfg.Backend {
+	case "redis":
+		return &RedisCache{}, nil
+	case "memcache":
+		return Memcache{}, nil
+	}
+	return nil, errors.New("unknown cache backend " + cfg.Backend)
+}
+
+func selectLegacyCache() interface{} {
+	var v interface{} = 42
+	return v
+}
+
+var Set = wire.NewSet(wire.BindInterface(new(Cache), selectCache))
@@ -0,0 +1,54 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/google/wire"
+)
+
+func main() {}
+
+type Config struct {
+	UseRedis bool
+}
+
+type Cache interface {
+	Get(key string) string
+}
+
+type RedisCache struct{}
+
+func (*RedisCache) Get(key string) string {
+	return key
+}
+
+func selectCache(cfg *Config) interface{} {
+	if cfg.UseRedis {
+		return RedisCache{}
+	}
+	return &RedisCache{}
+}
+
+func selectAnyCache(cfg *Config) interface{} {
+	return &RedisCache{}
+}
+
+func selectConcreteCache(cfg *Config) *RedisCache {
+	return &RedisCache{}
+}
+
+var Set = wire.NewSet(wire.BindInterface(new(Cache), selectCache))
+
+var ConcreteSet = wire.NewSet(wire.BindInterface(new(Cache), selectConcreteCache))
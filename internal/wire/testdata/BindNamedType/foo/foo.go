@@ -0,0 +1,45 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	s := injectServer()
+	fmt.Println(s.Host, s.Port, s.Addr)
+	fmt.Println(injectPort())
+}
+
+type Host string
+type Port int
+type Addr string
+
+type Server struct {
+	Host Host
+	Port Port
+	Addr Addr
+}
+
+func provideHost() string {
+	return "localhost"
+}
+
+func providePort() int {
+	return 8080
+}
+
+func NewAddr(h Host, p Port) Addr {
+	return Addr(fmt.Sprintf("%s:%d", h, p))
+}
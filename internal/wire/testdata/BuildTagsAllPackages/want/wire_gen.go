@@ -9,10 +9,6 @@ package main
 // Injectors from wire.go:
 
 func injectedMessage() string {
-	string2 := _wireStringValue
+	string2 := "Hello, World!"
 	return string2
 }
-
-var (
-	_wireStringValue = "Hello, World!"
-)
@@ -0,0 +1,45 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	c, cleanup := injectCloser()
+	fmt.Println(c != nil)
+	cleanup()
+}
+
+type Resource struct{}
+
+type Closer struct {
+	close func()
+}
+
+func provideResource() (*Resource, func()) {
+	return &Resource{}, func() {}
+}
+
+// NewCloser needs a func(), which the cleanup function returned by
+// provideResource must not satisfy.
+func NewCloser(r *Resource, close func()) *Closer {
+	return &Closer{close: close}
+}
+
+var Set = wire.NewSet(provideResource, NewCloser)
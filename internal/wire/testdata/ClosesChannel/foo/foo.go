@@ -0,0 +1,49 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	p, cleanup := injectPublisher()
+	p.Publish("started")
+	p.Publish("stopped")
+	cleanup()
+	// The cleanup function closes the channel, so the loop ends.
+	for e := range p.Events {
+		fmt.Println(e)
+	}
+}
+
+type Publisher struct {
+	Events chan string
+}
+
+func (p *Publisher) Publish(e string) {
+	p.Events <- e
+}
+
+// provideEvents makes the channel that events are published on.
+//
+//wire:closes
+func provideEvents() chan string {
+	return make(chan string, 2)
+}
+
+func providePublisher(events chan string) *Publisher {
+	return &Publisher{Events: events}
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	var closeErrs []error
+	wire.SetCloseErrorHandler(func(name string, err error) {
+		closeErrs = append(closeErrs, fmt.Errorf("%s: %w", name, err))
+	})
+	app, cleanup := injectApp()
+	fmt.Println(app.DB.Name, app.Conn.Name)
+	cleanup()
+	fmt.Println(closeErrs)
+}
+
+type DB struct {
+	Name string
+}
+
+func (db *DB) Close() error {
+	fmt.Println("closing", db.Name)
+	return nil
+}
+
+type Conn struct {
+	Name string
+}
+
+func (c *Conn) Close() error {
+	fmt.Println("closing", c.Name)
+	return errors.New("connection reset")
+}
+
+type App struct {
+	DB   *DB
+	Conn *Conn
+}
+
+// provideDB opens the database.
+//
+//wire:closes
+func provideDB() *DB {
+	return &DB{Name: "db"}
+}
+
+//wire:closes
+func provideConn(db *DB) *Conn {
+	return &Conn{Name: "conn"}
+}
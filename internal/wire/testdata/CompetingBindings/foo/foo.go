@@ -0,0 +1,49 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/google/wire"
+)
+
+func main() {}
+
+type Parser struct {
+	r io.Reader
+}
+
+func provideStringsReader() *strings.Reader {
+	return strings.NewReader("hello")
+}
+
+func provideBuffer() *bytes.Buffer {
+	return bytes.NewBufferString("hello")
+}
+
+func provideParser(r io.Reader) *Parser {
+	return &Parser{r: r}
+}
+
+var StringsSet = wire.NewSet(
+	provideStringsReader,
+	wire.Bind(new(io.Reader), new(*strings.Reader)))
+
+var BufferSet = wire.NewSet(
+	provideBuffer,
+	wire.Bind(new(io.Reader), new(*bytes.Buffer)))
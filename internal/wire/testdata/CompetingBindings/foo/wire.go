@@ -0,0 +1,40 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build wireinject
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/google/wire"
+)
+
+func injectParser() *Parser {
+	// fail: both *strings.Reader and *bytes.Buffer are bound to io.Reader.
+	panic(wire.Build(
+		provideParser,
+		provideStringsReader,
+		provideBuffer,
+		wire.Bind(new(io.Reader), new(*strings.Reader)),
+		wire.Bind(new(io.Reader), new(*bytes.Buffer))))
+}
+
+func injectParserFromSets() *Parser {
+	// fail: StringsSet and BufferSet both bind io.Reader.
+	panic(wire.Build(provideParser, StringsSet, BufferSet))
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"example.com/bar"
+)
+
+func main() {
+	a := injectApp()
+	fmt.Println(a.Version, a.Port, a.Workers, a.Ratio, a.Debug, a.Commit)
+}
+
+const (
+	version = "1.2.3"
+	debug   = false
+)
+
+type Port int
+
+type App struct {
+	Version string
+	Port    Port
+	Workers int
+	Ratio   float32
+	Debug   bool
+	Commit  bar.Commit
+}
+
+func NewApp(version string, port Port, workers int, ratio float32, debug bool, commit bar.Commit) App {
+	return App{
+		Version: version,
+		Port:    port,
+		Workers: workers,
+		Ratio:   ratio,
+		Debug:   debug,
+		Commit:  commit,
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+type Config struct {
+	Env string
+}
+
+func NewConfig(env string) *Config {
+	return &Config{Env: env}
+}
+
+type DB struct {
+	Name string
+}
+
+func NewDB(cfg *Config) (*DB, func(), error) {
+	db := &DB{Name: "db:" + cfg.Env}
+	return db, func() { fmt.Println("closing", db.Name) }, nil
+}
+
+type App struct {
+	db *DB
+}
+
+func NewApp(db *DB) *App {
+	return &App{db: db}
+}
+
+func (app *App) Greet() string {
+	return "hello from " + app.db.Name
+}
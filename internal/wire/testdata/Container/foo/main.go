@@ -0,0 +1,40 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !wireinject
+
+package main
+
+import (
+	"fmt"
+)
+
+// main uses the generated container, so it is excluded from the wireinject
+// build, where the container is not declared.
+func main() {
+	c, cleanup, err := NewAppContainer("prod")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(c.Config().Env, c.DB().Name, c.App().Greet())
+	cleanup()
+	app, cleanup, err := injectApp("test")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(app.Greet())
+	cleanup()
+}
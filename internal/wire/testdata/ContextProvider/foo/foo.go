@@ -0,0 +1,55 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectService().String())
+	fmt.Println(injectCanceledService().String())
+}
+
+type Store struct {
+	ready bool
+}
+
+type Service struct {
+	store *Store
+}
+
+func (s *Service) String() string {
+	return fmt.Sprintf("store ready: %t", s.store.ready)
+}
+
+func NewStore(ctx context.Context) *Store {
+	return &Store{ready: ctx.Err() == nil}
+}
+
+func NewService(store *Store) *Service {
+	return &Service{store}
+}
+
+func CanceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+var ServiceSet = wire.NewSet(NewStore, NewService)
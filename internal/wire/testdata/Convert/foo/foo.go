@@ -0,0 +1,51 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"example.com/bar"
+)
+
+func main() {
+	fmt.Println(injectLogger().Name)
+	n, err := injectLevel()
+	fmt.Println(n, err)
+}
+
+type Logger struct {
+	Name string
+}
+
+func toLogger(l *bar.Logger) *Logger {
+	return &Logger{Name: l.Prefix + "-adapted"}
+}
+
+type Verbosity string
+
+type Level int
+
+func provideVerbosity() Verbosity {
+	return "high"
+}
+
+func parseLevel(v Verbosity) (Level, error) {
+	if v != "high" {
+		return 0, errors.New("unknown verbosity")
+	}
+	return 3, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(injectCelsius())
+	fmt.Println(injectKelvin())
+}
+
+type Fahrenheit float64
+
+type Celsius float64
+
+type Kelvin float64
+
+func provideFahrenheit() Fahrenheit {
+	return 212
+}
+
+func toCelsius(f Fahrenheit, offset float64) Celsius {
+	return Celsius((float64(f) - offset) * 5 / 9)
+}
+
+func toKelvinFromCelsius(c Celsius) Kelvin {
+	return Kelvin(c + 273.15)
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	baz, cleanup, err := injectBaz(false)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("baz", baz)
+	cleanup()
+	fmt.Println("calls:", calls)
+	if _, _, err := injectBaz(true); err != nil {
+		fmt.Println(err)
+	}
+}
+
+type Foo int
+type Bar int
+type Baz int
+
+var calls int
+
+// Record counts the calls of each provider.
+func Record(name string, fn func() (int, error)) func() (int, error) {
+	return func() (int, error) {
+		fmt.Println("calling", name)
+		calls++
+		return fn()
+	}
+}
+
+var Set = wire.NewSet(provideFoo, provideBar, provideBaz)
+
+var RecordedSet = wire.Copy(Set, Record)
+
+func provideFoo() Foo {
+	return 40
+}
+
+func provideBar(foo Foo) (Bar, func()) {
+	return Bar(foo) + 1, func() { fmt.Println("cleaning up bar") }
+}
+
+func provideBaz(bar Bar, fail bool) (Baz, error) {
+	if fail {
+		return 0, errors.New("baz failed")
+	}
+	return Baz(bar) + 1, nil
+}
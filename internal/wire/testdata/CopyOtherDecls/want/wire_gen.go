@@ -13,8 +13,7 @@ import (
 // Injectors from foo.go:
 
 func injectedMessage() string {
-	string2 := provideMessage()
-	return string2
+	return provideMessage()
 }
 
 // foo.go:
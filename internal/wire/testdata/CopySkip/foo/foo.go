@@ -0,0 +1,72 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	baz, cleanup, err := injectBaz()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("baz", baz)
+	cleanup()
+	refuse = true
+	if _, _, err := injectBaz(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+type Foo int
+type Bar int
+type Baz int
+
+var refuse bool
+
+// Gate skips provideBar, and refuses to call provideBaz if refuse is set.
+func Gate[T any](name string, fn func() (T, error)) func() (T, error) {
+	return func() (T, error) {
+		var zero T
+		switch {
+		case name == "main.provideBar":
+			return zero, nil
+		case name == "main.provideBaz" && refuse:
+			return zero, errors.New("refused " + name)
+		}
+		return fn()
+	}
+}
+
+var Set = wire.NewSet(provideFoo, provideBar, provideBaz)
+
+var GatedSet = wire.Copy(Set, Gate[any])
+
+func provideFoo() Foo {
+	return 40
+}
+
+func provideBar(foo Foo) (Bar, func()) {
+	return Bar(foo) + 1, func() { fmt.Println("cleaning up bar") }
+}
+
+func provideBaz(bar Bar) Baz {
+	return Baz(bar) + 1
+}
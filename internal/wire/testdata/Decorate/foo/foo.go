@@ -0,0 +1,74 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectServer().Describe())
+	fmt.Println(injectTestServer().Describe())
+}
+
+type Clock interface {
+	Now() string
+}
+
+type realClock struct{}
+
+func (realClock) Now() string { return "real time" }
+
+type fakeClock struct{}
+
+func (fakeClock) Now() string { return "fake time" }
+
+type Config struct {
+	Name string
+}
+
+type Server struct {
+	Clock  Clock
+	Config Config
+}
+
+func (s *Server) Describe() string {
+	return s.Config.Name + " at " + s.Clock.Now()
+}
+
+func provideRealClock() realClock {
+	return realClock{}
+}
+
+func provideFakeClock() fakeClock {
+	return fakeClock{}
+}
+
+func provideConfig() Config {
+	return Config{Name: "prod"}
+}
+
+var ProdSet = wire.NewSet(
+	provideRealClock,
+	wire.Bind(new(Clock), new(realClock)),
+	provideConfig,
+	wire.Struct(new(Server), "*"))
+
+var TestSet = wire.Decorate(ProdSet,
+	provideFakeClock,
+	wire.Bind(new(Clock), new(fakeClock)),
+	wire.Value(Config{Name: "test"}))
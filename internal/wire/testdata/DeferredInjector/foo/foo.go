@@ -0,0 +1,85 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	newExpensive := injectExpensive(&Config{Addr: "db.example.com"})
+	newCheap := injectCheap()
+	fmt.Println("injectors returned")
+	e, cleanup, err := newExpensive()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(e)
+	cleanup()
+	if _, cleanup, err := newExpensive(); err == nil {
+		cleanup()
+	}
+	fmt.Printf("built %d expensive values\n", built)
+	fmt.Println(newCheap())
+	fmt.Println(newCheap())
+}
+
+type Config struct {
+	Addr string
+}
+
+type Conn struct {
+	addr string
+}
+
+type Expensive struct {
+	conn *Conn
+}
+
+func (e *Expensive) String() string {
+	return "expensive with conn to " + e.conn.addr
+}
+
+type Cheap int
+
+var built, cheap int
+
+func NewConn(cfg *Config) (*Conn, func(), error) {
+	if cfg.Addr == "" {
+		return nil, nil, fmt.Errorf("no address")
+	}
+	conn := &Conn{addr: cfg.Addr}
+	return conn, func() {
+		if built == 1 {
+			fmt.Println("closing conn")
+		}
+	}, nil
+}
+
+func NewExpensive(conn *Conn) *Expensive {
+	fmt.Println("building expensive")
+	built++
+	return &Expensive{conn}
+}
+
+func NewCheap() Cheap {
+	cheap++
+	return Cheap(cheap)
+}
+
+func (c Cheap) String() string {
+	return fmt.Sprintf("cheap %d", int(c))
+}
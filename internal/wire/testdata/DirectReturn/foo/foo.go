@@ -0,0 +1,43 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(injectGreeting("world"))
+	fmt.Println(injectGreetingOrError("gopher"))
+	fmt.Println(injectParsedGreeting(""))
+}
+
+type Greeting string
+
+func provideGreeting(name string) Greeting {
+	return Greeting("hello, " + name)
+}
+
+// ParsedGreeting is returned directly when it can't fail, but checked when
+// it can, so that the injector returns the zero value with the error.
+type ParsedGreeting string
+
+func parseGreeting(name string) (ParsedGreeting, error) {
+	if name == "" {
+		return "partial", errors.New("no name")
+	}
+	return ParsedGreeting("hello, " + name), nil
+}
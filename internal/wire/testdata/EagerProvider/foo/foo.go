@@ -0,0 +1,70 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	app, cleanup, err := initApp()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(app.Registry.names)
+	cleanup()
+}
+
+type Registry struct {
+	names []string
+}
+
+type Logger struct{}
+
+type Driver string
+
+type Metrics struct{}
+
+type App struct {
+	Registry *Registry
+	Logger   Logger
+}
+
+func provideRegistry() *Registry {
+	return new(Registry)
+}
+
+//wire:eager
+func provideLogger(r *Registry) Logger {
+	r.names = append(r.names, "logger")
+	return Logger{}
+}
+
+//wire:eager
+func registerDriver(r *Registry) Driver {
+	r.names = append(r.names, "driver")
+	return "sql"
+}
+
+//wire:eager
+func registerMetrics(r *Registry) (Metrics, func(), error) {
+	r.names = append(r.names, "metrics")
+	return Metrics{}, func() { fmt.Println("metrics stopped") }, nil
+}
+
+func provideApp(r *Registry, l Logger) App {
+	return App{Registry: r, Logger: l}
+}
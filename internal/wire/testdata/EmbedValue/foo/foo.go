@@ -0,0 +1,41 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed hello.txt
+var content embed.FS
+
+func main() {
+	data, err := fs.ReadFile(injectFS(), "hello.txt")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(string(data))
+	entries, err := injectEmbedFS().ReadDir(".")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	for _, e := range entries {
+		fmt.Println(e.Name())
+	}
+}
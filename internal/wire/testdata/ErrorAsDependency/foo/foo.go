@@ -0,0 +1,70 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	r, err := injectReport()
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+	fmt.Println(r.Message)
+
+	_, err = injectFailingReport()
+	fmt.Println("ERROR:", err)
+}
+
+// ValidationError is a named error type. It is provided like any other
+// type, not treated as a provider's error result.
+type ValidationError struct {
+	Field string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid " + e.Field
+}
+
+// Reason is a named type whose underlying type is error.
+type Reason error
+
+type Report struct {
+	Message string
+}
+
+func provideLastError() error {
+	return errors.New("disk full")
+}
+
+func provideValidationError() *ValidationError {
+	return &ValidationError{Field: "name"}
+}
+
+func provideReason() Reason {
+	return errors.New("quota")
+}
+
+// provideReport takes errors as ordinary inputs and may still fail.
+func provideReport(last error, verr *ValidationError, reason Reason) (*Report, error) {
+	return &Report{Message: fmt.Sprintf("%v; %v; %v", last, verr, reason)}, nil
+}
+
+func provideFailingReport(last error) (*Report, error) {
+	return nil, fmt.Errorf("report: %w", last)
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !wireinject
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	str "strings"
+)
+
+func main() {
+	app := injectApp()
+	if _, err := fmt.Fprintln(app.Config.Out, app.Config); err != nil {
+		os.Exit(1)
+	}
+}
+
+// Config is declared in a file excluded by the wireinject tag, but the
+// providers that use it are not.
+type Config struct {
+	Name string
+	Out  io.Writer
+}
+
+func (c Config) String() string {
+	return fmt.Sprintf("config %s", str.ToUpper(c.Name))
+}
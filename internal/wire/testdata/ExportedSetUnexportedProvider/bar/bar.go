@@ -0,0 +1,34 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bar
+
+import "github.com/google/wire"
+
+type Bar int
+
+type Baz int
+
+func NewBar(b Baz) Bar {
+	return Bar(b) + 1
+}
+
+func newBaz() Baz {
+	return 41
+}
+
+var bazSet = wire.NewSet(newBaz)
+
+// Set includes newBaz through bazSet, so other packages cannot use it.
+var Set = wire.NewSet(NewBar, bazSet)
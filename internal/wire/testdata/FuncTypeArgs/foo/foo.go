@@ -0,0 +1,54 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	h := injectHandler()
+	fmt.Println(h.Serve("wire"))
+}
+
+// HandlerFunc is a named function type. It is distinct from the unnamed
+// func(string) string for the purposes of dependency resolution.
+type HandlerFunc func(string) string
+
+type Handler struct {
+	next   HandlerFunc
+	format func(string) string
+}
+
+func (h *Handler) Serve(s string) string {
+	return h.format(h.next(s))
+}
+
+func provideHandlerFunc() HandlerFunc {
+	return strings.ToUpper
+}
+
+func provideFormat() func(string) string {
+	return func(s string) string { return "<" + s + ">" }
+}
+
+func NewHandler(next HandlerFunc, format func(string) string) *Handler {
+	return &Handler{next: next, format: format}
+}
+
+var Set = wire.NewSet(provideHandlerFunc, provideFormat, NewHandler)
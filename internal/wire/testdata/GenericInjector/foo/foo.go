@@ -0,0 +1,83 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	svc, err := initService(MemStore{})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(svc.Lookup("greeting"))
+	upper, err := initService(UpperStore{})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(upper.Lookup("greeting"))
+}
+
+type Store interface {
+	Get(key string) string
+}
+
+type MemStore struct{}
+
+func (MemStore) Get(key string) string {
+	return key + " from memory"
+}
+
+type UpperStore struct{}
+
+func (UpperStore) Get(key string) string {
+	return strings.ToUpper(key)
+}
+
+// Cache remembers the values read from a store.
+type Cache[T Store] struct {
+	store  T
+	values map[string]string
+}
+
+func NewCache[T Store](store T) *Cache[T] {
+	return &Cache[T]{store: store, values: make(map[string]string)}
+}
+
+type Service[T Store] struct {
+	cache *Cache[T]
+	name  string
+}
+
+func NewService[T Store](cache *Cache[T], name string) (*Service[T], error) {
+	return &Service[T]{cache: cache, name: name}, nil
+}
+
+func (s *Service[T]) Lookup(key string) string {
+	v, ok := s.cache.values[key]
+	if !ok {
+		v = s.cache.store.Get(key)
+		s.cache.values[key] = v
+	}
+	return s.name + ": " + v
+}
+
+func provideName() string {
+	return "service"
+}
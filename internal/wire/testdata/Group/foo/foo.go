@@ -0,0 +1,67 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"example.com/bar"
+)
+
+func main() {
+	mux, err := injectMux()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(mux)
+}
+
+type Prefix string
+
+type usersRoute struct {
+	prefix Prefix
+}
+
+func (r *usersRoute) Path() string { return string(r.prefix) + "/users" }
+
+type ordersRoute struct {
+	prefix Prefix
+}
+
+func (r ordersRoute) Path() string { return string(r.prefix) + "/orders" }
+
+func providePrefix() Prefix {
+	return "/api"
+}
+
+func newUsersRoute(p Prefix) *usersRoute {
+	return &usersRoute{prefix: p}
+}
+
+func newOrdersRoute(p Prefix) (ordersRoute, error) {
+	return ordersRoute{prefix: p}, nil
+}
+
+type Mux string
+
+func newMux(routes []bar.Route) Mux {
+	var paths []string
+	for _, r := range routes {
+		paths = append(paths, r.Path())
+	}
+	return Mux(strings.Join(paths, " "))
+}
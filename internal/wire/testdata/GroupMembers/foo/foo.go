@@ -0,0 +1,93 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	app := injectApp()
+	fmt.Println(app.mux)
+	fmt.Println(app.admin)
+	fmt.Println(app.health.Path())
+	fmt.Println("users routes built:", usersBuilt)
+}
+
+type Route interface {
+	Path() string
+}
+
+type usersRoute struct{}
+
+func (*usersRoute) Path() string { return "/users" }
+
+var usersBuilt int
+
+func newUsersRoute() *usersRoute {
+	usersBuilt++
+	return &usersRoute{}
+}
+
+type ordersRoute struct{}
+
+func (ordersRoute) Path() string { return "/orders" }
+
+func newOrdersRoute() ordersRoute {
+	return ordersRoute{}
+}
+
+// HealthRoute is provided both on its own and as a group member.
+type HealthRoute struct {
+	path string
+}
+
+func (r HealthRoute) Path() string { return r.path }
+
+func newHealthRoute() HealthRoute {
+	return HealthRoute{path: "/healthz"}
+}
+
+func newDebugHealthRoute() HealthRoute {
+	return HealthRoute{path: "/debug/healthz"}
+}
+
+type Mux string
+
+func newMux(routes []Route) Mux {
+	var paths []string
+	for _, r := range routes {
+		paths = append(paths, r.Path())
+	}
+	return Mux(strings.Join(paths, " "))
+}
+
+// Admin serves a single route from the group.
+type Admin string
+
+func newAdmin(users *usersRoute, orders Route) Admin {
+	return Admin(users.Path() + " " + orders.Path())
+}
+
+type App struct {
+	mux    Mux
+	admin  Admin
+	health HealthRoute
+}
+
+func newApp(mux Mux, admin Admin, health HealthRoute) App {
+	return App{mux: mux, admin: admin, health: health}
+}
@@ -11,6 +11,5 @@ package main
 // Injectors from wire.go:
 
 func injectFoo() Foo {
-	foo := provideFoo()
-	return foo
+	return provideFoo()
 }
@@ -0,0 +1,61 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectFooer().Do())
+	fmt.Println(injectFooerTwice().Do())
+	fmt.Println(injectGreeter().Greet())
+}
+
+type Fooer interface {
+	Do() string
+}
+
+type Foo struct{}
+
+func (f *Foo) Do() string {
+	return "did foo"
+}
+
+var fooCount int
+
+func NewFoo() *Foo {
+	fooCount++
+	return &Foo{}
+}
+
+type Greeter struct {
+	fooer Fooer
+}
+
+func NewGreeter(fooer Fooer, foo *Foo) *Greeter {
+	return &Greeter{fooer: fooer}
+}
+
+func (g *Greeter) Greet() string {
+	return fmt.Sprintf("%s (%d)", g.fooer.Do(), fooCount)
+}
+
+var FooSet = wire.NewSet(NewFoo, wire.Bind(new(Fooer), new(*Foo)))
+
+// GreeterSet binds Fooer to *Foo just like FooSet, which it imports.
+var GreeterSet = wire.NewSet(FooSet, NewGreeter, wire.Bind(new(Fooer), new(*Foo)))
@@ -0,0 +1,69 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	s := injectService()
+	s.Put("greeting", "hello")
+	fmt.Println(s.Get("greeting"))
+	s.Log("stored", "greeting")
+}
+
+type Service interface {
+	Get(key string) string
+	Put(key, value string)
+	Log(msgs ...string)
+}
+
+type Store struct {
+	m map[string]string
+}
+
+func NewStore() *Store {
+	return &Store{m: make(map[string]string)}
+}
+
+func (s *Store) Get(key string) string {
+	return s.m[key]
+}
+
+func (s *Store) Put(key, value string) {
+	s.m[key] = value
+}
+
+type Logger struct {
+	prefix string
+}
+
+func NewLogger() Logger {
+	return Logger{prefix: "log:"}
+}
+
+func (l Logger) Log(msgs ...string) {
+	fmt.Println(l.prefix, strings.Join(msgs, " "))
+}
+
+var Set = wire.NewSet(
+	NewStore,
+	NewLogger,
+	wire.Implement(new(Service), new(*Store), new(Logger)),
+)
@@ -0,0 +1,51 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectService())
+}
+
+type Service interface {
+	Get(key string) string
+	Put(key, value string)
+	Close() error
+}
+
+type Store struct{}
+
+func (*Store) Get(key string) string { return "" }
+func (*Store) Put(key, value string) {}
+
+type Cache struct{}
+
+func (Cache) Get(key string) string { return "" }
+
+// Close does not match Service.Close.
+func (Cache) Close() {}
+
+type Metrics struct{}
+
+func (Metrics) Count(name string) {}
+
+var Set = wire.NewSet(
+	wire.Implement(new(Service), new(*Store), new(Cache), new(Metrics)),
+)
@@ -0,0 +1,70 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	app := wire.Inject(injectApp).(*App)
+	fmt.Println(app.Name)
+	try(func() { wire.Inject(injectLogger) })
+	try(func() { wire.Inject(injectConfig) })
+	try(func() { wire.Inject(injectFailing) })
+}
+
+func try(f func()) {
+	defer func() {
+		fmt.Println(recover())
+	}()
+	f()
+}
+
+type App struct {
+	Name string
+}
+
+func NewApp() *App {
+	return &App{Name: "app"}
+}
+
+type Logger interface {
+	Log(string)
+}
+
+type fileLogger struct{}
+
+func (*fileLogger) Log(string) {}
+
+// newFileLogger returns a typed nil, which the compiler accepts.
+func newFileLogger() *fileLogger {
+	return nil
+}
+
+type Config struct {
+	Port int
+}
+
+func NewConfig() Config {
+	return Config{}
+}
+
+func NewFailing() (*App, error) {
+	return nil, errors.New("boom")
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	b := &Builder{Name: "pointer"}
+	app, err := b.Init()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(app.Greeting)
+	opts := Options{Prefix: "Hello"}
+	fmt.Println(opts.Greet("value"))
+}
+
+type Builder struct {
+	Name string
+}
+
+type Options struct {
+	Prefix string
+}
+
+type App struct {
+	Greeting string
+}
+
+func provideApp(b *Builder) (*App, error) {
+	return &App{Greeting: "built by " + b.Name}, nil
+}
+
+type Greeting string
+
+func provideGreeting(opts Options, name string) Greeting {
+	return Greeting(opts.Prefix + ", " + name)
+}
+
+var Set = wire.NewSet(provideApp)
@@ -0,0 +1,53 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+func main() {
+	w := injectWriter()
+	fmt.Fprint(*w, "hello")
+	fmt.Println(buf.String())
+	w2, err := injectWriterOrError()
+	fmt.Fprint(*w2, " world")
+	fmt.Println(buf.String(), err)
+	fmt.Fprint(*injectBoundWriter(), "!")
+	fmt.Println(buf.String())
+	fmt.Println(injectProvidedPointer() == &stdout)
+}
+
+var buf bytes.Buffer
+
+func provideWriter() io.Writer {
+	return &buf
+}
+
+func provideBuffer() *bytes.Buffer {
+	return &buf
+}
+
+var stdout io.Writer
+
+func provideWriterPointer(w io.Writer) *io.Writer {
+	stdout = w
+	return &stdout
+}
+
+// SetOutput has an API that demands a pointer to an interface.
+func SetOutput(w *io.Writer) {}
@@ -0,0 +1,50 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+type Foo int
+
+type Bar int
+
+func main() {
+	wire.SetLogger(logger{})
+	fmt.Println(injectBar())
+}
+
+// logger prints each message with the provider name, leaving out the
+// elapsed time so the output is stable.
+type logger struct{}
+
+func (logger) Debug(msg string, args ...interface{}) {
+	fmt.Println(msg, args[1])
+}
+
+func provideFoo() Foo {
+	return 41
+}
+
+func provideBar(foo Foo) (Bar, error) {
+	if foo == 0 {
+		return 0, errors.New("no foo")
+	}
+	return Bar(foo) + 1, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	r := injectReport()
+	fmt.Println(r.Name, r.Size, r.Text, r.Short, fileCalls)
+}
+
+type Namer interface {
+	Name() string
+}
+
+type Sizer interface {
+	Size() int
+}
+
+type Stringer interface {
+	String() string
+}
+
+// ShortStringer is bound to Stringer, which is in turn bound to *File.
+type ShortStringer interface {
+	String() string
+}
+
+type File struct {
+	name string
+}
+
+func (f *File) Name() string   { return f.name }
+func (f *File) Size() int      { return len(f.name) }
+func (f *File) String() string { return "file " + f.name }
+
+var fileCalls int
+
+func provideFile() *File {
+	fileCalls++
+	return &File{name: "wire.go"}
+}
+
+type Report struct {
+	Name  string
+	Size  int
+	Text  string
+	Short string
+}
+
+type name string
+type size int
+type text string
+type short string
+
+func provideName(n Namer) name           { return name(n.Name()) }
+func provideSize(s Sizer) size           { return size(s.Size()) }
+func provideText(s Stringer) text        { return text(s.String()) }
+func provideShort(s ShortStringer) short { return short(s.String()[:4]) }
+
+func provideReport(n name, s size, t text, sh short) *Report {
+	return &Report{Name: string(n), Size: int(s), Text: string(t), Short: string(sh)}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func main() {
+	fmt.Println(initServer())
+	fmt.Println(initPort())
+}
+
+type Server struct {
+	Addr string
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func (s *Server) String() string {
+	return "server on " + s.Addr
+}
+
+type Port int
+
+func (p Port) String() string {
+	return fmt.Sprintf("port %d", int(p))
+}
+
+func provideServer() *Server {
+	return &Server{Addr: ":8080"}
+}
+
+func providePort() Port {
+	return 8080
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build wireinject
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/wire"
+)
+
+func initServer() *Server {
+	wire.Build(provideServer)
+	return nil
+}
+
+func initPort() Port {
+	wire.Build(providePort)
+	return 0
+}
+
+var (
+	_ = wire.Check(new(http.Handler), initServer)
+	_ = wire.Check(new(fmt.Stringer), initServer)
+)
+
+var _ = wire.Check(new(fmt.Stringer), initPort)
+
+var defaultAddr = ":8080"
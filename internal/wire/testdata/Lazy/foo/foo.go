@@ -0,0 +1,73 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	app, cleanup, err := injectApp()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("injected")
+	cache, err := app.Cache()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	again, _ := app.Cache()
+	fmt.Println(cache.Name, cache == again, app.Index())
+	cleanup()
+}
+
+type Config struct {
+	Name string
+}
+
+type Cache struct {
+	Name string
+}
+
+type Index int
+
+type App struct {
+	Cache func() (*Cache, error)
+	Index func() Index
+}
+
+func provideConfig() *Config {
+	return &Config{Name: "cache"}
+}
+
+func provideCache(cfg *Config) (*Cache, func(), error) {
+	fmt.Println("building cache")
+	return &Cache{Name: cfg.Name}, func() { fmt.Println("cleaning up cache") }, nil
+}
+
+func provideIndex() Index {
+	fmt.Println("building index")
+	return 42
+}
+
+func provideApp(cache func() (*Cache, error), index func() Index) *App {
+	return &App{Cache: cache, Index: index}
+}
+
+var Set = wire.NewSet(provideConfig, wire.Lazy(provideCache), wire.Lazy(provideIndex), provideApp)
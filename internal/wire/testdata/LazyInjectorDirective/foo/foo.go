@@ -0,0 +1,52 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	a1, err := GetApp()
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+	a2, _ := GetApp()
+	fmt.Println(a1 == a2, a1.Name, calls)
+
+	_, err1 := GetBroken()
+	_, err2 := GetBroken()
+	fmt.Println(err1, err2, brokenCalls)
+}
+
+type App struct {
+	Name string
+}
+
+var calls, brokenCalls int
+
+func provideApp() *App {
+	calls++
+	return &App{Name: "app"}
+}
+
+type Broken struct{}
+
+func provideBroken() (*Broken, error) {
+	brokenCalls++
+	return nil, errors.New("broken")
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectGreeter().Greet())
+}
+
+type Greeter interface {
+	Greet() string
+}
+
+type English struct {
+	Name Name
+}
+
+func (e *English) Greet() string {
+	return "Hello, " + string(e.Name)
+}
+
+type Name string
+
+type Config struct {
+	Language string
+}
+
+func provideEnglish(name Name) *English {
+	return &English{Name: name}
+}
+
+func provideConfig() *Config {
+	return &Config{Language: "en"}
+}
+
+func provideUnused(c *Config) int {
+	return len(c.Language)
+}
+
+// Set is larger than injectGreeter needs.
+var Set = wire.NewSet(
+	provideEnglish,
+	wire.Bind(new(Greeter), new(*English)),
+	wire.Value(Name("World")),
+	provideConfig,
+	provideUnused,
+)
@@ -0,0 +1,49 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	s, cleanup, err := injectServer()
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+	defer cleanup()
+	fmt.Println(s.Addr, s.Port)
+}
+
+type Addr string
+type Port int
+
+type Server struct {
+	Addr Addr
+	Port Port
+}
+
+func provideAddr() (s Addr) {
+	return "localhost"
+}
+
+// provideUnnamed has a blank result name, so a name is made up from its type.
+func provideUnnamed() (_ Port) {
+	return 8080
+}
+
+// provideServer's result name is also taken by provideAddr.
+func provideServer(a Addr, p Port) (s *Server, cleanup func(), err error) {
+	return &Server{Addr: a, Port: p}, func() {}, nil
+}
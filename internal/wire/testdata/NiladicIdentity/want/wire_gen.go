@@ -9,6 +9,5 @@ package main
 // Injectors from wire.go:
 
 func injectedMessage() string {
-	string2 := provideMessage()
-	return string2
+	return provideMessage()
 }
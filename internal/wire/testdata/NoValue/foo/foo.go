@@ -0,0 +1,54 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	app := injectApp()
+	fmt.Println(app.Registry.Metrics)
+	r := injectRegistry()
+	fmt.Println(r.Metrics)
+}
+
+type Registry struct {
+	Metrics []string
+}
+
+type App struct {
+	Registry *Registry
+}
+
+func provideRegistry() *Registry {
+	return new(Registry)
+}
+
+func RegisterMetrics(r *Registry) {
+	r.Metrics = append(r.Metrics, "requests")
+}
+
+func RegisterDebugMetrics(r *Registry) {
+	r.Metrics = append(r.Metrics, "debug")
+}
+
+func provideApp(r *Registry) *App {
+	return &App{Registry: r}
+}
+
+var Set = wire.NewSet(provideRegistry, wire.NoValue(RegisterMetrics))
@@ -0,0 +1,55 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+type Config struct {
+	Addr string
+}
+
+type DB interface {
+	Name() string
+}
+
+type prodDB struct {
+	addr string
+}
+
+func (db *prodDB) Name() string { return "prod db at " + db.addr }
+
+type testDB struct {
+	addr string
+}
+
+func (db *testDB) Name() string { return "test db at " + db.addr }
+
+type App struct {
+	db DB
+}
+
+func (app *App) String() string {
+	return "app with " + app.db.Name()
+}
+
+func NewProdDB(cfg *Config) (DB, error) {
+	return &prodDB{addr: cfg.Addr}, nil
+}
+
+func NewTestDB(cfg *Config) DB {
+	return &testDB{addr: cfg.Addr}
+}
+
+func NewApp(db DB) *App {
+	return &App{db: db}
+}
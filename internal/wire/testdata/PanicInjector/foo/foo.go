@@ -0,0 +1,69 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	DSN string
+}
+
+type DB struct {
+	DSN string
+}
+
+type App struct {
+	DB *DB
+}
+
+func main() {
+	app := injectApp("db://local")
+	fmt.Println(app.DB.DSN)
+
+	defer func() {
+		fmt.Println("recovered:", recover())
+	}()
+	_, cleanup := injectAppWithCleanup("")
+	cleanup()
+}
+
+func provideConfig(dsn string) (*Config, error) {
+	if dsn == "" {
+		return nil, errors.New("missing DSN")
+	}
+	return &Config{DSN: dsn}, nil
+}
+
+func provideDB(cfg *Config) (*DB, error) {
+	if cfg == nil {
+		return nil, errors.New("no config")
+	}
+	return &DB{DSN: cfg.DSN}, nil
+}
+
+func provideApp(db *DB) *App {
+	return &App{DB: db}
+}
+
+func provideCheckedConfig(dsn string) (*Config, func(), error) {
+	return &Config{DSN: dsn}, func() { fmt.Println("config cleanup") }, nil
+}
+
+func provideFailingDB(cfg *Config) (*DB, error) {
+	return nil, fmt.Errorf("cannot open %q", cfg.DSN)
+}
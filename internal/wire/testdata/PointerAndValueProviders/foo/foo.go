@@ -0,0 +1,45 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+func main() {
+	b := injectBar()
+	fmt.Println(b.Value.Name, b.Ptr.Name, b.Ptr == nil)
+	fmt.Println(injectFoo().Name)
+	fmt.Println(injectFooPtr().Name)
+}
+
+type Foo struct {
+	Name string
+}
+
+type Bar struct {
+	Value Foo
+	Ptr   *Foo
+}
+
+func NewFoo() Foo {
+	return Foo{Name: "value"}
+}
+
+func NewFooPtr() *Foo {
+	return &Foo{Name: "pointer"}
+}
+
+func NewBar(value Foo, ptr *Foo) Bar {
+	return Bar{Value: value, Ptr: ptr}
+}
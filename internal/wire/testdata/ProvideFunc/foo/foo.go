@@ -0,0 +1,62 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	for _, remote := range []bool{false, true} {
+		app, err := initApp(&Config{RemoteCache: remote})
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		fmt.Println(app.cache.Get("key"))
+	}
+}
+
+type Config struct {
+	RemoteCache bool
+}
+
+type Cache interface {
+	Get(key string) string
+}
+
+// TieredCache reads from a remote cache when it is enabled.
+type TieredCache struct {
+	remote bool
+}
+
+func (c *TieredCache) Get(key string) string {
+	if c.remote {
+		return key + " from remote cache"
+	}
+	return key + " from local cache"
+}
+
+func NewCache(cfg *Config) (*TieredCache, error) {
+	return &TieredCache{remote: cfg.RemoteCache}, nil
+}
+
+type App struct {
+	cache Cache
+}
+
+func NewApp(cache Cache) *App {
+	return &App{cache: cache}
+}
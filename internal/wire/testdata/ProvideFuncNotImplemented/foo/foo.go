@@ -0,0 +1,38 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+func main() {}
+
+type Config struct {
+	RemoteCache bool
+}
+
+type Cache interface {
+	Get(key string) string
+}
+
+type TieredCache struct {
+	remote bool
+}
+
+// Get has a pointer receiver, but NewCache returns a TieredCache value.
+func (c *TieredCache) Get(key string) string {
+	return key
+}
+
+func NewCache(cfg *Config) TieredCache {
+	return TieredCache{remote: cfg.RemoteCache}
+}
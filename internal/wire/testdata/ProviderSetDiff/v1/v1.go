@@ -0,0 +1,37 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "github.com/google/wire"
+
+type Config struct {
+	Addr string
+}
+
+type Logger struct{}
+
+type Server struct {
+	Addr string
+}
+
+func NewLogger() *Logger {
+	return &Logger{}
+}
+
+func NewServer(c *Config, l *Logger) *Server {
+	return &Server{Addr: c.Addr}
+}
+
+var Set = wire.NewSet(NewLogger, NewServer)
@@ -0,0 +1,49 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	ch, err := injectChan()
+	fmt.Println(ch == nil, err)
+	m, err := injectMap()
+	fmt.Println(m == nil, err)
+	f, err := injectFunc()
+	fmt.Println(f == nil, err)
+}
+
+type Greeting string
+
+func provideGreeting() (Greeting, error) {
+	return "", errors.New("no greeting")
+}
+
+func provideChan(g Greeting) (chan string, error) {
+	ch := make(chan string, 1)
+	ch <- string(g)
+	return ch, nil
+}
+
+func provideMap(g Greeting) (map[string]int, error) {
+	return map[string]int{string(g): 1}, nil
+}
+
+func provideFunc(g Greeting) (func() string, error) {
+	return func() string { return string(g) }, nil
+}
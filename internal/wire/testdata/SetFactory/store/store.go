@@ -0,0 +1,62 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"strings"
+
+	"github.com/google/wire"
+)
+
+type Prefix string
+
+type Options struct {
+	ReadOnly bool
+	Shards   int
+	Ratio    float64
+}
+
+type Store struct {
+	Prefix Prefix
+	Opts   Options
+}
+
+func (s *Store) Key(k string) string {
+	return strings.Join([]string{string(s.Prefix), k}, "/")
+}
+
+func NewStore(p Prefix, opts Options) *Store {
+	return &Store{Prefix: p, Opts: opts}
+}
+
+// Set returns a provider set for a store whose keys start with prefix.
+func Set(prefix string, readOnly bool, shards int) wire.ProviderSet {
+	return wire.NewSet(
+		NewStore,
+		wire.Value(Prefix(prefix)),
+		wire.Value(Options{ReadOnly: readOnly, Shards: shards}),
+	)
+}
+
+// SampledSet returns a provider set for a store that samples the given
+// ratio of its keys.
+func SampledSet(prefix string, ratio float64) wire.ProviderSet {
+	return wire.NewSet(NewStore, wire.Value(Prefix(prefix)), wire.Value(Options{Shards: 1, Ratio: ratio}))
+}
+
+// DefaultSet returns the provider set for a read-write store.
+func DefaultSet(prefix Prefix) wire.ProviderSet {
+	return wire.NewSet(NewStore, wire.Value(prefix), wire.Value(Options{Shards: 1}))
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/wire"
+)
+
+type Prefix string
+
+func main() {
+	fmt.Println(injectPrefix())
+}
+
+func prefixSet(p string) wire.ProviderSet {
+	return wire.NewSet(wire.Value(Prefix(p)))
+}
+
+func loopSet(p string) wire.ProviderSet {
+	return wire.NewSet(loopSet(p))
+}
+
+func longSet(p string) wire.ProviderSet {
+	s := wire.NewSet(wire.Value(Prefix(p)))
+	return s
+}
+
+func anySet(n interface{}) wire.ProviderSet {
+	return wire.NewSet(wire.Value(Prefix(fmt.Sprint(n))))
+}
+
+var envPrefix = os.Getenv("PREFIX")
@@ -0,0 +1,77 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	app1, err := injectApp()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	app2, err := injectApp()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	db, err := injectDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(app1 != app2)
+	fmt.Println(app1.DB == app2.DB, app1.DB == db)
+	fmt.Println(configCalls, dbCalls)
+}
+
+var (
+	configCalls int
+	dbCalls     int
+)
+
+type Config struct {
+	Name string
+}
+
+type DB struct {
+	Config *Config
+}
+
+type App struct {
+	DB *DB
+}
+
+//wire:singleton
+func provideConfig() *Config {
+	configCalls++
+	return &Config{Name: "prod"}
+}
+
+//wire:singleton
+func provideDB(cfg *Config) (*DB, error) {
+	dbCalls++
+	return &DB{Config: cfg}, nil
+}
+
+func provideApp(db *DB) *App {
+	return &App{DB: db}
+}
+
+var Set = wire.NewSet(provideConfig, provideDB)
@@ -0,0 +1,62 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fmt.Println(injectFastApp())
+	fmt.Println(injectSlowApp())
+}
+
+type Cache interface {
+	Name() string
+}
+
+type fastCache struct{}
+
+func (fastCache) Name() string { return "fast cache" }
+
+type slowCache struct{}
+
+func (slowCache) Name() string { return "slow cache" }
+
+type App struct {
+	cache Cache
+}
+
+func (app *App) String() string {
+	return "app using " + app.cache.Name()
+}
+
+//wire:strategy Fast
+func newFastCache() Cache {
+	return fastCache{}
+}
+
+//wire:strategy Slow
+func newSlowCache() Cache {
+	return slowCache{}
+}
+
+func newApp(cache Cache) *App {
+	return &App{cache: cache}
+}
+
+var Set = wire.NewSet(newFastCache, newSlowCache, newApp)
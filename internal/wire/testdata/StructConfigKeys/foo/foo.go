@@ -0,0 +1,63 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	cfg, err := injectDBConfig()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(cfg.Host, cfg.Port, cfg.Debug, cfg.Logger.Prefix)
+	_, err = injectDBAddr(map[string]interface{}{"db.host": "localhost", "db.port": "x"})
+	fmt.Println(err)
+}
+
+type Port int
+
+type Logger struct {
+	Prefix string
+}
+
+type DBConfig struct {
+	Host   string `wire:"key=db.host"`
+	Port   Port   `wire:"key=db.port"`
+	Debug  bool   `wire:"key=debug"`
+	Logger *Logger
+}
+
+func provideConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"db.host": "localhost",
+		"db.port": 5432.0,
+		"debug":   "true",
+	}
+}
+
+func provideLogger() *Logger {
+	return &Logger{Prefix: "db"}
+}
+
+var Set = wire.NewSet(
+	wire.Struct(new(DBConfig), "*"),
+	provideConfig,
+	provideLogger,
+)
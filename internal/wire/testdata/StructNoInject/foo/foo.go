@@ -0,0 +1,48 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	fb := injectFooBar()
+	fmt.Println(fb.Foo, fb.Bar, fb.Baz == nil)
+}
+
+type Foo int
+type Bar int
+type Baz struct{}
+
+type FooBar struct {
+	Foo Foo
+
+	//wire:noinject
+	Bar Bar
+
+	Baz *Baz //wire:noinject
+}
+
+func provideFoo() Foo {
+	return 41
+}
+
+var Set = wire.NewSet(
+	wire.Struct(new(FooBar), "*"),
+	provideFoo,
+)
@@ -0,0 +1,65 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !wireinject
+
+package main
+
+import (
+	"testing"
+)
+
+type fakeCache struct{}
+
+func (fakeCache) Get(key string) string {
+	return "fake " + key
+}
+
+func TestApp(t *testing.T) {
+	tests := []struct {
+		name      string
+		kit       InitAppTestKit
+		wantDSN   string
+		wantValue string
+	}{
+		{
+			name:      "Real",
+			wantDSN:   "test",
+			wantValue: "hello",
+		},
+		{
+			name:      "FakeCache",
+			kit:       InitAppTestKit{Cache: fakeCache{}},
+			wantDSN:   "test",
+			wantValue: "fake greeting",
+		},
+		{
+			name:      "FakeDB",
+			kit:       InitAppTestKit{DB: &DB{DSN: "fake"}},
+			wantDSN:   "fake",
+			wantValue: "hello",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := test.kit.Build(t, &Config{DSN: "test"})
+			if app.DB.DSN != test.wantDSN {
+				t.Errorf("DB.DSN = %q; want %q", app.DB.DSN, test.wantDSN)
+			}
+			if got := app.Cache.Get("greeting"); got != test.wantValue {
+				t.Errorf("Cache.Get(\"greeting\") = %q; want %q", got, test.wantValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	app, cleanup, err := initApp(&Config{DSN: "prod"})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(app.DB.DSN, app.Cache.Get("greeting"))
+	cleanup()
+}
+
+type Config struct {
+	DSN string
+}
+
+type DB struct {
+	DSN string
+}
+
+func NewDB(cfg *Config) (*DB, func(), error) {
+	if cfg.DSN == "" {
+		return nil, nil, errors.New("no DSN")
+	}
+	return &DB{DSN: cfg.DSN}, func() { fmt.Println("closing", cfg.DSN) }, nil
+}
+
+type Cache interface {
+	Get(key string) string
+}
+
+type memCache map[string]string
+
+func (c memCache) Get(key string) string {
+	return c[key]
+}
+
+func NewCache() Cache {
+	return memCache{"greeting": "hello"}
+}
+
+type App struct {
+	DB    *DB
+	Cache Cache
+}
+
+func NewApp(db *DB, cache Cache) *App {
+	return &App{DB: db, Cache: cache}
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"example.com/bar"
+	"example.com/baz"
+)
+
+func main() {
+	s := injectServer()
+	fmt.Println(s.Addr)
+	fmt.Println(s.Logger.Log("started"))
+	s = injectServerWithConfig(&baz.Config{Addr: ":9090"})
+	fmt.Println(s.Addr)
+	fmt.Println(s.Logger.Log("restarted"))
+}
+
+// Server depends on bar's types through the aliases in baz.
+type Server struct {
+	Addr   string
+	Logger baz.Logger
+}
+
+type ServerAlias = Server
+
+func provideLogger() baz.Logger {
+	return bar.NewLogger()
+}
+
+func newServer(cfg *baz.Config, logger baz.Logger) *ServerAlias {
+	return &Server{Addr: cfg.Addr, Logger: logger}
+}
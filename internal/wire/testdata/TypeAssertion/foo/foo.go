@@ -0,0 +1,65 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func main() {
+	g, err := injectGreeter()
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+	fmt.Println(g.Greet())
+
+	_, err = injectBrokenGreeter()
+	fmt.Println("ERROR:", err)
+
+	r, cleanup, err := injectReader()
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+	defer cleanup()
+	b, _ := io.ReadAll(r)
+	fmt.Println(string(b))
+}
+
+type Greeter interface {
+	Greet() string
+}
+
+type english struct{}
+
+func (english) Greet() string {
+	return "Hello, World!"
+}
+
+// loadPlugin's result type is only known at run time.
+func loadPlugin() interface{} {
+	return english{}
+}
+
+func loadBrokenPlugin() interface{} {
+	return 42
+}
+
+func provideReader() (io.Reader, func()) {
+	return strings.NewReader("contents"), func() {}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func main() {
+	r := new(Router)
+	registerRoutes(r)
+	fmt.Println(r.Routes)
+	if err := registerAll(new(Router), false); err != nil {
+		fmt.Println("error:", err)
+	}
+	if err := registerAll(new(Router), true); err != nil {
+		fmt.Println("error:", err)
+	}
+}
+
+type Router struct {
+	Routes []string
+}
+
+type Handler struct {
+	Name string
+}
+
+func provideHandler() *Handler {
+	return &Handler{Name: "index"}
+}
+
+func RegisterIndex(r *Router, h *Handler) {
+	r.Routes = append(r.Routes, h.Name)
+}
+
+type Health struct{}
+
+func RegisterHealth(r *Router, fail bool) (*Health, error) {
+	if fail {
+		return nil, errors.New("health check unavailable")
+	}
+	r.Routes = append(r.Routes, "health")
+	fmt.Println(r.Routes)
+	return new(Health), nil
+}
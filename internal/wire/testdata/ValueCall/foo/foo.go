@@ -0,0 +1,51 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	// The value is evaluated when the injector is called, not at package
+	// initialization.
+	os.Setenv("WIRE_TEST_PORT", "8080")
+	fmt.Println(injectServer())
+	os.Setenv("WIRE_TEST_PORT", "9090")
+	fmt.Println(injectServer())
+}
+
+type Port string
+
+type Server struct {
+	port  Port
+	calls int
+}
+
+func (s *Server) String() string {
+	return fmt.Sprintf("port %s at call %d", s.port, s.calls)
+}
+
+var calls int
+
+func nextCall() int {
+	calls++
+	return calls
+}
+
+func newServer(port Port, calls int) *Server {
+	return &Server{port: port, calls: calls}
+}
@@ -9,6 +9,5 @@ package main
 // Injectors from wire.go:
 
 func injectedMessage(t title, lines ...string) string {
-	string2 := provideMessage(lines...)
-	return string2
+	return provideMessage(lines...)
 }
@@ -0,0 +1,80 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+)
+
+func main() {
+	s, err := injectServer()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(s.Run())
+}
+
+type Handler interface {
+	Serve() string
+}
+
+type Base struct{}
+
+func (*Base) Serve() string { return "hello" }
+
+type Prefix string
+
+type prefixed struct {
+	h      Handler
+	prefix Prefix
+}
+
+func (p prefixed) Serve() string { return string(p.prefix) + p.h.Serve() }
+
+type exclaimed struct {
+	h Handler
+}
+
+func (e exclaimed) Serve() string { return e.h.Serve() + "!" }
+
+type Server struct {
+	h Handler
+}
+
+func (s *Server) Run() string { return "served by " + s.h.Serve() }
+
+func NewBase() *Base {
+	return new(Base)
+}
+
+func WithPrefix(h Handler, prefix Prefix) Handler {
+	return prefixed{h, prefix}
+}
+
+func WithExclamation(h Handler) (Handler, error) {
+	return exclaimed{h}, nil
+}
+
+func NewServer(h Handler) *Server {
+	return &Server{h}
+}
+
+var HandlerSet = wire.NewSet(
+	NewBase,
+	wire.Bind(new(Handler), new(*Base)),
+	wire.Wrap(new(Handler), WithPrefix, WithExclamation))
@@ -0,0 +1,42 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println(injectHandler())
+}
+
+type Handler func() string
+
+type Logger struct {
+	h Handler
+}
+
+func NewHandler() Handler {
+	return func() string { return "hello" }
+}
+
+// NewLogger needs the wrapped Handler that WithLogging produces.
+func NewLogger(h Handler) *Logger {
+	return &Logger{h}
+}
+
+func WithLogging(h Handler, l *Logger) Handler {
+	return h
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.18
+// +build !go1.18
+
+package wire
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// namedTypeArgs returns the type arguments of an instantiated generic type.
+// Go toolchains before 1.18 do not support generics.
+func namedTypeArgs(t *types.Named) []types.Type {
+	return nil
+}
+
+// isTypeParam reports whether t is a type parameter.
+func isTypeParam(t types.Type) bool {
+	return false
+}
+
+// funcInstance returns the generic function that expr instantiates.
+func funcInstance(info *types.Info, expr ast.Expr) (*types.Func, *types.Signature, []types.Type) {
+	return nil, nil, nil
+}
+
+// typeParamCount returns the number of type parameters of a function.
+func typeParamCount(sig *types.Signature) int {
+	return 0
+}
+
+// typeParamList returns the type parameter list of a generic function.
+func typeParamList(sig *types.Signature, qf types.Qualifier) string {
+	return ""
+}
+
+// newTypesInfo returns a types.Info that records what packages.Load
+// records for a package.
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package wire
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// namedTypeArgs returns the type arguments of an instantiated generic type.
+func namedTypeArgs(t *types.Named) []types.Type {
+	args := t.TypeArgs()
+	list := make([]types.Type, args.Len())
+	for i := range list {
+		list[i] = args.At(i)
+	}
+	return list
+}
+
+// isTypeParam reports whether t is a type parameter.
+func isTypeParam(t types.Type) bool {
+	_, ok := t.(*types.TypeParam)
+	return ok
+}
+
+// funcInstance returns the generic function that expr instantiates, as in
+// NewFoo[int], along with the instantiated signature and the type
+// arguments. It returns a nil function if expr is not such an instantiation.
+func funcInstance(info *types.Info, expr ast.Expr) (*types.Func, *types.Signature, []types.Type) {
+	var x ast.Expr
+	switch expr := astutil.Unparen(expr).(type) {
+	case *ast.IndexExpr:
+		x = expr.X
+	case *ast.IndexListExpr:
+		x = expr.X
+	default:
+		return nil, nil, nil
+	}
+	var id *ast.Ident
+	switch x := astutil.Unparen(x).(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return nil, nil, nil
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok {
+		return nil, nil, nil
+	}
+	inst, ok := info.Instances[id]
+	if !ok {
+		return nil, nil, nil
+	}
+	typeArgs := make([]types.Type, inst.TypeArgs.Len())
+	for i := range typeArgs {
+		typeArgs[i] = inst.TypeArgs.At(i)
+	}
+	return fn, inst.Type.(*types.Signature), typeArgs
+}
+
+// typeParamCount returns the number of type parameters of a function.
+func typeParamCount(sig *types.Signature) int {
+	return sig.TypeParams().Len()
+}
+
+// typeParamList returns the type parameter list of a generic function as
+// written in its declaration, as in "[T Store]", or the empty string if the
+// function is not generic.
+func typeParamList(sig *types.Signature, qf types.Qualifier) string {
+	tparams := sig.TypeParams()
+	if tparams.Len() == 0 {
+		return ""
+	}
+	list := make([]string, tparams.Len())
+	for i := range list {
+		tp := tparams.At(i)
+		list[i] = tp.Obj().Name() + " " + types.TypeString(tp.Constraint(), qf)
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+// newTypesInfo returns a types.Info that records what packages.Load
+// records for a package.
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Instances:  make(map[*ast.Ident]types.Instance),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+}
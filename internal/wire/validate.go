@@ -0,0 +1,98 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+)
+
+// ValidateAll analyzes the packages matching patterns as Generate does, but
+// without generating code, and returns every error it finds. These include
+// errors in provider set variables that no injector uses, and errors in each
+// injector, such as missing providers, cycles, conflicting bindings and
+// mismatched types. An error reported for several injectors from the same
+// root cause is returned once. This covers a provider set error reported for
+// each injector that uses the set, and a missing type needed by the same
+// provider in several injectors.
+//
+// The other arguments are interpreted as they are by Load.
+func ValidateAll(ctx context.Context, wd string, env []string, tags string, patterns []string) []error {
+	pkgs, errs := load(ctx, wd, env, tags, patterns, false)
+	if len(errs) > 0 {
+		return errs
+	}
+	if len(pkgs) == 0 {
+		return nil
+	}
+	oc := newObjectCache(pkgs)
+	ec := new(errorCollector)
+	for _, pkg := range pkgs {
+		if isWireImport(pkg.PkgPath) {
+			// The marker function package confuses analysis.
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if !isProviderSetType(obj.Type()) {
+				continue
+			}
+			if _, errs := oc.get(obj); len(errs) > 0 {
+				ec.add(notePositionAll(oc.fset.Position(obj.Pos()), errs)...)
+			}
+		}
+		g := newGen(pkg)
+		g.target = buildTarget(env, tags)
+		_, errs := generateInjectors(g, pkg, oc)
+		ec.add(errs...)
+	}
+	return dedupeErrors(ec.errors)
+}
+
+// dedupeErrors returns errs without the errors that share the root cause of
+// an earlier one: the same message at the same position, or the same missing
+// type needed directly by the same provider.
+func dedupeErrors(errs []error) []error {
+	seen := make(map[string]bool)
+	var deduped []error
+	for _, err := range errs {
+		key := err.Error()
+		if missing := missingProvider(err); missing != nil && len(missing.NeededBy) > 0 {
+			key = "no provider found for " + missing.Type + " needed by " + missing.NeededBy[0].Source
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, err)
+	}
+	return deduped
+}
+
+// missingProvider returns the *MissingProviderError that err wraps, or nil.
+// It follows Unwrap methods itself since errors.As needs go1.13.
+func missingProvider(err error) *MissingProviderError {
+	for err != nil {
+		if missing, ok := err.(*MissingProviderError); ok {
+			return missing
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return nil
+		}
+		err = u.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Polling intervals used by Watch. They are variables so that tests can
+// shorten them.
+var (
+	// watchPollInterval is how often Watch checks for changed files.
+	watchPollInterval = 500 * time.Millisecond
+	// watchDebounce is how long files must stay unchanged after a change
+	// before Watch regenerates.
+	watchDebounce = 200 * time.Millisecond
+)
+
+// Watch generates the injectors for the packages matching patterns, then
+// generates them again each time the Go files of the packages or of their
+// dependencies in the main module change, until ctx is done.
+// Files are polled, and a burst of changes causes a single regeneration
+// once the files have stopped changing. Changes to the generated files
+// themselves are ignored.
+//
+// Watch does not write the generated files: it passes the results of each
+// call to Generate to onResult, which is called from the goroutine running
+// Watch and may call GenerateResult.Commit. The other arguments are
+// interpreted as they are by Generate. If opts does not set a ProviderCache,
+// Watch uses its own so that unchanged packages are not reloaded.
+//
+// Watch returns ctx.Err() once ctx is done.
+func Watch(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions, onResult func([]GenerateResult, []error)) error {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	if opts.ProviderCache == nil && !opts.Tests {
+		cp := *opts
+		cp.ProviderCache = NewProviderCache()
+		opts = &cp
+	}
+	var w watcher
+	regenerate := func() {
+		results, errs := Generate(ctx, wd, env, patterns, opts)
+		if ctx.Err() != nil {
+			return
+		}
+		w.outputs = make(map[string]bool)
+		for _, res := range results {
+			if res.OutputPath != "" {
+				w.outputs[res.OutputPath] = true
+			}
+		}
+		// Refresh the watched files, since the change may have added
+		// files or imports. If the packages cannot all be listed, add
+		// the files that could be, and those of the directories the
+		// patterns name, to the files from before, so that fixing the
+		// error is noticed.
+		listed, listErrs := loadFiles(ctx, wd, env, opts.Tags, patterns)
+		if len(listErrs) == 0 {
+			w.files = watchedFiles(listed)
+		} else {
+			w.files = mergeFiles(w.files, watchedFiles(listed), patternFiles(wd, patterns))
+		}
+		w.state = w.snapshot()
+		onResult(results, errs)
+	}
+	regenerate()
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if w.snapshot() == w.state {
+			continue
+		}
+		// Wait for the files to stop changing.
+		for state := w.snapshot(); ; {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(watchDebounce):
+			}
+			next := w.snapshot()
+			if next == state {
+				break
+			}
+			state = next
+		}
+		regenerate()
+	}
+}
+
+// A watcher records the files that Watch polls.
+type watcher struct {
+	// files is the sorted list of Go files to watch.
+	files []string
+	// outputs holds the paths of the generated files, which are ignored.
+	outputs map[string]bool
+	// state is the snapshot taken after the last generation.
+	state string
+}
+
+// snapshot returns a description of the current state of the watched files
+// and of the Go files in their directories, which changes when a file is
+// modified, added or removed.
+func (w *watcher) snapshot() string {
+	var sb strings.Builder
+	dirs := make(map[string]bool)
+	for _, path := range w.files {
+		dirs[filepath.Dir(path)] = true
+		if w.outputs[path] {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s missing\n", path)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	dirList := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		dirList = append(dirList, dir)
+	}
+	sort.Strings(dirList)
+	for _, dir := range dirList {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s unreadable\n", dir)
+			continue
+		}
+		for _, info := range infos {
+			path := filepath.Join(dir, info.Name())
+			if filepath.Ext(path) == ".go" && !w.outputs[path] {
+				fmt.Fprintf(&sb, "%s listed\n", path)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// watchedFiles returns the sorted paths of the Go files in pkgs and their
+// dependencies that belong to the main module or to a module replaced by a
+// local directory. Other modules, such as those in the module cache, are
+// not expected to change. Outside module mode, all files except those of
+// the standard library are included. The Go files that pkgs ignore are
+// included too, since they may be inject files excluded by a //go:build
+// ignore constraint.
+func watchedFiles(pkgs []*packages.Package) []string {
+	goroot := filepath.Clean(runtime.GOROOT()) + string(filepath.Separator)
+	var files []string
+	for _, p := range pkgs {
+		for _, f := range p.IgnoredFiles {
+			if strings.HasSuffix(f, ".go") {
+				files = append(files, f)
+			}
+		}
+	}
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if m := p.Module; m != nil && !m.Main && (m.Replace == nil || m.Replace.Version != "") {
+			return
+		}
+		for _, f := range p.GoFiles {
+			if !strings.HasPrefix(f, goroot) {
+				files = append(files, f)
+			}
+		}
+	})
+	sort.Strings(files)
+	return files
+}
+
+// patternFiles returns the Go files in the directories named by the
+// relative or absolute paths among patterns, such as "." or "./cmd/...",
+// for Watch to fall back on when the packages cannot be listed. Import
+// path patterns are skipped.
+func patternFiles(wd string, patterns []string) []string {
+	var files []string
+	addDir := func(dir string) {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, info := range infos {
+			if !info.IsDir() && filepath.Ext(info.Name()) == ".go" {
+				files = append(files, filepath.Join(dir, info.Name()))
+			}
+		}
+	}
+	for _, pattern := range patterns {
+		if !build.IsLocalImport(pattern) && !filepath.IsAbs(pattern) {
+			continue
+		}
+		dir := pattern
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(wd, dir)
+		}
+		if !strings.HasSuffix(pattern, "...") {
+			addDir(dir)
+			continue
+		}
+		root := filepath.Dir(dir)
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return nil
+			}
+			// The go command skips these directories when matching
+			// patterns with "...".
+			if name := info.Name(); path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			addDir(path)
+			return nil
+		})
+	}
+	return files
+}
+
+// mergeFiles returns the sorted union of lists of paths.
+func mergeFiles(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, list := range lists {
+		for _, f := range list {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
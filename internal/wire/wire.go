@@ -19,14 +19,20 @@ package wire
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/constant"
 	"go/format"
 	"go/printer"
 	"go/token"
 	"go/types"
+	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -60,12 +66,198 @@ func (gen GenerateResult) Commit() error {
 	return ioutil.WriteFile(gen.OutputPath, gen.Content, 0666)
 }
 
+// CheckGenerated generates the injectors for the packages that match the
+// given patterns, as Generate does, and compares the results to the files
+// already on disk. It returns the paths of the files that are missing or
+// differ from what Wire would generate. If update is true, it also writes
+// those files. Errors from loading the packages or generating any of them
+// are returned, and no file is written for a package that failed.
+func CheckGenerated(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions, update bool) (stale []string, _ []error) {
+	results, errs := Generate(ctx, wd, env, patterns, opts)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	ec := new(errorCollector)
+	for _, res := range results {
+		if len(res.Errs) > 0 {
+			ec.add(res.Errs...)
+			continue
+		}
+		if len(res.Content) == 0 {
+			continue
+		}
+		if cur, err := ioutil.ReadFile(res.OutputPath); err == nil && bytes.Equal(cur, res.Content) {
+			continue
+		}
+		stale = append(stale, res.OutputPath)
+		if update {
+			ec.add(res.Commit())
+		}
+	}
+	return stale, ec.errors
+}
+
 // GenerateOptions holds options for Generate.
 type GenerateOptions struct {
 	// Header will be inserted at the start of each generated file.
 	Header           []byte
 	PrefixOutputFile string
 	Tags             string
+
+	// GoVersion is the oldest Go version that the generated code must
+	// compile with, such as "go1.17". Injectors that need newer language
+	// features or standard library APIs are reported as errors. If empty, it defaults to the version
+	// of the Go toolchain running Wire.
+	GoVersion string
+
+	// CacheDir is a directory in which to record generated code. If set,
+	// packages whose source files and dependencies have not changed since
+	// the last run reuse the recorded code instead of being type-checked
+	// and analyzed again.
+	CacheDir string
+
+	// ProviderCache, if not nil, holds loaded packages and their provider
+	// sets for reuse by later calls to Generate with the same cache.
+	ProviderCache *ProviderCache
+
+	// Tests causes injectors declared in _test.go files to be generated as
+	// well. Injectors in a package's own test files are written to
+	// wire_gen_test.go, and those in its external test package to
+	// wire_gen_external_test.go, each with PrefixOutputFile prepended.
+	// CacheDir and ProviderCache are not used when Tests is set.
+	Tests bool
+
+	// MainFunc, if set, is the name of a function to generate in package
+	// main, usually "main", that calls the package's only injector. It is
+	// generated from MainTemplate, or DefaultMainTemplate if MainTemplate
+	// is empty.
+	MainFunc     string
+	MainTemplate string
+
+	// StrictExports makes it an error for an exported provider set variable
+	// to include an unexported provider function of the same package. By
+	// default, such providers are reported as warnings on stderr.
+	StrictExports bool
+
+	// WrapErrors makes generated injectors wrap each error returned by a
+	// provider with the provider's name, as in
+	// fmt.Errorf("provider foo.NewDB: %w", err).
+	WrapErrors bool
+
+	// StructuredErrors makes generated injectors return each error returned
+	// by a provider as a *wire.ProviderError that identifies the provider,
+	// as in &wire.ProviderError{Provider: "NewDB", Package: "example.com/db",
+	// Cause: err}. It takes precedence over WrapErrors.
+	StructuredErrors bool
+
+	// InstrumentProviders makes generated injectors call wire.TraceProvider
+	// around each provider call. The calls log only in programs built with
+	// the wiredebug build tag.
+	InstrumentProviders bool
+
+	// OtelTrace makes generated injectors start an OpenTelemetry span
+	// around each provider call, named for the provider as in
+	// "foo.NewDB". Providers that take the injector's context.Context
+	// receive the span's context instead. Injectors that call providers
+	// must then accept a context.Context, and the generated code imports
+	// go.opentelemetry.io/otel, which the module must require.
+	OtelTrace bool
+
+	// GroupImports splits the imports of generated files into two groups
+	// separated by a blank line, as goimports does: standard library
+	// packages first, then all others. By default, all imports are in one
+	// group.
+	GroupImports bool
+
+	// Nolint, if set, adds a //nolint directive before the package clause of
+	// generated files, so that linters skip them without excluding them by
+	// path. It is a comma-separated list of the linters to suppress, such as
+	// "errcheck,funlen", or "all".
+	Nolint string
+
+	// InputsHash adds a // wire-inputs-hash: comment to the header of
+	// generated files, holding a hash of the signatures of the injectors and
+	// providers the file uses. The hash changes only when these inputs do, so
+	// tools can tell a change to the provider graph apart from changes to the
+	// generated code's formatting.
+	InputsHash bool
+
+	// OneFilePerInjector writes each injector of a package to a file of its
+	// own, named after the injector, such as wire_gen_newapp.go for NewApp.
+	// Each file imports only the packages its injector uses. Code that is not
+	// part of an injector, such as the //go:generate directive and the main
+	// function generated for MainFunc, is written to wire_gen.go as usual.
+	// Generate returns one GenerateResult for each file. CacheDir is not used
+	// when OneFilePerInjector is set.
+	OneFilePerInjector bool
+
+	// VisitCall, if not nil, is called for each provider function call in
+	// the generated injectors. The returned before and after snippets are
+	// Go statements written just before and just after the call, ahead of
+	// the check of the error it returns. They may refer to the injector's
+	// parameters, to the variables of earlier calls, and, in after, to the
+	// call's own result, but not to packages the generated file does not
+	// import for other reasons. CacheDir is not used when VisitCall is set.
+	VisitCall func(call CallInfo) (before, after string)
+
+	// EmitMarkdown makes Generate also document the injectors of each
+	// package in a wire_graph.md file next to its generated code, with
+	// PrefixOutputFile prepended. The file holds a table of the injectors
+	// and their signatures, a table of the providers they call, and a
+	// Mermaid diagram of their dependency graphs. It is not written for
+	// test packages. CacheDir is not used when EmitMarkdown is set.
+	EmitMarkdown bool
+
+	// AutoDeref lets injectors use a provider of a named struct type where
+	// a pointer to the struct is needed, by taking the address of its
+	// output, and a provider of a pointer to a named struct type where the
+	// struct is needed, by dereferencing its output. A type provided
+	// directly is never converted. It is off by default, since the
+	// conversions copy structs and may dereference nil pointers.
+	AutoDeref bool
+
+	// ImportAliases maps import paths to the names that generated files
+	// should give their imports, such as "pb" for a protobuf package. By
+	// default, an import is named after its package. If the preferred name
+	// is already taken in a file, as by another import or a declaration of
+	// the package, a number is appended to it.
+	ImportAliases map[string]string
+
+	// ConfigFiles makes Generate read wire.yaml files, as LoadConfig does,
+	// and fill in the options left unset with their settings. The file in
+	// the directory of a package applies to the code generated for it,
+	// through the header_file, output_file_prefix, go_version and nolint
+	// keys, and takes precedence over the file in the working directory.
+	// The tags and cache_dir keys apply to loading, which covers all
+	// packages at once, so they are only read from the working directory.
+	ConfigFiles bool
+
+	// wdConfig holds the settings of the wire.yaml file in the working
+	// directory if ConfigFiles is set, or nil.
+	wdConfig *PackageConfig
+
+	// Indent is the indentation of each level of the generated source
+	// before it is formatted, such as "    " for four spaces. It defaults to
+	// a tab. Generated files are formatted with gofmt, which indents with
+	// tabs regardless, so Indent only shows in the unformatted source that
+	// Generate returns along with the error when formatting fails.
+	Indent string
+}
+
+// CallInfo describes a provider function call in a generated injector, as
+// passed to GenerateOptions.VisitCall.
+type CallInfo struct {
+	// Injector is the name of the injector function.
+	Injector string
+	// Provider is the provider function, qualified by its package name as
+	// in "foo.NewDB".
+	Provider string
+	// Out is the type the provider returns, not counting a cleanup
+	// function or error.
+	Out types.Type
+	// Var is the name of the local variable holding the call's result, or
+	// "_" if the result is discarded.
+	Var string
 }
 
 // Generate performs dependency injection for the packages that match the given
@@ -79,16 +271,100 @@ type GenerateOptions struct {
 // In case of duplicate environment variables, the last one in the list
 // takes precedence.
 //
+// Packages that match the patterns are loaded and type-checked together, and
+// the provider sets they share are analyzed once. To generate several
+// binaries that use the same provider library, such as ./cmd/server and
+// ./cmd/worker, pass all of them in a single call.
+//
 // Generate may return one or more errors if it failed to load the packages.
 func Generate(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions) ([]GenerateResult, []error) {
 	if opts == nil {
 		opts = &GenerateOptions{}
 	}
-	pkgs, errs := load(ctx, wd, env, opts.Tags, patterns)
+	if opts.ConfigFiles && opts.wdConfig == nil {
+		cfg, err := LoadConfig(wd)
+		if err != nil {
+			return nil, []error{err}
+		}
+		if cfg != nil {
+			cp := *opts
+			cp.wdConfig = cfg
+			if cp.Tags == "" {
+				cp.Tags = cfg.Tags
+			}
+			if cp.CacheDir == "" {
+				cp.CacheDir = cfg.CacheDir
+			}
+			opts = &cp
+		}
+	}
+	if opts.CacheDir != "" && !opts.Tests && !opts.OneFilePerInjector && opts.VisitCall == nil && !opts.EmitMarkdown {
+		return generateCached(ctx, wd, env, patterns, opts)
+	}
+	return generate(ctx, wd, env, patterns, opts)
+}
+
+// generate implements Generate without consulting opts.CacheDir.
+func generate(ctx context.Context, wd string, env []string, patterns []string, opts *GenerateOptions) ([]GenerateResult, []error) {
+	goMinor, err := parseGoVersion(opts.GoVersion)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if _, err := nolintDirective(opts.Nolint); err != nil {
+		return nil, []error{err}
+	}
+	if err := checkImportAliases(opts.ImportAliases); err != nil {
+		return nil, []error{err}
+	}
+	if opts.MainFunc != "" {
+		// Report template errors once, not for each package.
+		if _, err := mainTemplate(opts); err != nil {
+			return nil, []error{err}
+		}
+	}
+	if opts.ProviderCache != nil && !opts.Tests {
+		return opts.ProviderCache.generate(ctx, wd, env, patterns, opts, goMinor)
+	}
+	pkgs, errs := load(ctx, wd, env, opts.Tags, patterns, opts.Tests)
 	if len(errs) > 0 {
 		return nil, errs
 	}
+	return generatePackages(pkgs, objectCaches(pkgs, opts.Tests), env, opts, goMinor), nil
+}
+
+// objectCaches returns the object cache to use for each of pkgs, which were
+// loaded together. Packages share a cache, so that provider sets they have
+// in common, such as those of a library used by several binaries, are
+// analyzed once. Test variants have the same import paths as the packages
+// they augment, so each gets a cache of its own when tests are loaded.
+func objectCaches(pkgs []*packages.Package, tests bool) []*objectCache {
+	caches := make([]*objectCache, len(pkgs))
+	if len(pkgs) == 0 {
+		return caches
+	}
+	if tests {
+		for i, pkg := range pkgs {
+			caches[i] = newObjectCache([]*packages.Package{pkg})
+		}
+		return caches
+	}
+	oc := newObjectCache(pkgs)
+	for i := range caches {
+		caches[i] = oc
+	}
+	return caches
+}
+
+// generatePackages generates code for each of pkgs, using the object cache
+// at the same index in caches. It returns a result for each package, or, if
+// opts.OneFilePerInjector is set, for each file generated for the package.
+func generatePackages(pkgs []*packages.Package, caches []*objectCache, env []string, opts *GenerateOptions, goMinor int) []GenerateResult {
 	generated := make([]GenerateResult, len(pkgs))
+	var split [][]GenerateResult
+	if opts.OneFilePerInjector {
+		split = make([][]GenerateResult, len(pkgs))
+	}
+	var docs []GenerateResult
 	for i, pkg := range pkgs {
 		generated[i].PkgPath = pkg.PkgPath
 		outDir, err := detectOutputDir(pkg.GoFiles)
@@ -96,29 +372,133 @@ func Generate(ctx context.Context, wd string, env []string, patterns []string, o
 			generated[i].Errs = append(generated[i].Errs, err)
 			continue
 		}
-		generated[i].OutputPath = filepath.Join(outDir, opts.PrefixOutputFile+"wire_gen.go")
+		pkgOpts, pkgMinor, err := packageOptions(opts, outDir, goMinor)
+		if err != nil {
+			generated[i].Errs = append(generated[i].Errs, err)
+			continue
+		}
+		generated[i].OutputPath = filepath.Join(outDir, pkgOpts.PrefixOutputFile+outputFileName(pkg))
+		if errs := caches[i].unexportedProviders(pkg); len(errs) > 0 {
+			if pkgOpts.StrictExports {
+				generated[i].Errs = errs
+				continue
+			}
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
 		g := newGen(pkg)
-		injectorFiles, errs := generateInjectors(g, pkg)
+		g.goMinor = pkgMinor
+		g.wrapErrors = pkgOpts.WrapErrors
+		g.structuredErrors = pkgOpts.StructuredErrors
+		g.instrument = pkgOpts.InstrumentProviders
+		g.visitCall = pkgOpts.VisitCall
+		g.otelTrace = pkgOpts.OtelTrace
+		g.groupImports = pkgOpts.GroupImports
+		g.nolint, _ = nolintDirective(pkgOpts.Nolint)
+		g.inputsHash = pkgOpts.InputsHash
+		g.importAliases = pkgOpts.ImportAliases
+		g.autoDeref = pkgOpts.AutoDeref
+		g.genMain = pkgOpts.MainFunc != ""
+		g.target = buildTarget(env, pkgOpts.Tags)
+		if pkgOpts.OneFilePerInjector {
+			g.tags = pkgOpts.Tags
+			g.fileImports = make(map[string]bool)
+		}
+		injectorFiles, errs := generateInjectors(g, pkg, caches[i])
 		if len(errs) > 0 {
 			generated[i].Errs = errs
 			continue
 		}
+		if pkgOpts.MainFunc != "" && pkg.Name == "main" && len(g.injectors) > 0 {
+			if err := g.mainFunc(pkgOpts); err != nil {
+				generated[i].Errs = []error{err}
+				continue
+			}
+		}
 		copyNonInjectorDecls(g, injectorFiles, pkg.TypesInfo)
-		goSrc := g.frame(opts.Tags)
-		if len(opts.Header) > 0 {
-			goSrc = append(opts.Header, goSrc...)
+		generated[i].Content, generated[i].Errs = formatOutput(pkgOpts.Header, g.frame(pkgOpts.Tags), pkgOpts.Indent)
+		if pkgOpts.EmitMarkdown && !isTestVariant(pkg) && len(generated[i].Errs) == 0 {
+			doc := GenerateResult{PkgPath: pkg.PkgPath, OutputPath: filepath.Join(outDir, pkgOpts.PrefixOutputFile+markdownFileName)}
+			doc.Content, doc.Errs = graphMarkdown(pkg, caches[i], pkgOpts.AutoDeref)
+			if len(doc.Content) > 0 || len(doc.Errs) > 0 {
+				docs = append(docs, doc)
+			}
 		}
-		fmtSrc, err := format.Source(goSrc)
-		if err != nil {
-			// This is likely a bug from a poorly generated source file.
-			// Add an error but also the unformatted source.
-			generated[i].Errs = append(generated[i].Errs, err)
+		if split != nil && len(g.files) > 0 {
+			split[i] = append(split[i], generated[i])
+			for _, f := range g.files {
+				res := GenerateResult{PkgPath: pkg.PkgPath, OutputPath: filepath.Join(outDir, pkgOpts.PrefixOutputFile+f.name)}
+				res.Content, res.Errs = formatOutput(pkgOpts.Header, f.src, pkgOpts.Indent)
+				split[i] = append(split[i], res)
+			}
+		}
+	}
+	if split == nil {
+		return append(generated, docs...)
+	}
+	var all []GenerateResult
+	for i := range generated {
+		if split[i] != nil {
+			all = append(all, split[i]...)
 		} else {
-			goSrc = fmtSrc
+			all = append(all, generated[i])
 		}
-		generated[i].Content = goSrc
 	}
-	return generated, nil
+	return append(all, docs...)
+}
+
+// formatOutput prepends header to the unformatted Go source src and formats
+// it. If src cannot be formatted, formatOutput returns the unformatted source,
+// indented with indent if it is not empty, along with the error.
+func formatOutput(header, src []byte, indent string) ([]byte, []error) {
+	if len(src) == 0 {
+		return nil, nil
+	}
+	if len(header) > 0 {
+		src = append(append([]byte(nil), header...), src...)
+	}
+	fmtSrc, err := format.Source(src)
+	if err != nil {
+		// This is likely a bug from a poorly generated source file.
+		return reindent(src, indent), []error{err}
+	}
+	return fmtSrc, nil
+}
+
+// reindent replaces the tabs that indent each line of src with indent.
+// It returns src unchanged if indent is empty.
+func reindent(src []byte, indent string) []byte {
+	if indent == "" || indent == "\t" {
+		return src
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	var buf bytes.Buffer
+	for _, line := range lines {
+		trimmed := bytes.TrimLeft(line, "\t")
+		buf.WriteString(strings.Repeat(indent, len(line)-len(trimmed)))
+		buf.Write(trimmed)
+	}
+	return buf.Bytes()
+}
+
+// injectorFileName returns the name of the file to which
+// GenerateOptions.OneFilePerInjector writes the injector named name, given
+// the name of the file generated for its package.
+func injectorFileName(outputFile, name string) string {
+	return "wire_gen_" + strings.ToLower(name) + strings.TrimPrefix(outputFile, "wire_gen")
+}
+
+// outputFileName returns the name of the file to generate for pkg.
+func outputFileName(pkg *packages.Package) string {
+	switch {
+	case !isTestVariant(pkg):
+		return "wire_gen.go"
+	case strings.HasSuffix(pkg.Name, "_test"):
+		return "wire_gen_external_test.go"
+	default:
+		return "wire_gen_test.go"
+	}
 }
 
 func detectOutputDir(paths []string) (string, error) {
@@ -135,14 +515,14 @@ func detectOutputDir(paths []string) (string, error) {
 }
 
 // generateInjectors generates the injectors for a given package.
-func generateInjectors(g *gen, pkg *packages.Package) (injectorFiles []*ast.File, _ []error) {
-	oc := newObjectCache([]*packages.Package{pkg})
-	injectorFiles = make([]*ast.File, 0, len(pkg.Syntax))
+func generateInjectors(g *gen, pkg *packages.Package, oc *objectCache) (injectorFiles []*ast.File, _ []error) {
+	syntax := injectorSyntax(pkg)
+	injectorFiles = make([]*ast.File, 0, len(syntax))
 	ec := new(errorCollector)
-	for _, f := range pkg.Syntax {
+	for _, f := range syntax {
 		for _, decl := range f.Decls {
 			fn, ok := decl.(*ast.FuncDecl)
-			if !ok {
+			if !ok || skipInjector(fn, pkg.Name, g.genMain) {
 				continue
 			}
 			buildCall, err := findInjectorBuild(pkg.TypesInfo, fn)
@@ -159,31 +539,30 @@ func generateInjectors(g *gen, pkg *packages.Package) (injectorFiles []*ast.File
 				name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
 				g.p("// Injectors from %s:\n\n", name)
 				injectorFiles = append(injectorFiles, f)
+			} else if g.fileImports != nil {
+				// Each injector starts a new generated file.
+				name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+				g.p("// Injectors from %s:\n\n", name)
 			}
-			sig := pkg.TypesInfo.ObjectOf(fn.Name).Type().(*types.Signature)
-			ins, _, err := injectorFuncSignature(sig)
-			if err != nil {
-				if w, ok := err.(*wireErr); ok {
-					ec.add(notePosition(w.position, fmt.Errorf("inject %s: %v", fn.Name.Name, w.error)))
-				} else {
-					ec.add(notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err)))
-				}
-				continue
-			}
-			injectorArgs := &InjectorArgs{
-				Name:  fn.Name.Name,
-				Tuple: ins,
-				Pos:   fn.Pos(),
-			}
-			set, errs := oc.processNewSet(pkg.TypesInfo, pkg.PkgPath, buildCall, injectorArgs, "")
+			inj, container, errs := g.parseInjector(oc, fn, buildCall)
 			if len(errs) > 0 {
-				ec.add(notePositionAll(g.pkg.Fset.Position(fn.Pos()), errs)...)
+				ec.add(errs...)
 				continue
 			}
-			if errs := g.inject(fn.Pos(), fn.Name.Name, sig, set, fn.Doc); len(errs) > 0 {
+			if errs := g.inject(fn.Pos(), inj.name, inj.sig, inj.set, injectOptions{
+				doc:       fn.Doc,
+				once:      inj.once,
+				panics:    inj.panics,
+				use:       inj.use,
+				container: container,
+			}); len(errs) > 0 {
 				ec.add(errs...)
 				continue
 			}
+			g.injectors = append(g.injectors, *inj)
+			if g.fileImports != nil {
+				g.cutFile(injectorFileName(outputFileName(pkg), fn.Name.Name))
+			}
 		}
 
 		for _, impt := range f.Imports {
@@ -195,523 +574,2672 @@ func generateInjectors(g *gen, pkg *packages.Package) (injectorFiles []*ast.File
 	if len(ec.errors) > 0 {
 		return nil, ec.errors
 	}
-	return injectorFiles, nil
-}
-
-// copyNonInjectorDecls copies any non-injector declarations from the
-// given files into the generated output.
-func copyNonInjectorDecls(g *gen, files []*ast.File, info *types.Info) {
-	for _, f := range files {
-		name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+	// Overrides and checks may name injectors from any file, so they are
+	// emitted once all injectors are known.
+	for _, f := range syntax {
 		first := true
 		for _, decl := range f.Decls {
-			switch decl := decl.(type) {
-			case *ast.FuncDecl:
-				// OK to ignore error, as any error cases should already have
-				// been filtered out.
-				if buildCall, _ := findInjectorBuild(info, decl); buildCall != nil {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				call := findOverride(pkg.TypesInfo, spec)
+				if call == nil {
 					continue
 				}
-			case *ast.GenDecl:
-				if decl.Tok == token.IMPORT {
+				if first || g.fileImports != nil {
+					name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+					g.p("// Overridden injectors from %s:\n\n", name)
+					first = false
+				}
+				injName, errs := g.injectOverride(pkg.TypesInfo, pkg.PkgPath, oc, call)
+				if len(errs) > 0 {
+					ec.add(errs...)
 					continue
 				}
-			default:
-				continue
-			}
-			if first {
-				g.p("// %s:\n\n", name)
-				first = false
+				if g.fileImports != nil {
+					g.cutFile(injectorFileName(outputFileName(pkg), injName))
+				}
 			}
-			// TODO(light): Add line number at top of each declaration.
-			g.writeAST(info, decl)
-			g.p("\n\n")
 		}
 	}
-}
-
-// importInfo holds info about an import.
-type importInfo struct {
-	// name is the identifier that is used in the generated source.
-	name string
-	// differs is true if the import is given an identifier that does not
-	// match the package's identifier.
-	differs bool
-}
-
-// gen is the file-wide generator state.
-type gen struct {
-	pkg         *packages.Package
-	buf         bytes.Buffer
-	imports     map[string]importInfo
-	anonImports map[string]bool
-	values      map[ast.Expr]string
-}
-
-func newGen(pkg *packages.Package) *gen {
-	return &gen{
-		pkg:         pkg,
-		anonImports: make(map[string]bool),
-		imports:     make(map[string]importInfo),
-		values:      make(map[ast.Expr]string),
-	}
-}
-
-// frame bakes the built up source body into an unformatted Go source file.
-func (g *gen) frame(tags string) []byte {
-	if g.buf.Len() == 0 {
-		return nil
-	}
-	var buf bytes.Buffer
-	if len(tags) > 0 {
-		tags = fmt.Sprintf(" gen -tags \"%s\"", tags)
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
 	}
-	buf.WriteString("// Code generated by Wire. DO NOT EDIT.\n\n")
-	buf.WriteString("//go:generate go run -mod=mod github.com/google/wire/cmd/wire" + tags + "\n")
-	buf.WriteString("//+build !wireinject\n\n")
-	buf.WriteString("package ")
-	buf.WriteString(g.pkg.Name)
-	buf.WriteString("\n\n")
-	if len(g.imports) > 0 {
-		buf.WriteString("import (\n")
-		imps := make([]string, 0, len(g.imports))
-		for path := range g.imports {
-			imps = append(imps, path)
-		}
-		sort.Strings(imps)
-		for _, path := range imps {
-			// Omit the local package identifier if it matches the package name.
-			info := g.imports[path]
-			if info.differs {
-				fmt.Fprintf(&buf, "\t%s %q\n", info.name, path)
-			} else {
-				fmt.Fprintf(&buf, "\t%q\n", path)
+	for _, f := range syntax {
+		first := true
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				call := findTestKit(pkg.TypesInfo, spec)
+				if call == nil {
+					continue
+				}
+				name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+				if !strings.HasSuffix(name, "_test.go") {
+					ec.add(notePosition(g.pkg.Fset.Position(call.Pos()), errors.New("wire.TestKit may only be used in _test.go files")))
+					continue
+				}
+				if first || g.fileImports != nil {
+					g.p("// Test kits from %s:\n\n", name)
+					first = false
+				}
+				kitName, errs := g.injectTestKit(pkg.TypesInfo, oc, call)
+				if len(errs) > 0 {
+					ec.add(errs...)
+					continue
+				}
+				if g.fileImports != nil {
+					g.cutFile(injectorFileName(outputFileName(pkg), kitName))
+				}
 			}
 		}
-		buf.WriteString(")\n\n")
 	}
-	if len(g.anonImports) > 0 {
-		buf.WriteString("import (\n")
-		anonImps := make([]string, 0, len(g.anonImports))
-		for path := range g.anonImports {
-			anonImps = append(anonImps, path)
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	for _, f := range syntax {
+		first := true
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				call := findCheck(pkg.TypesInfo, spec)
+				if call == nil {
+					continue
+				}
+				iface, out, err := g.checkTypes(pkg.TypesInfo, call)
+				if err != nil {
+					ec.add(notePosition(g.pkg.Fset.Position(call.Pos()), err))
+					continue
+				}
+				if first {
+					name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+					g.p("// Interface checks from %s:\n\n", name)
+					first = false
+				}
+				g.p("var _ %s = %s\n", types.TypeString(iface, g.qualifyPkg), typedZeroValue(out, g.qualifyPkg))
+			}
 		}
-		sort.Strings(anonImps)
-
-		for _, path := range anonImps {
-			fmt.Fprintf(&buf, "\t_ %s\n", path)
+		if !first {
+			g.p("\n")
 		}
-		buf.WriteString(")\n\n")
 	}
-	buf.Write(g.buf.Bytes())
-	return buf.Bytes()
+	if len(ec.errors) > 0 {
+		return nil, ec.errors
+	}
+	return injectorFiles, nil
 }
 
-// inject emits the code for an injector.
-func (g *gen) inject(pos token.Pos, name string, sig *types.Signature, set *ProviderSet, doc *ast.CommentGroup) []error {
-	injectSig, err := funcOutput(sig)
+// parseInjector parses the injector template fn, whose wire.Build call is
+// buildCall, and returns the injector along with the name given by its
+// //wire:container directive, if any.
+func (g *gen) parseInjector(oc *objectCache, fn *ast.FuncDecl, buildCall *ast.CallExpr) (*generatedInjector, string, []error) {
+	pkg := g.pkg
+	sig, err := buildInputs(pkg.TypesInfo, pkg.TypesInfo.ObjectOf(fn.Name).Type().(*types.Signature), buildCall)
 	if err != nil {
-		return []error{notePosition(g.pkg.Fset.Position(pos),
-			fmt.Errorf("inject %s: %v", name, err))}
+		return nil, "", []error{notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err))}
+	}
+	use := isUse(pkg.TypesInfo, buildCall)
+	ins, out, err := injectorFuncSignature(sig, use)
+	once := isBuildOnce(pkg.TypesInfo, fn, buildCall)
+	if err == nil && once {
+		err = verifyBuildOnce(ins, out, use)
+	}
+	panics := hasDirective(fn.Doc, "panics")
+	if err == nil && panics && out.err {
+		err = errors.New("injectors marked //wire:panics may not return an error")
+	}
+	if err != nil {
+		err = &SignatureError{Func: fn.Name.Name, Injector: true, Err: err}
+		return nil, "", []error{notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, err))}
+	}
+	injectorArgs := &InjectorArgs{
+		Name:  fn.Name.Name,
+		Tuple: ins,
+		Pos:   fn.Pos(),
 	}
-	params := sig.Params()
-	calls, errs := solve(g.pkg.Fset, injectSig.out, params, set)
+	set, errs := oc.processNewSet(pkg.TypesInfo, pkg.PkgPath, buildCall, injectorArgs, "")
 	if len(errs) > 0 {
-		return mapErrors(errs, func(e error) error {
+		return nil, "", notePositionAll(g.pkg.Fset.Position(fn.Pos()), errs)
+	}
+	strategy, err := strategyDirective(fn.Doc)
+	if err != nil {
+		return nil, "", []error{notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err))}
+	}
+	set, errs = selectStrategy(g.pkg.Fset, oc.hasher, set, strategy)
+	if len(errs) > 0 {
+		return nil, "", mapErrors(errs, func(e error) error {
 			if w, ok := e.(*wireErr); ok {
-				return notePosition(w.position, fmt.Errorf("inject %s: %v", name, w.error))
+				return notePosition(w.position, fmt.Errorf("inject %s: %w", fn.Name.Name, w.error))
 			}
-			return notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %v", name, e))
+			return notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, e))
 		})
 	}
-	type pendingVar struct {
-		name     string
-		expr     ast.Expr
-		typeInfo *types.Info
+	if hasDirective(fn.Doc, "assignable") {
+		set, errs = allowAssignable(oc.hasher, set, out.out)
+		if len(errs) > 0 {
+			return nil, "", mapErrors(errs, func(e error) error {
+				if w, ok := e.(*wireErr); ok {
+					return notePosition(w.position, fmt.Errorf("inject %s: %w", fn.Name.Name, w.error))
+				}
+				return notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %w", fn.Name.Name, e))
+			})
+		}
+	}
+	if g.autoDeref {
+		set = derefPointers(oc.hasher, set, out.out)
+	}
+	ambient, err := ambientDirective(fn.Doc)
+	if err != nil {
+		return nil, "", []error{notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err))}
+	}
+	if errs := verifyAmbient(ambient, ins, out.out, set); len(errs) > 0 {
+		return nil, "", mapErrors(errs, func(e error) error {
+			return notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, e))
+		})
+	}
+	container, err := containerDirective(fn.Doc)
+	if err != nil {
+		return nil, "", []error{notePosition(g.pkg.Fset.Position(fn.Pos()), fmt.Errorf("inject %s: %v", fn.Name.Name, err))}
+	}
+	return &generatedInjector{
+		name:     fn.Name.Name,
+		sig:      sig,
+		set:      set,
+		strategy: strategy,
+		once:     once,
+		panics:   panics,
+		use:      use,
+	}, container, nil
+}
+
+// checkTypes returns the interface and the injector result type named by a
+// wire.Check call, and verifies that the result implements the interface.
+func (g *gen) checkTypes(info *types.Info, call *ast.CallExpr) (iface, out types.Type, err error) {
+	ifacePtr, ok := info.TypeOf(call.Args[0]).(*types.Pointer)
+	if !ok || !types.IsInterface(ifacePtr.Elem()) {
+		return nil, nil, fmt.Errorf("first argument to Check must be a pointer to an interface type; found %s", types.TypeString(info.TypeOf(call.Args[0]), nil))
+	}
+	iface = ifacePtr.Elem()
+	inj := g.findInjector(info, call.Args[1])
+	if inj == nil {
+		return nil, nil, fmt.Errorf("second argument to Check must be an injector function in this package; found %s", types.ExprString(call.Args[1]))
+	}
+	result, err := funcOutput(inj.sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	out = result.out
+	if !types.Implements(out, iface.Underlying().(*types.Interface)) {
+		m, _ := types.MissingMethod(out, iface.Underlying().(*types.Interface), true)
+		return nil, nil, fmt.Errorf("injector %s returns %s, which does not implement %s (missing method %s)", inj.name, types.TypeString(out, nil), types.TypeString(iface, nil), m.Name())
+	}
+	return iface, out, nil
+}
+
+// findInjector returns the injector generated for the function that expr
+// names, or nil if expr does not name an injector in this package.
+func (g *gen) findInjector(info *types.Info, expr ast.Expr) *generatedInjector {
+	fn, ok := qualifiedIdentObject(info, expr).(*types.Func)
+	if !ok || fn.Pkg() != g.pkg.Types {
+		return nil
+	}
+	for i := range g.injectors {
+		if g.injectors[i].name == fn.Name() {
+			return &g.injectors[i]
+		}
+	}
+	return nil
+}
+
+// injectOverride emits the injector declared by a wire.Override call and
+// returns its name. The injector has the signature of the overridden
+// injector, and is built from its provider set with the entries of the
+// override set taking precedence.
+func (g *gen) injectOverride(info *types.Info, pkgPath string, oc *objectCache, call *ast.CallExpr) (string, []error) {
+	pos := g.pkg.Fset.Position(call.Pos())
+	inj := g.findInjector(info, call.Args[0])
+	if inj == nil {
+		return "", []error{notePosition(pos,
+			fmt.Errorf("first argument to Override must be an injector function in this package; found %s", types.ExprString(call.Args[0])))}
+	}
+	item, errs := oc.processExpr(info, pkgPath, call.Args[1], "")
+	if len(errs) > 0 {
+		return "", notePositionAll(pos, errs)
+	}
+	overrides, ok := item.(*ProviderSet)
+	if !ok {
+		return "", []error{notePosition(pos, errors.New("second argument to Override must be a provider set"))}
+	}
+	name := "Test" + strings.ToUpper(inj.name[:1]) + inj.name[1:]
+	if g.pkg.Types.Scope().Lookup(name) != nil {
+		return "", []error{notePosition(pos,
+			fmt.Errorf("wire.Override of %s generates %s, which is already declared in this package", inj.name, name))}
+	}
+	set := &ProviderSet{
+		Pos:       call.Pos(),
+		PkgPath:   pkgPath,
+		Imports:   []*ProviderSet{inj.set, overrides},
+		overrides: true,
+	}
+	set.providerMap, set.srcMap, errs = buildProviderMap(g.pkg.Fset, oc.hasher, set)
+	if len(errs) > 0 {
+		return "", notePositionAll(pos, errs)
+	}
+	set.wrapMap, set.wrapSrcMap, errs = buildWrapMap(g.pkg.Fset, oc.hasher, set)
+	if len(errs) > 0 {
+		return "", notePositionAll(pos, errs)
+	}
+	set.strategyMap, errs = buildStrategyMap(g.pkg.Fset, oc.hasher, set)
+	if len(errs) > 0 {
+		return "", notePositionAll(pos, errs)
+	}
+	if errs := verifyAcyclic(set.providerMap, oc.hasher); len(errs) > 0 {
+		return "", notePositionAll(pos, errs)
+	}
+	// The injector's strategy selects among the alternatives that the
+	// replacements add.
+	if set.strategyMap != nil {
+		set, errs = selectStrategy(g.pkg.Fset, oc.hasher, set, inj.strategy)
+		if len(errs) > 0 {
+			return "", notePositionAll(pos, errs)
+		}
+	}
+	doc := &ast.CommentGroup{List: []*ast.Comment{{
+		Text: fmt.Sprintf("// %s is %s with the providers passed to wire.Override.", name, inj.name),
+	}}}
+	if errs := g.inject(call.Pos(), name, inj.sig, set, injectOptions{doc: doc, once: inj.once, panics: inj.panics, use: inj.use}); len(errs) > 0 {
+		return "", errs
+	}
+	return name, nil
+}
+
+// injectTestKit emits the test kit declared by a wire.TestKit call and
+// returns the name of its type. The injector it names may be declared in
+// any file of the package, not only in its test files.
+func (g *gen) injectTestKit(info *types.Info, oc *objectCache, call *ast.CallExpr) (string, []error) {
+	pos := g.pkg.Fset.Position(call.Pos())
+	if len(call.Args) == 0 {
+		return "", []error{notePosition(pos, errors.New("call to TestKit takes at least one argument"))}
+	}
+	inj := g.findInjector(info, call.Args[0])
+	if inj == nil {
+		var errs []error
+		inj, errs = g.packageInjector(info, oc, call.Args[0])
+		if len(errs) > 0 {
+			return "", errs
+		}
+		if inj == nil {
+			return "", []error{notePosition(pos,
+				fmt.Errorf("first argument to TestKit must be an injector function in this package; found %s", types.ExprString(call.Args[0])))}
+		}
+	}
+	injectSig, err := injectorOutput(inj.sig, inj.use)
+	if err != nil {
+		return "", []error{notePosition(pos, err)}
+	}
+	_, deferred := deferredOutput(inj.set, injectSig)
+	switch {
+	case inj.once || inj.use || deferred:
+		return "", []error{notePosition(pos,
+			fmt.Errorf("wire.TestKit can't be used with %s, which uses wire.BuildOnce or wire.Use, is marked //wire:lazy, or returns a function", inj.name))}
+	case typeParamCount(inj.sig) > 0:
+		return "", []error{notePosition(pos, fmt.Errorf("wire.TestKit can't be used with generic injector %s", inj.name))}
+	}
+	kit := &testKit{name: export(inj.name) + "TestKit"}
+	if g.nameInFileScope(kit.name) {
+		return "", []error{notePosition(pos,
+			fmt.Errorf("wire.TestKit of %s generates %s, which is already declared in this package", inj.name, kit.name))}
+	}
+	ec := new(errorCollector)
+	for _, arg := range call.Args[1:] {
+		ptr, ok := info.TypeOf(arg).(*types.Pointer)
+		if !ok {
+			ec.add(notePosition(pos, fmt.Errorf("arguments to TestKit after the injector must be pointers to types; found %s", types.TypeString(info.TypeOf(arg), nil))))
+			continue
+		}
+		t := ptr.Elem()
+		if !hasNil(t) {
+			ec.add(notePosition(pos, fmt.Errorf("wire.TestKit can't override %s, which has no nil value", types.TypeString(t, nil))))
+			continue
+		}
+		for _, f := range kit.fields {
+			if types.Identical(f.typ, t) {
+				ec.add(notePosition(pos, fmt.Errorf("multiple arguments to TestKit for %s", types.TypeString(t, nil))))
+			}
+		}
+		kit.fields = append(kit.fields, testKitField{
+			name: disambiguate(testKitFieldName(t), kit.fieldDeclared),
+			typ:  t,
+		})
+	}
+	if len(ec.errors) > 0 {
+		return "", ec.errors
+	}
+	if errs := g.inject(call.Pos(), inj.name, inj.sig, inj.set, injectOptions{panics: inj.panics, kit: kit}); len(errs) > 0 {
+		return "", errs
+	}
+	g.declared[kit.name] = true
+	return kit.name, nil
+}
+
+// packageInjector returns the injector named by expr if it is declared in a
+// file of the package that g does not generate injectors for, such as a
+// non-test file of a test variant, or nil if expr names no injector.
+func (g *gen) packageInjector(info *types.Info, oc *objectCache, expr ast.Expr) (*generatedInjector, []error) {
+	fn, ok := qualifiedIdentObject(info, expr).(*types.Func)
+	if !ok || fn.Pkg() != g.pkg.Types {
+		return nil, nil
+	}
+	for _, f := range g.pkg.Syntax {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || info.Defs[decl.Name] != fn {
+				continue
+			}
+			buildCall, err := findInjectorBuild(info, decl)
+			if err != nil || buildCall == nil {
+				return nil, nil
+			}
+			inj, _, errs := g.parseInjector(oc, decl, buildCall)
+			return inj, errs
+		}
+	}
+	return nil, nil
+}
+
+// testKit describes the test kit type generated for a wire.TestKit call.
+type testKit struct {
+	// name is the name of the kit type.
+	name string
+	// fields holds a field for each type that the kit can override, in the
+	// order the types were passed to wire.TestKit.
+	fields []testKitField
+}
+
+// testKitField is a field of a test kit. If the field is set, its value is
+// used in place of the result of the provider of its type.
+type testKitField struct {
+	name string
+	typ  types.Type
+	// call is the provider call whose result the field replaces, and
+	// method is the name of the kit's method that makes the call unless
+	// the field is set. They are filled in by gen.inject.
+	call   *call
+	method string
+}
+
+// fieldDeclared reports whether kit already has a field or method named
+// name.
+func (kit *testKit) fieldDeclared(name string) bool {
+	if name == "Build" {
+		return true
+	}
+	for _, f := range kit.fields {
+		if f.name == name || f.method == name {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns the field of kit that replaces the result of c, or nil if
+// none does.
+func (kit *testKit) field(c *call) *testKitField {
+	if kit == nil {
+		return nil
+	}
+	for i := range kit.fields {
+		if kit.fields[i].call == c {
+			return &kit.fields[i]
+		}
+	}
+	return nil
+}
+
+// testKitFieldName returns the name of the test kit field for values of
+// type t, such as DB for *DB.
+func testKitFieldName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return export(n.Obj().Name())
+	}
+	return export(typeVariableName(t, "Value", unexport, func(string) bool { return false }))
+}
+
+// hasNil reports whether nil is a value of type t.
+func hasNil(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Chan, *types.Interface, *types.Map, *types.Pointer, *types.Signature, *types.Slice:
+		return !isTypeParam(t)
+	default:
+		return false
+	}
+}
+
+// skipInjector reports whether fn is never treated as an injector, even if
+// it calls wire.Build: init functions, and the main function of package
+// main unless genMain is set.
+func skipInjector(fn *ast.FuncDecl, pkgName string, genMain bool) bool {
+	if fn.Recv != nil {
+		return false
+	}
+	switch fn.Name.Name {
+	case "init":
+		return true
+	case "main":
+		return pkgName == "main" && !genMain
+	default:
+		return false
+	}
+}
+
+// copyNonInjectorDecls copies any non-injector declarations from the
+// given files into the generated output.
+func copyNonInjectorDecls(g *gen, files []*ast.File, info *types.Info) {
+	for _, f := range files {
+		name := filepath.Base(g.pkg.Fset.File(f.Pos()).Name())
+		first := true
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				// OK to ignore error, as any error cases should already have
+				// been filtered out.
+				if buildCall, _ := findInjectorBuild(info, decl); buildCall != nil {
+					continue
+				}
+			case *ast.GenDecl:
+				if decl.Tok == token.IMPORT {
+					continue
+				}
+				// Replace checks with the assertions generated for them,
+				// and overrides and test kits with the code generated for
+				// them.
+				specs := make([]ast.Spec, 0, len(decl.Specs))
+				for _, spec := range decl.Specs {
+					if findCheck(info, spec) == nil && findOverride(info, spec) == nil && findTestKit(info, spec) == nil {
+						specs = append(specs, spec)
+					}
+				}
+				if len(specs) == 0 {
+					continue
+				}
+				if len(specs) < len(decl.Specs) {
+					d := *decl
+					d.Specs = specs
+					decl = &d
+				}
+			default:
+				continue
+			}
+			if first {
+				g.p("// %s:\n\n", name)
+				first = false
+			}
+			// TODO(light): Add line number at top of each declaration.
+			g.writeAST(info, decl)
+			g.p("\n\n")
+		}
+	}
+}
+
+// importInfo holds info about an import.
+type importInfo struct {
+	// name is the identifier that is used in the generated source.
+	name string
+	// differs is true if the import is given an identifier that does not
+	// match the package's identifier.
+	differs bool
+}
+
+// generatedInjector identifies an injector in the generated file.
+type generatedInjector struct {
+	name string
+	sig  *types.Signature
+	// set, once, panics and use are passed to gen.inject for the injector,
+	// and strategy is the name of its //wire:strategy directive, so that
+	// wire.Override can generate a variant of it.
+	set      *ProviderSet
+	strategy string
+	once     bool
+	panics   bool
+	use      bool
+}
+
+// singletonAccessor holds the generated names for a //wire:singleton
+// provider. The accessor function guards a single call to the provider with
+// a package-level sync.Once.
+type singletonAccessor struct {
+	// name is the name of the accessor function.
+	name string
+	// once, value, and err are the names of the package-level variables
+	// holding the sync.Once and the cached results.
+	once  string
+	value string
+	err   string
+	// hasErr is true if the accessor returns an error, either from the
+	// provider itself or from one of the singletons it depends on.
+	hasErr bool
+	// out is the type the accessor returns.
+	out types.Type
+}
+
+// gen is the file-wide generator state.
+type gen struct {
+	pkg         *packages.Package
+	buf         bytes.Buffer
+	imports     map[string]importInfo
+	anonImports map[string]bool
+	values      map[ast.Expr]string
+	singletons  map[string]*singletonAccessor // keyed by qualified provider name
+	// implements holds the names of the struct types generated for
+	// wire.Implement calls, keyed by implementKey.
+	implements map[string]string
+	// declared holds the names of other package-level declarations in the
+	// generated file.
+	declared map[string]bool
+	// injectors holds the injectors generated so far.
+	injectors []generatedInjector
+	// goMinor is the minor version of the oldest Go 1.x release the
+	// generated code must compile with, or 0 if there is no limit.
+	goMinor int
+	// wrapErrors is true if errors from providers are wrapped with the
+	// provider's name.
+	wrapErrors bool
+	// structuredErrors is true if errors from providers are returned as
+	// *wire.ProviderError values.
+	structuredErrors bool
+	// instrument is true if provider calls are traced with
+	// wire.TraceProvider.
+	instrument bool
+	// otelTrace is true if provider calls are wrapped in OpenTelemetry
+	// spans.
+	otelTrace bool
+	// visitCall returns the code to write around each provider call, or is
+	// nil.
+	visitCall func(CallInfo) (before, after string)
+	// groupImports is true if standard library imports are written in a
+	// separate group.
+	groupImports bool
+	// nolint is the //nolint directive to write before the package clause,
+	// or empty.
+	nolint string
+	// importAliases is the value of GenerateOptions.ImportAliases.
+	importAliases map[string]string
+	// autoDeref is the value of GenerateOptions.AutoDeref.
+	autoDeref bool
+	// inputsHash is true if generated files record a hash of their inputs.
+	inputsHash bool
+	// inputs describes the injectors and provider calls generated since the
+	// last file was cut, if inputsHash is true.
+	inputs []string
+	// genMain is true if GenerateOptions.MainFunc is set.
+	genMain bool
+	// target is the build context of the generated code, against which the
+	// //wire:build constraints of providers are checked.
+	target *build.Context
+
+	// fileImports is non-nil if GenerateOptions.OneFilePerInjector is set,
+	// and then holds the paths of the imports used by the code in buf.
+	fileImports map[string]bool
+	// files holds the files cut from buf for each injector when
+	// GenerateOptions.OneFilePerInjector is set.
+	files []generatedFile
+	// tags is the value of GenerateOptions.Tags, used to frame files.
+	tags string
+}
+
+// generatedFile is an injector's file generated for
+// GenerateOptions.OneFilePerInjector.
+type generatedFile struct {
+	// name is the file's base name, without GenerateOptions.PrefixOutputFile.
+	name string
+	// src is the unformatted source of the file.
+	src []byte
+}
+
+func newGen(pkg *packages.Package) *gen {
+	return &gen{
+		pkg:         pkg,
+		anonImports: make(map[string]bool),
+		imports:     make(map[string]importInfo),
+		values:      make(map[ast.Expr]string),
+		singletons:  make(map[string]*singletonAccessor),
+		implements:  make(map[string]string),
+		declared:    make(map[string]bool),
+	}
+}
+
+// frame bakes the built up source body into an unformatted Go source file.
+// If injectors have been cut into files of their own, the file is generated
+// even without a body, to hold the //go:generate directive.
+func (g *gen) frame(tags string) []byte {
+	if g.buf.Len() == 0 && len(g.files) == 0 {
+		return nil
+	}
+	return g.frameFile(tags, true)
+}
+
+// cutFile moves the code generated since the last call into a file of its
+// own, named name.
+func (g *gen) cutFile(name string) {
+	name = disambiguate(strings.TrimSuffix(name, ".go"), func(n string) bool {
+		for _, f := range g.files {
+			if f.name == n+".go" {
+				return true
+			}
+		}
+		return false
+	}) + ".go"
+	g.files = append(g.files, generatedFile{name: name, src: g.frameFile(g.tags, false)})
+	g.buf.Reset()
+	g.fileImports = make(map[string]bool)
+	g.inputs = nil
+}
+
+// frameFile is like frame, but always generates a file. The //go:generate
+// directive and the anonymous imports are only written if main is true.
+func (g *gen) frameFile(tags string, main bool) []byte {
+	var buf bytes.Buffer
+	if len(tags) > 0 {
+		tags = fmt.Sprintf(" gen -tags \"%s\"", tags)
+	}
+	buf.WriteString("// Code generated by Wire. DO NOT EDIT.\n\n")
+	if g.inputsHash {
+		buf.WriteString(inputsHashPrefix + hashInputs(g.inputs) + "\n\n")
+	}
+	if main {
+		buf.WriteString("//go:generate go run -mod=mod github.com/google/wire/cmd/wire" + tags + "\n")
+	}
+	buf.WriteString("//+build !wireinject\n\n")
+	if g.nolint != "" {
+		buf.WriteString(g.nolint + "\n")
+	}
+	buf.WriteString("package ")
+	buf.WriteString(g.pkg.Name)
+	buf.WriteString("\n\n")
+	imps := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		if g.fileImports == nil || g.fileImports[path] {
+			imps = append(imps, path)
+		}
+	}
+	if len(imps) > 0 {
+		buf.WriteString("import (\n")
+		sort.Strings(imps)
+		if g.groupImports {
+			// Stable sort keeps each group in path order.
+			sort.SliceStable(imps, func(i, j int) bool {
+				return isStdImport(imps[i]) && !isStdImport(imps[j])
+			})
+		}
+		for i, path := range imps {
+			if g.groupImports && i > 0 && isStdImport(imps[i-1]) && !isStdImport(path) {
+				buf.WriteString("\n")
+			}
+			// Omit the local package identifier if it matches the package name.
+			info := g.imports[path]
+			if info.differs {
+				fmt.Fprintf(&buf, "\t%s %q\n", info.name, path)
+			} else {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+	if main && len(g.anonImports) > 0 {
+		buf.WriteString("import (\n")
+		anonImps := make([]string, 0, len(g.anonImports))
+		for path := range g.anonImports {
+			anonImps = append(anonImps, path)
+		}
+		sort.Strings(anonImps)
+
+		for _, path := range anonImps {
+			fmt.Fprintf(&buf, "\t_ %s\n", path)
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(g.buf.Bytes())
+	return buf.Bytes()
+}
+
+// inputsHashPrefix starts the comment in which generated files record the
+// hash of their inputs when GenerateOptions.InputsHash is set.
+const inputsHashPrefix = "// wire-inputs-hash: "
+
+// hashInputs returns the hex-encoded SHA-256 hash of the sorted, distinct
+// inputs.
+func hashInputs(inputs []string) string {
+	sorted := append([]string(nil), inputs...)
+	sort.Strings(sorted)
+	h := sha256.New()
+	for i, in := range sorted {
+		if i > 0 && in == sorted[i-1] {
+			continue
+		}
+		io.WriteString(h, in)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// callInput describes c for GenerateOptions.InputsHash: the provider,
+// value or field it uses, with the types it takes and produces.
+func callInput(c *call) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d ", c.kind)
+	if c.pkg != nil {
+		sb.WriteString(c.pkg.Path() + ".")
+	}
+	sb.WriteString(c.name)
+	sb.WriteString("(")
+	for i, t := range c.ins {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(types.TypeString(t, nil))
+	}
+	fmt.Fprintf(&sb, ") %s", types.TypeString(c.out, nil))
+	if len(c.fieldNames) > 0 {
+		fmt.Fprintf(&sb, " fields %s", strings.Join(c.fieldNames, ","))
+	}
+	if c.hasCleanup {
+		sb.WriteString(" cleanup")
+	}
+	if c.hasErr {
+		sb.WriteString(" error")
+	}
+	return sb.String()
+}
+
+// checkImportAliases reports an error if any name in aliases, the value of
+// GenerateOptions.ImportAliases, can't name an import.
+func checkImportAliases(aliases map[string]string) error {
+	paths := make([]string, 0, len(aliases))
+	for path := range aliases {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := aliases[path]
+		if path == "" {
+			return fmt.Errorf("import alias %q is for an empty import path", name)
+		}
+		if !token.IsIdentifier(name) || name == "_" {
+			return fmt.Errorf("import alias %q for %s is not a valid identifier", name, path)
+		}
+	}
+	return nil
+}
+
+// nolintDirective returns the //nolint directive for the comma-separated
+// list of linters in GenerateOptions.Nolint, or the empty string if the list
+// is empty.
+func nolintDirective(linters string) (string, error) {
+	names := strings.FieldsFunc(linters, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(names) == 0 {
+		return "", nil
+	}
+	for _, name := range names {
+		for _, r := range name {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				return "", fmt.Errorf("invalid linter name %q in nolint option", name)
+			}
+		}
+	}
+	return "//nolint:" + strings.Join(names, ","), nil
+}
+
+// isStdImport reports whether path appears to be a standard library import
+// path, that is, whether its first element does not contain a dot.
+func isStdImport(path string) bool {
+	first := path
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		first = path[:i]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// injectOptions describes how gen.inject generates an injector, besides
+// its name, signature and provider set.
+type injectOptions struct {
+	// doc is the doc comment of the generated function, or nil.
+	doc *ast.CommentGroup
+	// once is true if the injector was declared with wire.BuildOnce or
+	// marked //wire:lazy: the generated function returns the cached result
+	// of a separate injector function.
+	once bool
+	// panics is true if the injector was marked //wire:panics and panics
+	// when a provider fails.
+	panics bool
+	// use is true if the injector calls wire.Use, so that it has no result.
+	use bool
+	// container is the name of the //wire:container type that the injector
+	// builds, or empty.
+	container string
+	// kit is the test kit whose Build method is generated instead of an
+	// injector function, or nil.
+	kit *testKit
+}
+
+// inject emits the code for an injector.
+func (g *gen) inject(pos token.Pos, name string, sig *types.Signature, set *ProviderSet, opts injectOptions) []error {
+	injectSig, err := injectorOutput(sig, opts.use)
+	if err != nil {
+		err = &SignatureError{Func: name, Injector: true, Err: err}
+		return []error{notePosition(g.pkg.Fset.Position(pos),
+			fmt.Errorf("inject %s: %w", name, err))}
+	}
+	generic := false
+	switch n := typeParamCount(sig); {
+	case n > 1:
+		err := &SignatureError{Func: name, Injector: true, Err: fmt.Errorf("injector has %d type parameters; only one is supported", n)}
+		return []error{notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %w", name, err))}
+	case n == 1 && opts.once:
+		err := &SignatureError{Func: name, Injector: true, Err: errors.New("generic injector may not use wire.BuildOnce or be marked //wire:lazy")}
+		return []error{notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %w", name, err))}
+	case n == 1:
+		generic = true
+	}
+	if opts.container != "" {
+		err := errors.New("//wire:container can't be used with wire.Use")
+		if !opts.use {
+			err = g.verifyContainer(opts.container, sig, set, opts.once)
+		}
+		if err != nil {
+			return []error{notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %v", name, err))}
+		}
+	}
+	params := injectorInputs(sig)
+	resultSig := injectSig
+	if inner, ok := deferredOutput(set, injectSig); ok {
+		resultSig = inner
+	}
+	target := resultSig.out
+	if iface := addressedInterface(set, target); iface != nil {
+		target = iface
+	}
+	calls, errs := solve(g.pkg.Fset, target, params, set)
+	if len(errs) > 0 {
+		return mapErrors(errs, func(e error) error {
+			if w, ok := e.(*wireErr); ok {
+				return notePosition(w.position, fmt.Errorf("inject %s: %w", name, w.error))
+			}
+			return notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %w", name, e))
+		})
+	}
+	if errs := g.checkGoVersion(name, sig, calls, opts.kit != nil); len(errs) > 0 {
+		return notePositionAll(g.pkg.Fset.Position(pos), errs)
+	}
+	if opts.kit != nil {
+		if errs := opts.kit.resolve(name, calls); len(errs) > 0 {
+			return notePositionAll(g.pkg.Fset.Position(pos), errs)
+		}
+	}
+	if g.otelTrace && contextParam(params) < 0 && tracedCalls(calls) {
+		err := &SignatureError{
+			Func:     name,
+			Injector: true,
+			Err:      errors.New("tracing provider calls with OpenTelemetry requires a context.Context parameter"),
+		}
+		return []error{notePosition(g.pkg.Fset.Position(pos), fmt.Errorf("inject %s: %w", name, err))}
+	}
+	type pendingVar struct {
+		name     string
+		expr     ast.Expr
+		typeInfo *types.Info
+	}
+	var pendingVars []pendingVar
+	type pendingSingleton struct {
+		s           *singletonAccessor
+		c           *call
+		providerErr bool
+		deps        []*singletonAccessor
+	}
+	var pendingSingletons []pendingSingleton
+	var pendingImplements []*call
+	ec := new(errorCollector)
+	for i := range calls {
+		c := &calls[i]
+		if c.singleton && generic {
+			// Singletons are held in package-level variables, which can't
+			// depend on the injector's type parameter.
+			ec.add(notePosition(
+				g.pkg.Fset.Position(pos),
+				fmt.Errorf("inject %s: generic injector may not use singleton provider %s", name, providerLabel(c))))
+			continue
+		}
+		if c.singleton {
+			key := singletonKey(c)
+			s := g.singletons[key]
+			if s == nil {
+				ps := pendingSingleton{s: g.newSingletonAccessor(c.out), c: c, providerErr: c.hasErr}
+				ps.s.hasErr = c.hasErr
+				for _, a := range c.args {
+					// solve guarantees that singletons only depend on other
+					// singletons, which have already been visited.
+					dep := g.singletons[singletonKey(&calls[a-params.Len()])]
+					ps.deps = append(ps.deps, dep)
+					if dep.hasErr {
+						ps.s.hasErr = true
+					}
+				}
+				s = ps.s
+				g.singletons[key] = s
+				pendingSingletons = append(pendingSingletons, ps)
+			}
+			// The injector calls the accessor, which fails if any
+			// singleton in its chain fails.
+			c.hasErr = s.hasErr
+		}
+		if (c.hasCleanup || c.closes) && !resultSig.cleanup {
+			ts := types.TypeString(c.out, nil)
+			ec.add(notePosition(
+				g.pkg.Fset.Position(pos),
+				fmt.Errorf("inject %s: %w", name, &SignatureError{
+					Func:     name,
+					Injector: true,
+					Err:      fmt.Errorf("provider for %s returns cleanup but injection does not return cleanup function", ts),
+				})))
+		}
+		if c.hasErr && !c.lazy && !resultSig.err && !opts.panics {
+			ts := types.TypeString(c.out, nil)
+			err := fmt.Errorf("provider for %s returns error but injection not allowed to fail", ts)
+			switch c.kind {
+			case typeAssertExpr:
+				err = fmt.Errorf("type assertion to %s may fail but injection not allowed to fail", ts)
+			case structProvider:
+				err = fmt.Errorf("struct provider for %s reads config keys and may fail but injection not allowed to fail", ts)
+			case interfaceSelect:
+				err = fmt.Errorf("selected implementation of %s is checked at run time but injection not allowed to fail", ts)
+			}
+			if c.instrument != nil && !c.fnErr {
+				err = fmt.Errorf("provider for %s is called through wire.Copy wrapper %s, which may fail, but injection not allowed to fail", ts, c.instrument.Name())
+			}
+			if c.wrapper {
+				err = fmt.Errorf("wrapper %s of %s returns error but injection not allowed to fail", c.name, ts)
+			}
+			ec.add(notePosition(
+				g.pkg.Fset.Position(pos),
+				fmt.Errorf("inject %s: %w", name, &SignatureError{
+					Func:     name,
+					Injector: true,
+					Err:      err,
+				})))
+		}
+		if c.constraint != "" && g.target != nil {
+			if ok, _ := satisfiesConstraint(g.target, c.constraint); !ok {
+				ec.add(notePosition(
+					g.pkg.Fset.Position(pos),
+					fmt.Errorf("inject %s: provider %s is constrained by //wire:build %s, which GOOS=%s GOARCH=%s does not satisfy", name, providerLabel(c), c.constraint, g.target.GOOS, g.target.GOARCH)))
+			}
+		}
+		if err := callAccessibleFrom(c, g.pkg.PkgPath); err != nil {
+			ec.add(notePosition(
+				g.pkg.Fset.Position(pos),
+				fmt.Errorf("inject %s: %v", name, err)))
+		}
+		if c.kind == implementStruct && g.implements[implementKey(c)] == "" {
+			name := typeVariableName(c.out, "", func(name string) string { return "_wire" + export(name) + "Impl" }, g.nameInFileScope)
+			g.implements[implementKey(c)] = name
+			pendingImplements = append(pendingImplements, c)
+		}
+		if c.kind == valueExpr && !inlinesConst(c, g.pkg.PkgPath) {
+			if err := accessibleFrom(c.valueTypeInfo, c.valueExpr, g.pkg.PkgPath); err != nil {
+				// TODO(light): Display line number of value expression.
+				ts := types.TypeString(c.out, nil)
+				ec.add(notePosition(
+					g.pkg.Fset.Position(pos),
+					fmt.Errorf("inject %s: value %s can't be used: %v", name, ts, err)))
+			}
+			if !c.valueVar && !c.valueCall && opts.kit == nil && g.values[c.valueExpr] == "" {
+				t := c.valueTypeInfo.TypeOf(c.valueExpr)
+
+				name := typeVariableName(t, "", func(name string) string { return "_wire" + export(name) + "Value" }, g.nameInFileScope)
+				g.values[c.valueExpr] = name
+				pendingVars = append(pendingVars, pendingVar{
+					name:     name,
+					expr:     c.valueExpr,
+					typeInfo: c.valueTypeInfo,
+				})
+			}
+		}
+	}
+	if len(ec.errors) > 0 {
+		return ec.errors
+	}
+	if g.inputsHash {
+		g.inputs = append(g.inputs, "injector "+name+types.TypeString(sig, nil)[len("func"):])
+		for i := range calls {
+			g.inputs = append(g.inputs, callInput(&calls[i]))
+		}
+	}
+
+	if opts.kit != nil {
+		g.testKit(opts.kit, name, sig, calls, set, opts.panics)
+		for _, c := range pendingImplements {
+			g.implementDecl(c)
+		}
+		return nil
+	}
+	injector := name
+	doc := opts.doc
+	if opts.once {
+		initName := g.onceAccessor(name, sig, doc)
+		name, doc = initName, nil
+	}
+	// Perform one pass to collect all imports, followed by the real pass.
+	injectPass(name, sig, calls, set, doc, &injectorGen{
+		g:        g,
+		injector: injector,
+		errVar:   disambiguate("err", g.nameInFileScope),
+		panics:   opts.panics,
+		use:      opts.use,
+		discard:  true,
+	})
+	injectPass(name, sig, calls, set, doc, &injectorGen{
+		g:        g,
+		injector: injector,
+		errVar:   disambiguate("err", g.nameInFileScope),
+		panics:   opts.panics,
+		use:      opts.use,
+		discard:  false,
+	})
+	if len(pendingVars) > 0 {
+		g.p("var (\n")
+		for _, pv := range pendingVars {
+			g.p("\t%s = ", pv.name)
+			g.writeAST(pv.typeInfo, pv.expr)
+			g.p("\n")
+		}
+		g.p(")\n\n")
+	}
+	for _, ps := range pendingSingletons {
+		g.singletonAccessorFunc(ps.s, ps.c, ps.providerErr, ps.deps)
+	}
+	for _, c := range pendingImplements {
+		g.implementDecl(c)
+	}
+	if opts.container != "" {
+		g.container(opts.container, injector, sig, calls, set, opts.panics)
+	}
+	return nil
+}
+
+// testKit generates the type declared by a wire.TestKit call for injector:
+// a struct with a field for each type the kit overrides, a Build method that
+// takes the injector's parameters and builds its result, failing the test
+// on error, and a method for each field that calls the provider of its type
+// unless the field is set.
+func (g *gen) testKit(kit *testKit, injector string, sig *types.Signature, calls []call, set *ProviderSet, panics bool) {
+	g.p("// %s builds the result of %s in tests. Each field that is set is used\n", kit.name, injector)
+	g.p("// in place of the provider of its type.\n")
+	g.p("type %s struct {\n", kit.name)
+	for _, f := range kit.fields {
+		g.p("\t%s %s\n", f.name, types.TypeString(f.typ, g.qualifyPkg))
+	}
+	g.p("}\n\n")
+	doc := &ast.CommentGroup{List: []*ast.Comment{{
+		Text: fmt.Sprintf("// Build builds the result of %s, using the fields of k that are set in place\n// of the providers of their types.", injector),
+	}}}
+	for _, discard := range []bool{true, false} {
+		injectPass("Build", sig, calls, set, doc, &injectorGen{
+			g:        g,
+			injector: injector,
+			errVar:   disambiguate("err", g.nameInFileScope),
+			panics:   panics,
+			discard:  discard,
+			kit:      kit,
+		})
+	}
+}
+
+// resolve matches the fields of kit to the provider calls of the injector
+// named injector whose results they replace.
+func (kit *testKit) resolve(injector string, calls []call) []error {
+	ec := new(errorCollector)
+	for i := range calls {
+		if calls[i].singleton {
+			// The singleton's accessor is declared with the injector,
+			// where the kit can't reach it.
+			ec.add(fmt.Errorf("wire.TestKit can't be used with %s, which calls singleton provider %s", injector, providerLabel(&calls[i])))
+		}
+	}
+	for i := range kit.fields {
+		f := &kit.fields[i]
+		for j := range calls {
+			c := &calls[j]
+			if c.kind == funcProviderCall && !c.wrapper && !c.noValue && types.Identical(c.out, f.typ) {
+				f.call = c
+				break
+			}
+		}
+		switch c := f.call; {
+		case c == nil:
+			ec.add(fmt.Errorf("wire.TestKit can't override %s, which %s does not build with a provider function", types.TypeString(f.typ, nil), injector))
+		case c.lazy || c.instrument != nil || len(c.typeArgs) > 0:
+			ec.add(fmt.Errorf("wire.TestKit can't override %s, whose provider %s is lazy, generic, or instrumented by wire.Copy", types.TypeString(f.typ, nil), providerLabel(c)))
+		default:
+			f.method = disambiguate("build"+f.name, kit.fieldDeclared)
+		}
+	}
+	return ec.errors
+}
+
+// verifyContainer reports why the injector with signature sig can't be
+// marked //wire:container name, or returns nil if it can.
+func (g *gen) verifyContainer(name string, sig *types.Signature, set *ProviderSet, once bool) error {
+	injectSig, err := funcOutput(sig)
+	if err != nil {
+		return err
+	}
+	switch _, deferred := deferredOutput(set, injectSig); {
+	case once:
+		return errors.New("//wire:container can't be used with wire.BuildOnce")
+	case sig.Recv() != nil || typeParamCount(sig) > 0:
+		return errors.New("//wire:container can't be used on methods or generic injectors")
+	case deferred:
+		return errors.New("//wire:container can't be used on injectors that return a function")
+	}
+	for _, n := range []string{name, containerCtorName(name)} {
+		if g.nameInFileScope(n) {
+			return fmt.Errorf("//wire:container: %s is already declared in package %s", n, g.pkg.Name)
+		}
+	}
+	return nil
+}
+
+// containerCtorName returns the name of the constructor of the container
+// type name, such as NewAppContainer for AppContainer.
+func containerCtorName(name string) string {
+	if ast.IsExported(name) {
+		return "New" + name
+	}
+	return "new" + export(name)
+}
+
+// container generates the container type named by the //wire:container
+// directive of injector: a struct with a field for each value that the
+// injector's calls build, a constructor that takes the injector's
+// parameters and builds every value once, and a getter method for each
+// field.
+func (g *gen) container(name, injector string, sig *types.Signature, calls []call, set *ProviderSet, panics bool) {
+	ctor := containerCtorName(name)
+	obj := types.NewTypeName(token.NoPos, g.pkg.Types, name, nil)
+	ptr := types.NewPointer(types.NewNamed(obj, types.NewStruct(nil, nil), nil))
+	results := []*types.Var{types.NewVar(token.NoPos, g.pkg.Types, "", ptr)}
+	for i := 1; i < sig.Results().Len(); i++ {
+		results = append(results, sig.Results().At(i))
+	}
+	ctorSig := types.NewSignature(nil, sig.Params(), types.NewTuple(results...), sig.Variadic())
+	doc := &ast.CommentGroup{List: []*ast.Comment{{
+		Text: fmt.Sprintf("// %s builds the values that %s uses into a new %s.", ctor, injector, name),
+	}}}
+	for _, discard := range []bool{true, false} {
+		injectPass(ctor, ctorSig, calls, set, doc, &injectorGen{
+			g:         g,
+			injector:  ctor,
+			errVar:    disambiguate("err", g.nameInFileScope),
+			panics:    panics,
+			discard:   discard,
+			container: name,
+		})
+	}
+	g.declared[name] = true
+	g.declared[ctor] = true
+}
+
+// checkGoVersion reports an error for each feature of the code generated
+// for an injector that the Go version targeted by g lacks: wrapped provider
+// errors, the cleanup of a test kit, which kit reports is generated, and
+// each generic type used.
+func (g *gen) checkGoVersion(name string, sig *types.Signature, calls []call, kit bool) []error {
+	if g.goMinor == 0 {
+		return nil
 	}
-	var pendingVars []pendingVar
 	ec := new(errorCollector)
+	require := func(minor int, feature string) {
+		if g.goMinor < minor {
+			ec.add(fmt.Errorf("inject %s: %s requires go1.%d, but generated code must compile with go1.%d",
+				name, feature, minor, g.goMinor))
+		}
+	}
+	var hasErr, hasCleanup bool
+	for i := range calls {
+		hasErr = hasErr || calls[i].hasErr
+		hasCleanup = hasCleanup || calls[i].hasCleanup
+	}
+	if hasErr && g.wrapErrors && !g.structuredErrors {
+		require(wrapErrorsMinorVersion, "wrapping provider errors with fmt.Errorf's %w verb")
+	}
+	if hasCleanup && kit {
+		require(testCleanupMinorVersion, "calling cleanup functions from a test kit with testing.T.Cleanup")
+	}
+	if g.goMinor >= genericsMinorVersion {
+		return ec.errors
+	}
+	seen := make(map[string]bool)
+	check := func(t types.Type) {
+		gt := genericType(t)
+		if gt == nil {
+			return
+		}
+		ts := types.TypeString(gt, nil)
+		if seen[ts] {
+			return
+		}
+		seen[ts] = true
+		ec.add(fmt.Errorf("inject %s: %s uses generics, which require go1.%d, but generated code must compile with go1.%d",
+			name, ts, genericsMinorVersion, g.goMinor))
+	}
+	check(sig)
+	for i := range calls {
+		check(calls[i].out)
+		for _, t := range calls[i].ins {
+			check(t)
+		}
+	}
+	return ec.errors
+}
+
+// onceAccessor emits the package-level variables and accessor function for
+// a wire.BuildOnce or //wire:lazy injector and returns the name to give the
+// injector function that the accessor calls.
+func (g *gen) onceAccessor(name string, sig *types.Signature, doc *ast.CommentGroup) string {
+	out, err := funcOutput(sig)
+	if err != nil {
+		// This should be checked by the caller already.
+		panic(err)
+	}
+	newName := func(suffix string) string {
+		n := disambiguate("_wire"+export(name)+suffix, g.nameInFileScope)
+		g.declared[n] = true
+		return n
+	}
+	onceVar, valueVar := newName("Once"), newName("Value")
+	var errVar string
+	if out.err {
+		errVar = newName("Err")
+	}
+	initName := newName("Init")
+
+	outTypeString := types.TypeString(out.out, g.qualifyPkg)
+	g.p("var (\n")
+	g.p("\t%s %s.Once\n", onceVar, g.qualifyImport("sync", "sync"))
+	g.p("\t%s %s\n", valueVar, outTypeString)
+	if out.err {
+		g.p("\t%s error\n", errVar)
+	}
+	g.p(")\n\n")
+	if doc != nil {
+		for _, c := range doc.List {
+			g.p("%s\n", c.Text)
+		}
+	}
+	if out.err {
+		g.p("func %s() (%s, error) {\n", name, outTypeString)
+	} else {
+		g.p("func %s() %s {\n", name, outTypeString)
+	}
+	g.p("\t%s.Do(func() {\n", onceVar)
+	if out.err {
+		g.p("\t\t%s, %s = %s()\n", valueVar, errVar, initName)
+	} else {
+		g.p("\t\t%s = %s()\n", valueVar, initName)
+	}
+	g.p("\t})\n")
+	if out.err {
+		g.p("\treturn %s, %s\n", valueVar, errVar)
+	} else {
+		g.p("\treturn %s\n", valueVar)
+	}
+	g.p("}\n\n")
+	return initName
+}
+
+// convertExpr returns expr, a value of type from, as an expression of type to.
+// Values that Go assigns implicitly, such as a concrete type bound to an
+// interface, are used as is. Otherwise, solve has checked that from is
+// convertible to to, so an explicit conversion is emitted.
+func (g *gen) convertExpr(expr string, from, to types.Type) string {
+	if types.AssignableTo(from, to) {
+		return expr
+	}
+	ts := types.TypeString(to, g.qualifyPkg)
+	switch to.(type) {
+	case *types.Pointer, *types.Signature, *types.Chan:
+		return "(" + ts + ")(" + expr + ")"
+	}
+	return ts + "(" + expr + ")"
+}
+
+// singletonKey returns the key for c in gen.singletons.
+func singletonKey(c *call) string {
+	return c.pkg.Path() + "." + c.name
+}
+
+// newSingletonAccessor picks names for a new singleton accessor producing
+// type t. The caller is responsible for adding it to g.singletons.
+func (g *gen) newSingletonAccessor(t types.Type) *singletonAccessor {
+	name := typeVariableName(t, "", func(name string) string { return "_wire" + export(name) }, func(name string) bool {
+		return g.nameInFileScope(name) || g.nameInFileScope(name+"Once") || g.nameInFileScope(name+"Instance") || g.nameInFileScope(name+"Err")
+	})
+	return &singletonAccessor{
+		name:  name,
+		once:  name + "Once",
+		value: name + "Instance",
+		err:   name + "Err",
+		out:   t,
+	}
+}
+
+// singletonAccessorFunc emits the package-level variables and accessor
+// function for the singleton provider called by c. deps are the accessors
+// for c's arguments.
+func (g *gen) singletonAccessorFunc(s *singletonAccessor, c *call, providerErr bool, deps []*singletonAccessor) {
+	outTypeString := types.TypeString(c.out, g.qualifyPkg)
+	g.p("var (\n")
+	g.p("\t%s %s.Once\n", s.once, g.qualifyImport("sync", "sync"))
+	g.p("\t%s %s\n", s.value, outTypeString)
+	if s.hasErr {
+		g.p("\t%s error\n", s.err)
+	}
+	g.p(")\n\n")
+	if s.hasErr {
+		g.p("func %s() (%s, error) {\n", s.name, outTypeString)
+	} else {
+		g.p("func %s() %s {\n", s.name, outTypeString)
+	}
+	g.p("\t%s.Do(func() {\n", s.once)
+	var locals []string
+	collides := func(name string) bool {
+		if name == "err" || g.nameInFileScope(name) {
+			return true
+		}
+		for _, l := range locals {
+			if l == name {
+				return true
+			}
+		}
+		return false
+	}
+	for i, dep := range deps {
+		lname := typeVariableName(c.ins[i], "v", unexport, collides)
+		locals = append(locals, lname)
+		if dep.hasErr {
+			g.p("\t\t%s, err := %s()\n", lname, dep.name)
+			g.p("\t\tif err != nil {\n")
+			g.p("\t\t\t%s = err\n", s.err)
+			g.p("\t\t\treturn\n")
+			g.p("\t\t}\n")
+		} else {
+			g.p("\t\t%s := %s()\n", lname, dep.name)
+		}
+	}
+	g.p("\t\t%s", s.value)
+	if providerErr {
+		g.p(", %s", s.err)
+	}
+	args := make([]string, len(locals))
+	for i, l := range locals {
+		args[i] = g.convertExpr(l, deps[i].out, c.ins[i])
+	}
+	g.p(" = %s(%s", g.providerFunc(c), strings.Join(args, ", "))
+	if c.varargs {
+		g.p("...")
+	}
+	g.p(")\n")
+	g.p("\t})\n")
+	if s.hasErr {
+		g.p("\treturn %s, %s\n", s.value, s.err)
+	} else {
+		g.p("\treturn %s\n", s.value)
+	}
+	g.p("}\n\n")
+}
+
+// implementKey returns the key of the struct generated for the
+// wire.Implement provider called by c in gen.implements. Calls with the same
+// interface and components share a struct.
+func implementKey(c *call) string {
+	var sb strings.Builder
+	sb.WriteString(types.TypeString(c.out, nil))
+	for _, t := range c.ins {
+		sb.WriteString(",")
+		sb.WriteString(types.TypeString(t, nil))
+	}
+	return sb.String()
+}
+
+// implementFields returns the names of the fields holding the components of
+// the struct generated for the wire.Implement provider called by c.
+func implementFields(c *call) []string {
+	var fields []string
+	collides := func(name string) bool {
+		for _, m := range c.implement {
+			if m.Name == name {
+				return true
+			}
+		}
+		for _, f := range fields {
+			if f == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, t := range c.ins {
+		fields = append(fields, typeVariableName(t, "v", unexport, collides))
+	}
+	return fields
+}
+
+// implementDecl emits the struct type generated for the wire.Implement
+// provider called by c, with a method for each method of the interface that
+// forwards to the component implementing it.
+func (g *gen) implementDecl(c *call) {
+	name := g.implements[implementKey(c)]
+	fields := implementFields(c)
+	g.p("// %s implements %s by forwarding each method to a component.\n", name, types.TypeString(c.out, g.qualifyPkg))
+	g.p("type %s struct {\n", name)
+	for i, t := range c.ins {
+		g.p("\t%s %s\n", fields[i], types.TypeString(t, g.qualifyPkg))
+	}
+	g.p("}\n\n")
+	for _, m := range c.implement {
+		params := m.Sig.Params()
+		var names []string
+		collides := func(name string) bool {
+			for _, n := range names {
+				if n == name {
+					return true
+				}
+			}
+			return false
+		}
+		for i := 0; i < params.Len(); i++ {
+			n := params.At(i).Name()
+			if n == "" || n == "_" || collides(n) {
+				n = typeVariableName(params.At(i).Type(), "arg", unexport, collides)
+			}
+			names = append(names, n)
+		}
+		recv := disambiguate("impl", collides)
+		g.p("func (%s *%s) %s(", recv, name, m.Name)
+		for i, n := range names {
+			if i > 0 {
+				g.p(", ")
+			}
+			t := params.At(i).Type()
+			if m.Sig.Variadic() && i == params.Len()-1 {
+				g.p("%s ...%s", n, types.TypeString(t.(*types.Slice).Elem(), g.qualifyPkg))
+			} else {
+				g.p("%s %s", n, types.TypeString(t, g.qualifyPkg))
+			}
+		}
+		g.p(")")
+		results := m.Sig.Results()
+		switch results.Len() {
+		case 0:
+		case 1:
+			g.p(" %s", types.TypeString(results.At(0).Type(), g.qualifyPkg))
+		default:
+			g.p(" (")
+			for i := 0; i < results.Len(); i++ {
+				if i > 0 {
+					g.p(", ")
+				}
+				g.p("%s", types.TypeString(results.At(i).Type(), g.qualifyPkg))
+			}
+			g.p(")")
+		}
+		g.p(" {\n\t")
+		if results.Len() > 0 {
+			g.p("return ")
+		}
+		args := strings.Join(names, ", ")
+		if m.Sig.Variadic() {
+			args += "..."
+		}
+		g.p("%s.%s.%s(%s)\n", recv, fields[m.Arg], m.Name, args)
+		g.p("}\n\n")
+	}
+}
+
+// rewritePkgRefs rewrites any package references in an AST into references for the
+// generated package.
+func (g *gen) rewritePkgRefs(info *types.Info, node ast.Node) ast.Node {
+	start, end := node.Pos(), node.End()
+	node = copyAST(node)
+	// First, rewrite all package names. This lets us know all the
+	// potentially colliding identifiers.
+	node = astutil.Apply(node, func(c *astutil.Cursor) bool {
+		switch node := c.Node().(type) {
+		case *ast.Ident:
+			// This is an unqualified identifier (qualified identifiers are peeled off below).
+			obj := info.ObjectOf(node)
+			if obj == nil {
+				return false
+			}
+			if pkg := obj.Pkg(); pkg != nil && obj.Parent() == pkg.Scope() && pkg.Path() != g.pkg.PkgPath {
+				// An identifier from either a dot import or read from a different package.
+				newPkgID := g.qualifyImport(pkg.Name(), pkg.Path())
+				c.Replace(&ast.SelectorExpr{
+					X:   ast.NewIdent(newPkgID),
+					Sel: ast.NewIdent(node.Name),
+				})
+				return false
+			}
+			return true
+		case *ast.SelectorExpr:
+			pkgIdent, ok := node.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			pkgName, ok := info.ObjectOf(pkgIdent).(*types.PkgName)
+			if !ok {
+				return true
+			}
+			// This is a qualified identifier. Rewrite and avoid visiting subexpressions.
+			imported := pkgName.Imported()
+			newPkgID := g.qualifyImport(imported.Name(), imported.Path())
+			c.Replace(&ast.SelectorExpr{
+				X:   ast.NewIdent(newPkgID),
+				Sel: ast.NewIdent(node.Sel.Name),
+			})
+			return false
+		default:
+			return true
+		}
+	}, nil)
+	// Now that we have all the identifiers, rename any variables declared
+	// in this scope to not collide.
+	newNames := make(map[types.Object]string)
+	inNewNames := func(n string) bool {
+		for _, other := range newNames {
+			if other == n {
+				return true
+			}
+		}
+		return false
+	}
+	var scopeStack []*types.Scope
+	pkgScope := g.pkg.Types.Scope()
+	node = astutil.Apply(node, func(c *astutil.Cursor) bool {
+		if scope := info.Scopes[c.Node()]; scope != nil {
+			scopeStack = append(scopeStack, scope)
+		}
+		id, ok := c.Node().(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := info.ObjectOf(id)
+		if obj == nil {
+			// We rewrote this identifier earlier, so it does not need
+			// further rewriting.
+			return true
+		}
+		if n, ok := newNames[obj]; ok {
+			// We picked a new name for this symbol. Rewrite it.
+			c.Replace(ast.NewIdent(n))
+			return false
+		}
+		if par := obj.Parent(); par == nil || par == pkgScope {
+			// Don't rename methods, field names, or top-level identifiers.
+			return true
+		}
+
+		// Rename any symbols defined within rewritePkgRefs's node that conflict
+		// with any symbols in the generated file.
+		objName := obj.Name()
+		if pos := obj.Pos(); pos < start || end <= pos || !(g.nameInFileScope(objName) || inNewNames(objName)) {
+			return true
+		}
+		newName := disambiguate(objName, func(n string) bool {
+			if g.nameInFileScope(n) || inNewNames(n) {
+				return true
+			}
+			if len(scopeStack) > 0 {
+				// Avoid picking a name that conflicts with other names in the
+				// current scope.
+				_, obj := scopeStack[len(scopeStack)-1].LookupParent(n, token.NoPos)
+				if obj != nil {
+					return true
+				}
+			}
+			return false
+		})
+		newNames[obj] = newName
+		c.Replace(ast.NewIdent(newName))
+		return false
+	}, func(c *astutil.Cursor) bool {
+		if info.Scopes[c.Node()] != nil {
+			// Should be top of stack; pop it.
+			scopeStack = scopeStack[:len(scopeStack)-1]
+		}
+		return true
+	})
+	return node
+}
+
+// writeAST prints an AST node into the generated output, rewriting any
+// package references it encounters.
+func (g *gen) writeAST(info *types.Info, node ast.Node) {
+	g.printNode(g.rewritePkgRefs(info, node))
+}
+
+// printNode prints node to g.buf. The lines after the first are indented
+// like the line the node starts on, so that a multi-line expression written
+// inside an injector lines up with the statement it is part of, even
+// before the output is formatted.
+func (g *gen) printNode(node ast.Node) {
+	b := g.buf.Bytes()
+	line := b[bytes.LastIndexByte(b, '\n')+1:]
+	indent := line[:len(line)-len(bytes.TrimLeft(line, "\t"))]
+	if len(indent) == 0 || hasMultilineRawString(node) {
+		if err := printer.Fprint(&g.buf, g.pkg.Fset, node); err != nil {
+			panic(err)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, g.pkg.Fset, node); err != nil {
+		panic(err)
+	}
+	g.buf.Write(bytes.ReplaceAll(buf.Bytes(), []byte("\n"), append([]byte("\n"), indent...)))
+}
+
+// hasMultilineRawString reports whether node contains a raw string literal
+// that spans lines, whose value would change if its lines were indented.
+func hasMultilineRawString(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING && strings.HasPrefix(lit.Value, "`") && strings.Contains(lit.Value, "\n") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// providerFunc returns the expression that refers to the provider function
+// of c, including the type arguments of a generic provider.
+func (g *gen) providerFunc(c *call) string {
+	name := g.qualifiedID(c.pkg.Name(), c.pkg.Path(), c.name)
+	if len(c.typeArgs) == 0 {
+		return name
+	}
+	args := make([]string, len(c.typeArgs))
+	for i, t := range c.typeArgs {
+		args[i] = types.TypeString(t, g.qualifyPkg)
+	}
+	return name + "[" + strings.Join(args, ", ") + "]"
+}
+
+func (g *gen) qualifiedID(pkgName, pkgPath, sym string) string {
+	name := g.qualifyImport(pkgName, pkgPath)
+	if name == "" {
+		return sym
+	}
+	return name + "." + sym
+}
+
+func (g *gen) qualifyImport(name, path string) string {
+	if path == g.pkg.PkgPath {
+		return ""
+	}
+	// TODO(light): This is depending on details of the current loader.
+	const vendorPart = "vendor/"
+	unvendored := path
+	if i := strings.LastIndex(path, vendorPart); i != -1 && (i == 0 || path[i-1] == '/') {
+		unvendored = path[i+len(vendorPart):]
+	}
+	if g.fileImports != nil {
+		g.fileImports[unvendored] = true
+	}
+	if info, ok := g.imports[unvendored]; ok {
+		return info.name
+	}
+	preferred := name
+	if alias, ok := g.importAliases[unvendored]; ok {
+		preferred = alias
+	}
+	// TODO(light): Use parts of import path to disambiguate.
+	newName := disambiguate(preferred, func(n string) bool {
+		// Don't let an import take the "err" name. That's annoying.
+		return n == "err" || g.nameInFileScope(n)
+	})
+	g.imports[unvendored] = importInfo{
+		name:    newName,
+		differs: newName != name,
+	}
+	return newName
+}
+
+func (g *gen) nameInFileScope(name string) bool {
+	for _, other := range g.imports {
+		if other.name == name {
+			return true
+		}
+	}
+	for _, other := range g.values {
+		if other == name {
+			return true
+		}
+	}
+	for _, s := range g.singletons {
+		if name == s.name || name == s.once || name == s.value || name == s.err {
+			return true
+		}
+	}
+	for _, other := range g.implements {
+		if other == name {
+			return true
+		}
+	}
+	if g.declared[name] {
+		return true
+	}
+	_, obj := g.pkg.Types.Scope().LookupParent(name, token.NoPos)
+	return obj != nil
+}
+
+func (g *gen) qualifyPkg(pkg *types.Package) string {
+	return g.qualifyImport(pkg.Name(), pkg.Path())
+}
+
+func (g *gen) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// injectorGen is the per-injector pass generator state.
+type injectorGen struct {
+	g *gen
+	// injector is the name of the injector being generated.
+	injector string
+	// container is the name of the type that the injector builds, if it is
+	// the constructor of a //wire:container type, or empty.
+	container string
+	// use is true if the injector calls wire.Use, so that it has no result.
+	use bool
+	// kit is the test kit whose Build method is being generated, or nil.
+	// The method fails the test passed to it instead of returning an error.
+	kit *testKit
+	// kitVar and testVar are the names of Build's receiver and its
+	// *testing.T parameter.
+	kitVar  string
+	testVar string
+
+	paramNames   []string
+	localNames   []string
+	argTypes     []types.Type // types of the params followed by the locals
+	cleanupNames []string
+	errVar       string
+	// panics is true if the injector is marked //wire:panics, so that
+	// errors are passed to panic instead of being returned.
+	panics bool
+	// traceVar is the name of the local holding the function returned by
+	// wire.TraceProvider, or empty if no provider call was traced yet.
+	traceVar string
+	// ctxParam is the index of the injector's context.Context parameter,
+	// or -1 if it has none.
+	ctxParam int
+	// spanVar and spanCtx are the names of the locals holding the current
+	// OpenTelemetry span and its context, or empty if not yet declared.
+	spanVar string
+	spanCtx string
+	// inSpan is true while a provider call is emitted inside a span, so
+	// that it is passed spanCtx in place of the injector's context.
+	inSpan bool
+	// extraNames holds other local variables, such as those that back
+	// wire.Lazy accessors.
+	extraNames []string
+
+	// discard causes ig.p and ig.writeAST to no-op. Useful to run
+	// generation for side-effects like filling in g.imports.
+	discard bool
+}
+
+// injectPass generates an injector given the output from analysis.
+// The sig passed in should be verified.
+func injectPass(name string, sig *types.Signature, calls []call, set *ProviderSet, doc *ast.CommentGroup, ig *injectorGen) {
+	params := injectorInputs(sig)
+	injectSig, err := injectorOutput(sig, ig.use)
+	if err != nil {
+		// This should be checked by the caller already.
+		panic(err)
+	}
+	if doc != nil {
+		for _, c := range doc.List {
+			ig.p("%s\n", c.Text)
+		}
+	}
+	ig.p("func ")
+	first := 0
+	if ig.kit != nil {
+		// Build takes the test after the kit, followed by the injector's
+		// inputs, including its receiver.
+		ig.kitVar = disambiguate("k", ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, ig.kitVar)
+		ig.testVar = disambiguate("t", ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, ig.testVar)
+		ig.p("(%s *%s) %s(%s *%s", ig.kitVar, ig.kit.name, name, ig.testVar, ig.g.qualifiedID("testing", "testing", "T"))
+		first = -1
+	} else if recv := sig.Recv(); recv != nil {
+		// The receiver is the first input. Emit it before the name.
+		first = 1
+		ig.paramNames = append(ig.paramNames, ig.inputName(recv))
+		ig.p("(%s %s) ", ig.paramNames[0], types.TypeString(recv.Type(), ig.g.qualifyPkg))
+	}
+	if first >= 0 {
+		ig.p("%s%s(", name, typeParamList(sig, ig.g.qualifyPkg))
+	}
+	for i := first; i < params.Len(); i++ {
+		if i > first {
+			ig.p(", ")
+		}
+		if i < 0 {
+			continue
+		}
+		pi := params.At(i)
+		ig.paramNames = append(ig.paramNames, ig.inputName(pi))
+		if sig.Variadic() && i == params.Len()-1 {
+			// Keep the varargs signature instead of a slice for the last argument if the
+			// injector is variadic.
+			ig.p("%s ...%s", ig.paramNames[i], types.TypeString(pi.Type().(*types.Slice).Elem(), ig.g.qualifyPkg))
+		} else {
+			ig.p("%s %s", ig.paramNames[i], types.TypeString(pi.Type(), ig.g.qualifyPkg))
+		}
+	}
+	outTypeString := types.TypeString(injectSig.out, ig.g.qualifyPkg)
+	switch {
+	case ig.kit != nil:
+		ig.p(") %s {\n", outTypeString)
+		ig.p("\t%s.Helper()\n", ig.testVar)
+	case injectSig.out == nil && injectSig.err:
+		ig.p(") error {\n")
+	case injectSig.out == nil:
+		ig.p(") {\n")
+	case injectSig.cleanup && injectSig.err:
+		ig.p(") (%s, func(), error) {\n", outTypeString)
+	case injectSig.cleanup:
+		ig.p(") (%s, func()) {\n", outTypeString)
+	case injectSig.err:
+		ig.p(") (%s, error) {\n", outTypeString)
+	default:
+		ig.p(") %s {\n", outTypeString)
+	}
+	closure := false
+	if inner, ok := deferredOutput(set, injectSig); ok {
+		// Build the result in a closure that the injector returns.
+		closure = true
+		injectSig = inner
+		ig.p("\treturn %s {\n", types.TypeString(closureType(sig), ig.g.qualifyPkg))
+	}
+	for i := 0; i < params.Len(); i++ {
+		ig.argTypes = append(ig.argTypes, params.At(i).Type())
+	}
+	ig.ctxParam = contextParam(params)
+	for i := range calls {
+		ig.argTypes = append(ig.argTypes, calls[i].valueType())
+	}
+	iface := addressedInterface(set, injectSig.out)
+	target := injectSig.out
+	if iface != nil {
+		target = iface
+	}
+	out := -1
+	var used []bool
+	if ig.container != "" || injectSig.out == nil {
+		// A container holds every value, not only the injector's output,
+		// and an injector that calls wire.Use makes every call.
+		used = make([]bool, len(calls))
+		for i := range used {
+			used[i] = true
+		}
+	} else {
+		out = outputIndex(calls, params.Len(), set, target)
+		if iface == nil && ig.kit == nil && ig.directReturn(calls, params.Len(), out, injectSig) {
+			c := &calls[0]
+			ig.p("\treturn ")
+			ig.providerCallExpr(c)
+			if injectSig.err {
+				ig.p(", nil")
+			}
+			if closure {
+				ig.p("\n}")
+			}
+			ig.p("\n}\n\n")
+			return
+		}
+		used = usedCalls(calls, params.Len(), out)
+	}
 	for i := range calls {
 		c := &calls[i]
-		if c.hasCleanup && !injectSig.cleanup {
-			ts := types.TypeString(c.out, nil)
-			ec.add(notePosition(
-				g.pkg.Fset.Position(pos),
-				fmt.Errorf("inject %s: provider for %s returns cleanup but injection does not return cleanup function", name, ts)))
+		if !used[i] || c.noValue {
+			ig.localNames = append(ig.localNames, "")
+			if used[i] {
+				ig.noValueCall(c)
+			}
+			continue
 		}
-		if c.hasErr && !injectSig.err {
-			ts := types.TypeString(c.out, nil)
-			ec.add(notePosition(
-				g.pkg.Fset.Position(pos),
-				fmt.Errorf("inject %s: provider for %s returns error but injection not allowed to fail", name, ts)))
+		discard := (c.eager || injectSig.out == nil) && ig.container == "" && !consumed(calls, params.Len(), out, used, i)
+		var lname string
+		switch {
+		case discard && !c.hasCleanup && !c.hasErr && !c.closes:
+			// Nothing uses the result of an eager provider, so discard it.
+			lname = "_"
+		case c.lazy && c.resultName != "":
+			lname = disambiguate(c.resultName+"Func", ig.nameInInjector)
+		case c.lazy:
+			lname = typeVariableName(lazyResult(c), "v", func(name string) string { return unexport(name) + "Func" }, ig.nameInInjector)
+		case c.resultName != "":
+			lname = disambiguate(c.resultName, ig.nameInInjector)
+		case c.kind == groupSlice:
+			elem := c.out.Underlying().(*types.Slice).Elem()
+			lname = typeVariableName(elem, "v", func(name string) string { return unexport(name) + "s" }, ig.nameInInjector)
+		default:
+			lname = typeVariableName(c.valueType(), "v", unexport, ig.nameInInjector)
 		}
-		if c.kind == valueExpr {
-			if err := accessibleFrom(c.valueTypeInfo, c.valueExpr, g.pkg.PkgPath); err != nil {
-				// TODO(light): Display line number of value expression.
-				ts := types.TypeString(c.out, nil)
-				ec.add(notePosition(
-					g.pkg.Fset.Position(pos),
-					fmt.Errorf("inject %s: value %s can't be used: %v", name, ts, err)))
+		ig.localNames = append(ig.localNames, lname)
+		switch c.kind {
+		case structProvider:
+			ig.structProviderCall(lname, c, injectSig)
+		case funcProviderCall:
+			if c.lazy {
+				ig.lazyProviderCall(lname, c)
+			} else {
+				ig.funcProviderCall(lname, c, injectSig)
 			}
-			if g.values[c.valueExpr] == "" {
-				t := c.valueTypeInfo.TypeOf(c.valueExpr)
-
-				name := typeVariableName(t, "", func(name string) string { return "_wire" + export(name) + "Value" }, g.nameInFileScope)
-				g.values[c.valueExpr] = name
-				pendingVars = append(pendingVars, pendingVar{
-					name:     name,
-					expr:     c.valueExpr,
-					typeInfo: c.valueTypeInfo,
-				})
+			if discard && lname != "_" {
+				ig.p("\t_ = %s\n", lname)
+			}
+		case valueExpr:
+			ig.valueExpr(lname, c)
+		case selectorExpr:
+			ig.fieldExpr(lname, c)
+		case typeAssertExpr:
+			ig.typeAssertExpr(lname, c, injectSig)
+		case interfaceSelect:
+			ig.interfaceSelect(lname, c, injectSig)
+		case groupSlice:
+			ig.groupSlice(lname, c)
+		case implementStruct:
+			ig.implementStruct(lname, c)
+		case derefExpr:
+			ig.derefExpr(lname, c)
+		default:
+			panic("unknown kind")
+		}
+	}
+	if injectSig.out == nil {
+		if injectSig.err {
+			ig.p("\treturn nil\n")
+		}
+		ig.p("}\n\n")
+		return
+	}
+	if ig.kit != nil {
+		if len(ig.cleanupNames) > 0 {
+			ig.p("\t%s.Cleanup(func() {\n", ig.testVar)
+			for i := len(ig.cleanupNames) - 1; i >= 0; i-- {
+				ig.p("\t\t%s()\n", ig.cleanupNames[i])
 			}
+			ig.p("\t})\n")
+		}
+		if iface != nil {
+			ig.p("\treturn &%s\n}\n\n", ig.addressable(out, iface))
+		} else {
+			ig.p("\treturn %s\n}\n\n", ig.arg(out, injectSig.out))
 		}
+		ig.testKitMethods()
+		return
 	}
-	if len(ec.errors) > 0 {
-		return ec.errors
+	switch {
+	case ig.container != "":
+		ig.p("\treturn &%s{\n", ig.container)
+		for _, lname := range ig.localNames[:len(calls)] {
+			if lname != "" {
+				ig.p("\t\t%s: %s,\n", lname, lname)
+			}
+		}
+		ig.p("\t}")
+	case iface != nil:
+		ig.p("\treturn &%s", ig.addressable(out, iface))
+	default:
+		ig.p("\treturn %s", ig.arg(out, injectSig.out))
 	}
-
-	// Perform one pass to collect all imports, followed by the real pass.
-	injectPass(name, sig, calls, set, doc, &injectorGen{
-		g:       g,
-		errVar:  disambiguate("err", g.nameInFileScope),
-		discard: true,
-	})
-	injectPass(name, sig, calls, set, doc, &injectorGen{
-		g:       g,
-		errVar:  disambiguate("err", g.nameInFileScope),
-		discard: false,
-	})
-	if len(pendingVars) > 0 {
-		g.p("var (\n")
-		for _, pv := range pendingVars {
-			g.p("\t%s = ", pv.name)
-			g.writeAST(pv.typeInfo, pv.expr)
-			g.p("\n")
+	if injectSig.cleanup {
+		ig.p(", func() {\n")
+		for i := len(ig.cleanupNames) - 1; i >= 0; i-- {
+			ig.p("\t\t%s()\n", ig.cleanupNames[i])
 		}
-		g.p(")\n\n")
+		ig.p("\t}")
+	}
+	if injectSig.err {
+		ig.p(", nil")
+	}
+	if closure {
+		ig.p("\n}")
+	}
+	ig.p("\n}\n\n")
+	if ig.container != "" {
+		ig.containerDecls(calls)
 	}
-	return nil
 }
 
-// rewritePkgRefs rewrites any package references in an AST into references for the
-// generated package.
-func (g *gen) rewritePkgRefs(info *types.Info, node ast.Node) ast.Node {
-	start, end := node.Pos(), node.End()
-	node = copyAST(node)
-	// First, rewrite all package names. This lets us know all the
-	// potentially colliding identifiers.
-	node = astutil.Apply(node, func(c *astutil.Cursor) bool {
-		switch node := c.Node().(type) {
-		case *ast.Ident:
-			// This is an unqualified identifier (qualified identifiers are peeled off below).
-			obj := info.ObjectOf(node)
-			if obj == nil {
-				return false
+// testKitMethods emits the methods of the test kit whose Build method ig
+// generates that build the value of each of its fields unless the field is
+// set.
+func (ig *injectorGen) testKitMethods() {
+	kit := ig.kit
+	for _, f := range kit.fields {
+		c := f.call
+		names := []string{"k"}
+		collides := func(name string) bool {
+			for _, other := range names {
+				if other == name {
+					return true
+				}
 			}
-			if pkg := obj.Pkg(); pkg != nil && obj.Parent() == pkg.Scope() && pkg.Path() != g.pkg.PkgPath {
-				// An identifier from either a dot import or read from a different package.
-				newPkgID := g.qualifyImport(pkg.Name(), pkg.Path())
-				c.Replace(&ast.SelectorExpr{
-					X:   ast.NewIdent(newPkgID),
-					Sel: ast.NewIdent(node.Name),
-				})
-				return false
+			return ig.g.nameInFileScope(name)
+		}
+		ig.p("// %s returns k.%s if it is set, or calls %s otherwise.\n", f.method, f.name, providerLabel(c))
+		ig.p("func (k *%s) %s(", kit.name, f.method)
+		for i, t := range c.ins {
+			name := typeVariableName(t, "arg", unexport, collides)
+			names = append(names, name)
+			if i > 0 {
+				ig.p(", ")
 			}
-			return true
-		case *ast.SelectorExpr:
-			pkgIdent, ok := node.X.(*ast.Ident)
-			if !ok {
-				return true
+			if c.varargs && i == len(c.ins)-1 {
+				ig.p("%s ...%s", name, types.TypeString(t.(*types.Slice).Elem(), ig.g.qualifyPkg))
+			} else {
+				ig.p("%s %s", name, types.TypeString(t, ig.g.qualifyPkg))
 			}
-			pkgName, ok := info.ObjectOf(pkgIdent).(*types.PkgName)
-			if !ok {
+		}
+		results := []string{types.TypeString(f.typ, ig.g.qualifyPkg)}
+		zeros := []string{"k." + f.name}
+		if c.hasCleanup {
+			results = append(results, "func()")
+			zeros = append(zeros, "func() {}")
+		}
+		if c.hasErr {
+			results = append(results, "error")
+			zeros = append(zeros, "nil")
+		}
+		if len(results) == 1 {
+			ig.p(") %s {\n", results[0])
+		} else {
+			ig.p(") (%s) {\n", strings.Join(results, ", "))
+		}
+		ig.p("\tif k.%s != nil {\n", f.name)
+		ig.p("\t\treturn %s\n", strings.Join(zeros, ", "))
+		ig.p("\t}\n")
+		ig.p("\treturn %s(%s", ig.g.providerFunc(c), strings.Join(names[1:], ", "))
+		if c.varargs {
+			ig.p("...")
+		}
+		ig.p(")\n}\n\n")
+	}
+}
+
+// containerDecls emits the declaration of the container type that ig
+// builds, with a field for the value of each of calls, named after the
+// local variable holding it, and a getter method for each field.
+func (ig *injectorGen) containerDecls(calls []call) {
+	fields := ig.localNames[:len(calls)]
+	ig.p("// %s holds the values built by %s.\n", ig.container, ig.injector)
+	ig.p("type %s struct {\n", ig.container)
+	for i, lname := range fields {
+		if lname != "" {
+			ig.p("\t%s %s\n", lname, types.TypeString(calls[i].valueType(), ig.g.qualifyPkg))
+		}
+	}
+	ig.p("}\n\n")
+	var getters []string
+	collides := func(name string) bool {
+		for _, other := range getters {
+			if other == name {
 				return true
 			}
-			// This is a qualified identifier. Rewrite and avoid visiting subexpressions.
-			imported := pkgName.Imported()
-			newPkgID := g.qualifyImport(imported.Name(), imported.Path())
-			c.Replace(&ast.SelectorExpr{
-				X:   ast.NewIdent(newPkgID),
-				Sel: ast.NewIdent(node.Sel.Name),
-			})
-			return false
-		default:
-			return true
 		}
-	}, nil)
-	// Now that we have all the identifiers, rename any variables declared
-	// in this scope to not collide.
-	newNames := make(map[types.Object]string)
-	inNewNames := func(n string) bool {
-		for _, other := range newNames {
-			if other == n {
+		for _, other := range fields {
+			if other == name {
 				return true
 			}
 		}
 		return false
 	}
-	var scopeStack []*types.Scope
-	pkgScope := g.pkg.Types.Scope()
-	node = astutil.Apply(node, func(c *astutil.Cursor) bool {
-		if scope := info.Scopes[c.Node()]; scope != nil {
-			scopeStack = append(scopeStack, scope)
+	for i, lname := range fields {
+		if lname == "" {
+			continue
 		}
-		id, ok := c.Node().(*ast.Ident)
-		if !ok {
-			return true
+		c := &calls[i]
+		var getter string
+		if c.lazy || c.resultName != "" || c.kind == groupSlice {
+			getter = disambiguate(export(lname), collides)
+		} else {
+			getter = typeVariableName(c.valueType(), "Value", export, collides)
 		}
-		obj := info.ObjectOf(id)
-		if obj == nil {
-			// We rewrote this identifier earlier, so it does not need
-			// further rewriting.
-			return true
+		getters = append(getters, getter)
+		ts := types.TypeString(c.valueType(), ig.g.qualifyPkg)
+		ig.p("// %s returns the %s built by %s.\n", getter, ts, ig.injector)
+		ig.p("func (c *%s) %s() %s {\n", ig.container, getter, ts)
+		ig.p("\treturn c.%s\n", lname)
+		ig.p("}\n\n")
+	}
+}
+
+// providerErr returns the expression that the injector returns for the error
+// of the provider call c, which is in ig.errVar.
+func (ig *injectorGen) providerErr(c *call) string {
+	switch {
+	case ig.g.structuredErrors:
+		return fmt.Sprintf("&%s{Provider: %q, Package: %q, Cause: %s}", ig.g.qualifiedID("wire", "github.com/google/wire", "ProviderError"), c.name, c.pkg.Path(), ig.errVar)
+	case ig.g.wrapErrors:
+		return fmt.Sprintf("%s.Errorf(%q, %s)", ig.g.qualifyImport("fmt", "fmt"), "provider "+providerLabel(c)+": %w", ig.errVar)
+	default:
+		return ig.errVar
+	}
+}
+
+// directReturn reports whether the injector can return the result of its
+// only call directly, as in return NewFoo(bar), instead of assigning it to a
+// variable first. The call must be a plain call of a provider function that
+// produces the injector's output type and returns neither a cleanup function
+// nor an error. A provider that fails is still checked, so that the injector
+// returns the zero value with the error.
+func (ig *injectorGen) directReturn(calls []call, numGiven, out int, injectSig outputSignature) bool {
+	if len(calls) != 1 || out != numGiven || injectSig.cleanup || ig.g.instrument || ig.g.otelTrace || ig.g.visitCall != nil {
+		return false
+	}
+	c := &calls[0]
+	if c.kind != funcProviderCall || c.lazy || c.singleton || c.noValue || c.hasCleanup || c.hasErr || c.closes || c.instrument != nil {
+		return false
+	}
+	return types.Identical(c.out, injectSig.out)
+}
+
+// deferredOutput reports whether an injector with the output signature out
+// returns a closure that builds its result, and returns the closure's
+// output signature. This is the case if out is a function type with no
+// parameters, such as func() (*Foo, error), that set does not provide and
+// the injector returns on its own. Each call of the closure then builds a
+// new *Foo, returning the error or cleanup function of that build.
+func deferredOutput(set *ProviderSet, out outputSignature) (outputSignature, bool) {
+	if out.out == nil || out.err || out.cleanup || !set.For(out.out).IsNil() {
+		return outputSignature{}, false
+	}
+	sig, ok := out.out.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() > 0 || sig.Results().Len() == 0 {
+		return outputSignature{}, false
+	}
+	inner, err := funcOutput(sig)
+	if err != nil {
+		return outputSignature{}, false
+	}
+	return inner, true
+}
+
+// closureType returns the function type of the closure returned by an
+// injector with signature sig, for which deferredOutput is true.
+func closureType(sig *types.Signature) *types.Signature {
+	return sig.Results().At(0).Type().Underlying().(*types.Signature)
+}
+
+// outputIndex returns the index of the injector's output, using the same
+// numbering as call.args. The output is usually the last call, but may be
+// built earlier as a dependency of a wire.NoValue provider. If the output is
+// wrapped with wire.Wrap, it is the last wrapper's call.
+func outputIndex(calls []call, numGiven int, set *ProviderSet, out types.Type) int {
+	pv := set.For(out)
+	if pv.IsArg() {
+		return pv.Arg().Index
+	}
+	for i := len(calls) - 1; i >= 0; i-- {
+		if types.Identical(calls[i].out, pv.Type()) {
+			return numGiven + i
 		}
-		if n, ok := newNames[obj]; ok {
-			// We picked a new name for this symbol. Rewrite it.
-			c.Replace(ast.NewIdent(n))
-			return false
+	}
+	panic("injector output is not produced by any call")
+}
+
+// usedCalls reports which of calls the injector body must make, given the
+// index of the injector's output. Singleton accessors build their own
+// dependencies, so a call whose result is only consumed by singletons is
+// not made by the injector. wire.NoValue providers and providers marked
+// //wire:eager are always called.
+func usedCalls(calls []call, numGiven int, out int) []bool {
+	used := make([]bool, len(calls))
+	if out >= numGiven {
+		used[out-numGiven] = true
+	}
+	for i := range calls {
+		if calls[i].noValue || calls[i].eager {
+			used[i] = true
 		}
-		if par := obj.Parent(); par == nil || par == pkgScope {
-			// Don't rename methods, field names, or top-level identifiers.
-			return true
+	}
+	for i := len(calls) - 1; i >= 0; i-- {
+		if !used[i] || calls[i].singleton {
+			continue
+		}
+		for _, a := range calls[i].args {
+			if a >= numGiven {
+				used[a-numGiven] = true
+			}
 		}
+	}
+	return used
+}
 
-		// Rename any symbols defined within rewritePkgRefs's node that conflict
-		// with any symbols in the generated file.
-		objName := obj.Name()
-		if pos := obj.Pos(); pos < start || end <= pos || !(g.nameInFileScope(objName) || inNewNames(objName)) {
-			return true
+// consumed reports whether the result of calls[i] is the injector's output
+// or an argument to another call the injector makes.
+func consumed(calls []call, numGiven int, out int, used []bool, i int) bool {
+	if out == numGiven+i {
+		return true
+	}
+	for j := range calls {
+		if !used[j] || calls[j].singleton {
+			continue
 		}
-		newName := disambiguate(objName, func(n string) bool {
-			if g.nameInFileScope(n) || inNewNames(n) {
+		for _, a := range calls[j].args {
+			if a == numGiven+i {
 				return true
 			}
-			if len(scopeStack) > 0 {
-				// Avoid picking a name that conflicts with other names in the
-				// current scope.
-				_, obj := scopeStack[len(scopeStack)-1].LookupParent(n, token.NoPos)
-				if obj != nil {
-					return true
-				}
-			}
-			return false
+		}
+	}
+	return false
+}
+
+func (ig *injectorGen) funcProviderCall(lname string, c *call, injectSig outputSignature) {
+	var after string
+	if ig.g.visitCall != nil && !ig.discard {
+		var before string
+		before, after = ig.g.visitCall(CallInfo{
+			Injector: ig.injector,
+			Provider: providerLabel(c),
+			Out:      c.out,
+			Var:      lname,
 		})
-		newNames[obj] = newName
-		c.Replace(ast.NewIdent(newName))
-		return false
-	}, func(c *astutil.Cursor) bool {
-		if info.Scopes[c.Node()] != nil {
-			// Should be top of stack; pop it.
-			scopeStack = scopeStack[:len(scopeStack)-1]
+		ig.snippet(before)
+	}
+	if ig.g.instrument {
+		ig.traceProvider(c)
+	}
+	if ig.g.otelTrace {
+		ig.startSpan(c)
+	}
+	prevCleanup := len(ig.cleanupNames)
+	cname := ""
+	if c.hasCleanup {
+		cname = disambiguate("cleanup", ig.nameInInjector)
+		ig.cleanupNames = append(ig.cleanupNames, cname)
+	}
+	if c.instrument != nil {
+		ig.instrumentedCall(lname, cname, c)
+	} else {
+		ig.p("\t%s", lname)
+		if cname != "" {
+			ig.p(", %s", cname)
 		}
-		return true
-	})
-	return node
+		if c.hasErr {
+			ig.p(", %s", ig.errVar)
+		}
+		if lname == "_" {
+			ig.p(" = ")
+		} else {
+			ig.p(" := ")
+		}
+		if c.singleton {
+			// Singletons are built by their accessor, not by the injector.
+			ig.p("%s()\n", ig.g.singletons[singletonKey(c)].name)
+		} else {
+			ig.providerCallExpr(c)
+			ig.p("\n")
+		}
+	}
+	if ig.g.otelTrace {
+		ig.endSpan()
+	}
+	if ig.g.instrument {
+		ig.p("\t%s()\n", ig.traceVar)
+	}
+	ig.snippet(after)
+	if c.hasErr {
+		ig.p("\tif %s != nil {\n", ig.errVar)
+		ig.fail(injectSig, prevCleanup, ig.providerErr(c))
+		ig.p("\t}\n")
+	}
+	if c.closes {
+		cname := disambiguate("cleanup", ig.nameInInjector)
+		ig.cleanupNames = append(ig.cleanupNames, cname)
+		ig.p("\t%s := func() {\n", cname)
+		if !isCloser(c.out) {
+			// Channels are closed with the builtin, which can't fail.
+			ig.p("\t\tclose(%s)\n", lname)
+		} else {
+			ig.p("\t\t%s(%q, %s)\n", ig.g.qualifiedID("wire", "github.com/google/wire", "Close"), providerLabel(c), lname)
+		}
+		ig.p("\t}\n")
+	}
 }
 
-// writeAST prints an AST node into the generated output, rewriting any
-// package references it encounters.
-func (g *gen) writeAST(info *types.Info, node ast.Node) {
-	node = g.rewritePkgRefs(info, node)
-	if err := printer.Fprint(&g.buf, g.pkg.Fset, node); err != nil {
-		panic(err)
+// snippet emits code returned by GenerateOptions.VisitCall on lines of its
+// own.
+func (ig *injectorGen) snippet(code string) {
+	if code == "" {
+		return
 	}
+	ig.p("\t%s\n", strings.TrimSuffix(code, "\n"))
 }
 
-func (g *gen) qualifiedID(pkgName, pkgPath, sym string) string {
-	name := g.qualifyImport(pkgName, pkgPath)
-	if name == "" {
-		return sym
+// providerCallExpr emits the expression that calls the provider function of
+// c with its arguments.
+func (ig *injectorGen) providerCallExpr(c *call) {
+	if f := ig.kit.field(c); f != nil {
+		ig.p("%s.%s(", ig.kitVar, f.method)
+	} else {
+		ig.p("%s(", ig.g.providerFunc(c))
 	}
-	return name + "." + sym
+	for i, a := range c.args {
+		if i > 0 {
+			ig.p(", ")
+		}
+		ig.p("%s", ig.arg(a, c.ins[i]))
+	}
+	if c.varargs {
+		ig.p("...")
+	}
+	ig.p(")")
 }
 
-func (g *gen) qualifyImport(name, path string) string {
-	if path == g.pkg.PkgPath {
-		return ""
+// instrumentedCall emits the call of a provider that wire.Copy instruments.
+// The provider is called in a closure passed to the wrapper function, which
+// is instantiated for the provider's type by inference:
+//
+//	cleanup := func() {}
+//	foo, err := Record("main.provideFoo", func() (foo Foo, err error) {
+//		foo, cleanup, err = provideFoo(bar)
+//		return
+//	})()
+//
+// The cleanup function starts out doing nothing, in case the wrapper returns
+// without calling the provider. The error the wrapper returns is checked
+// like the provider's. cname is the name of the provider's cleanup function,
+// or empty.
+func (ig *injectorGen) instrumentedCall(lname, cname string, c *call) {
+	if cname != "" {
+		ig.p("\t%s := func() {}\n", cname)
+	}
+	tok := ":="
+	if lname == "_" {
+		tok = "="
+	}
+	wrapper := ig.g.qualifiedID(c.instrument.Pkg().Name(), c.instrument.Pkg().Path(), c.instrument.Name())
+	ig.p("\t%s, %s %s %s(%q, func() (%s %s, %s error) {\n", lname, ig.errVar, tok, wrapper, providerLabel(c),
+		lname, types.TypeString(c.valueType(), ig.g.qualifyPkg), ig.errVar)
+	ig.p("\t\t%s", lname)
+	if cname != "" {
+		ig.p(", %s", cname)
 	}
-	// TODO(light): This is depending on details of the current loader.
-	const vendorPart = "vendor/"
-	unvendored := path
-	if i := strings.LastIndex(path, vendorPart); i != -1 && (i == 0 || path[i-1] == '/') {
-		unvendored = path[i+len(vendorPart):]
+	if c.fnErr {
+		ig.p(", %s", ig.errVar)
 	}
-	if info, ok := g.imports[unvendored]; ok {
-		return info.name
+	ig.p(" = ")
+	ig.providerCallExpr(c)
+	ig.p("\n\t\treturn\n\t})()\n")
+}
+
+// fail emits the body of a branch taken when a call fails with the error
+// errExpr. It runs the first n cleanup functions in reverse order, then
+// returns the error, or panics with it if the injector is marked
+// //wire:panics.
+func (ig *injectorGen) fail(injectSig outputSignature, n int, errExpr string) {
+	for i := n - 1; i >= 0; i-- {
+		ig.p("\t\t%s()\n", ig.cleanupNames[i])
+	}
+	if ig.kit != nil {
+		ig.p("\t\t%s.Fatal(%s)\n", ig.testVar, errExpr)
+		return
+	}
+	if ig.panics {
+		ig.p("\t\tpanic(%s)\n", errExpr)
+		return
+	}
+	if injectSig.out == nil {
+		ig.p("\t\treturn %s\n", errExpr)
+		return
 	}
-	// TODO(light): Use parts of import path to disambiguate.
-	newName := disambiguate(name, func(n string) bool {
-		// Don't let an import take the "err" name. That's annoying.
-		return n == "err" || g.nameInFileScope(n)
-	})
-	g.imports[unvendored] = importInfo{
-		name:    newName,
-		differs: newName != name,
+	ig.p("\t\treturn %s", zeroValue(injectSig.out, ig.g.qualifyPkg))
+	if injectSig.cleanup {
+		ig.p(", nil")
 	}
-	return newName
+	ig.p(", %s\n", errExpr)
 }
 
-func (g *gen) nameInFileScope(name string) bool {
-	for _, other := range g.imports {
-		if other.name == name {
-			return true
-		}
+// noValueCall emits a call to a wire.NoValue provider.
+func (ig *injectorGen) noValueCall(c *call) {
+	if ig.g.instrument {
+		ig.traceProvider(c)
 	}
-	for _, other := range g.values {
-		if other == name {
-			return true
+	if ig.g.otelTrace {
+		ig.startSpan(c)
+	}
+	ig.p("\t%s(", ig.g.providerFunc(c))
+	for i, a := range c.args {
+		if i > 0 {
+			ig.p(", ")
 		}
+		ig.p("%s", ig.arg(a, c.ins[i]))
+	}
+	if c.varargs {
+		ig.p("...")
+	}
+	ig.p(")\n")
+	if ig.g.otelTrace {
+		ig.endSpan()
+	}
+	if ig.g.instrument {
+		ig.p("\t%s()\n", ig.traceVar)
 	}
-	_, obj := g.pkg.Types.Scope().LookupParent(name, token.NoPos)
-	return obj != nil
 }
 
-func (g *gen) qualifyPkg(pkg *types.Package) string {
-	return g.qualifyImport(pkg.Name(), pkg.Path())
+// traceProvider emits the call to wire.TraceProvider made before calling
+// the provider of c. The injector calls the returned function, held in
+// ig.traceVar, once the provider returns.
+func (ig *injectorGen) traceProvider(c *call) {
+	tok := "="
+	if ig.traceVar == "" {
+		ig.traceVar = disambiguate("done", ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, ig.traceVar)
+		tok = ":="
+	}
+	ig.p("\t%s %s %s(%q)\n", ig.traceVar, tok, ig.g.qualifiedID("wire", "github.com/google/wire", "TraceProvider"), providerLabel(c))
 }
 
-func (g *gen) p(format string, args ...interface{}) {
-	fmt.Fprintf(&g.buf, format, args...)
+// startSpan emits the start of an OpenTelemetry span named for the provider
+// of c. Until endSpan is called, arguments that pass the injector's
+// context.Context pass the span's context instead.
+func (ig *injectorGen) startSpan(c *call) {
+	tok := "="
+	if ig.spanVar == "" {
+		ig.spanVar = disambiguate("span", ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, ig.spanVar)
+		tok = ":="
+	}
+	ctxName := "_"
+	if ig.passesContext(c) {
+		if ig.spanCtx == "" {
+			ig.spanCtx = disambiguate("spanCtx", ig.nameInInjector)
+			ig.extraNames = append(ig.extraNames, ig.spanCtx)
+			tok = ":="
+		}
+		ctxName = ig.spanCtx
+		ig.inSpan = true
+	}
+	tracer := ig.g.qualifiedID("otel", "go.opentelemetry.io/otel", "Tracer")
+	ig.p("\t%s, %s %s %s(%q).Start(%s, %q)\n", ctxName, ig.spanVar, tok, tracer, "wire", ig.paramNames[ig.ctxParam], providerLabel(c))
 }
 
-// injectorGen is the per-injector pass generator state.
-type injectorGen struct {
-	g *gen
-
-	paramNames   []string
-	localNames   []string
-	cleanupNames []string
-	errVar       string
-
-	// discard causes ig.p and ig.writeAST to no-op. Useful to run
-	// generation for side-effects like filling in g.imports.
-	discard bool
+// endSpan emits the end of the span started by startSpan.
+func (ig *injectorGen) endSpan() {
+	ig.p("\t%s.End()\n", ig.spanVar)
+	ig.inSpan = false
 }
 
-// injectPass generates an injector given the output from analysis.
-// The sig passed in should be verified.
-func injectPass(name string, sig *types.Signature, calls []call, set *ProviderSet, doc *ast.CommentGroup, ig *injectorGen) {
-	params := sig.Params()
-	injectSig, err := funcOutput(sig)
-	if err != nil {
-		// This should be checked by the caller already.
-		panic(err)
+// passesContext reports whether the provider call of c passes the
+// injector's context.Context as an argument.
+func (ig *injectorGen) passesContext(c *call) bool {
+	if c.singleton {
+		// Singleton accessors take no arguments.
+		return false
 	}
-	if doc != nil {
-		for _, c := range doc.List {
-			ig.p("%s\n", c.Text)
+	for _, a := range c.args {
+		if a == ig.ctxParam {
+			return true
 		}
 	}
-	ig.p("func %s(", name)
+	return false
+}
+
+// contextParam returns the index of the first of params whose type is
+// context.Context, or -1 if there is none.
+func contextParam(params *types.Tuple) int {
 	for i := 0; i < params.Len(); i++ {
-		if i > 0 {
-			ig.p(", ")
-		}
-		pi := params.At(i)
-		a := pi.Name()
-		if a == "" || a == "_" {
-			a = typeVariableName(pi.Type(), "arg", unexport, ig.nameInInjector)
-		} else {
-			a = disambiguate(a, ig.nameInInjector)
+		named, ok := params.At(i).Type().(*types.Named)
+		if !ok {
+			continue
 		}
-		ig.paramNames = append(ig.paramNames, a)
-		if sig.Variadic() && i == params.Len()-1 {
-			// Keep the varargs signature instead of a slice for the last argument if the
-			// injector is variadic.
-			ig.p("%s ...%s", ig.paramNames[i], types.TypeString(pi.Type().(*types.Slice).Elem(), ig.g.qualifyPkg))
-		} else {
-			ig.p("%s %s", ig.paramNames[i], types.TypeString(pi.Type(), ig.g.qualifyPkg))
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context" {
+			return i
 		}
 	}
-	outTypeString := types.TypeString(injectSig.out, ig.g.qualifyPkg)
-	switch {
-	case injectSig.cleanup && injectSig.err:
-		ig.p(") (%s, func(), error) {\n", outTypeString)
-	case injectSig.cleanup:
-		ig.p(") (%s, func()) {\n", outTypeString)
-	case injectSig.err:
-		ig.p(") (%s, error) {\n", outTypeString)
-	default:
-		ig.p(") %s {\n", outTypeString)
-	}
+	return -1
+}
+
+// tracedCalls reports whether any of calls calls a provider that
+// GenerateOptions.OtelTrace wraps in a span.
+func tracedCalls(calls []call) bool {
 	for i := range calls {
 		c := &calls[i]
-		lname := typeVariableName(c.out, "v", unexport, ig.nameInInjector)
-		ig.localNames = append(ig.localNames, lname)
-		switch c.kind {
-		case structProvider:
-			ig.structProviderCall(lname, c)
-		case funcProviderCall:
-			ig.funcProviderCall(lname, c, injectSig)
-		case valueExpr:
-			ig.valueExpr(lname, c)
-		case selectorExpr:
-			ig.fieldExpr(lname, c)
-		default:
-			panic("unknown kind")
+		if c.kind == interfaceSelect || c.kind == funcProviderCall && !c.lazy {
+			return true
 		}
 	}
-	if len(calls) == 0 {
-		ig.p("\treturn %s", ig.paramNames[set.For(injectSig.out).Arg().Index])
-	} else {
-		ig.p("\treturn %s", ig.localNames[len(calls)-1])
+	return false
+}
+
+// providerLabel returns the name of the provider of c, qualified with its
+// package name, for use in generated messages.
+func providerLabel(c *call) string {
+	return c.pkg.Name() + "." + c.name
+}
+
+// lazyProviderCall emits an accessor function for a wire.Lazy provider.
+// The accessor calls the provider under a sync.Once and caches its results.
+func (ig *injectorGen) lazyProviderCall(lname string, c *call) {
+	base := strings.TrimSuffix(lname, "Func")
+	newName := func(suffix string) string {
+		name := disambiguate(base+suffix, ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, name)
+		return name
 	}
-	if injectSig.cleanup {
-		ig.p(", func() {\n")
-		for i := len(ig.cleanupNames) - 1; i >= 0; i-- {
-			ig.p("\t\t%s()\n", ig.cleanupNames[i])
-		}
-		ig.p("\t}")
+	once, value := newName("Once"), newName("Value")
+	var errName, cleanupName string
+	if c.hasErr {
+		errName = newName("Err")
 	}
-	if injectSig.err {
-		ig.p(", nil")
+	if c.hasCleanup {
+		cleanupName = newName("Cleanup")
 	}
-	ig.p("\n}\n\n")
-}
-
-func (ig *injectorGen) funcProviderCall(lname string, c *call, injectSig outputSignature) {
-	ig.p("\t%s", lname)
-	prevCleanup := len(ig.cleanupNames)
+	ig.p("\tvar (\n")
+	ig.p("\t\t%s %s.Once\n", once, ig.g.qualifyImport("sync", "sync"))
+	ig.p("\t\t%s %s\n", value, types.TypeString(lazyResult(c), ig.g.qualifyPkg))
 	if c.hasCleanup {
-		cname := disambiguate("cleanup", ig.nameInInjector)
-		ig.cleanupNames = append(ig.cleanupNames, cname)
-		ig.p(", %s", cname)
+		ig.p("\t\t%s func()\n", cleanupName)
 	}
 	if c.hasErr {
-		ig.p(", %s", ig.errVar)
+		ig.p("\t\t%s error\n", errName)
 	}
-	ig.p(" := ")
-	ig.p("%s(", ig.g.qualifiedID(c.pkg.Name(), c.pkg.Path(), c.name))
+	ig.p("\t)\n")
+	ig.p("\t%s := %s {\n", lname, types.TypeString(c.out, ig.g.qualifyPkg))
+	ig.p("\t\t%s.Do(func() {\n", once)
+	ig.p("\t\t\t%s", value)
+	if c.hasCleanup {
+		ig.p(", %s", cleanupName)
+	}
+	if c.hasErr {
+		ig.p(", %s", errName)
+	}
+	ig.p(" = %s(", ig.g.providerFunc(c))
 	for i, a := range c.args {
 		if i > 0 {
 			ig.p(", ")
 		}
-		if a < len(ig.paramNames) {
-			ig.p("%s", ig.paramNames[a])
-		} else {
-			ig.p("%s", ig.localNames[a-len(ig.paramNames)])
-		}
+		ig.p("%s", ig.arg(a, c.ins[i]))
 	}
 	if c.varargs {
 		ig.p("...")
 	}
 	ig.p(")\n")
+	ig.p("\t\t})\n")
 	if c.hasErr {
-		ig.p("\tif %s != nil {\n", ig.errVar)
-		for i := prevCleanup - 1; i >= 0; i-- {
-			ig.p("\t\t%s()\n", ig.cleanupNames[i])
-		}
-		ig.p("\t\treturn %s", zeroValue(injectSig.out, ig.g.qualifyPkg))
-		if injectSig.cleanup {
-			ig.p(", nil")
-		}
-		// TODO(light): Give information about failing provider.
-		ig.p(", err\n")
+		ig.p("\t\treturn %s, %s\n", value, errName)
+	} else {
+		ig.p("\t\treturn %s\n", value)
+	}
+	ig.p("\t}\n")
+	if c.hasCleanup {
+		// Waiting on the sync.Once keeps the provider from running
+		// concurrently with or after cleanup.
+		cname := disambiguate("cleanup", ig.nameInInjector)
+		ig.cleanupNames = append(ig.cleanupNames, cname)
+		ig.p("\t%s := func() {\n", cname)
+		ig.p("\t\t%s.Do(func() {})\n", once)
+		ig.p("\t\tif %s != nil {\n", cleanupName)
+		ig.p("\t\t\t%s()\n", cleanupName)
+		ig.p("\t\t}\n")
 		ig.p("\t}\n")
 	}
 }
 
-func (ig *injectorGen) structProviderCall(lname string, c *call) {
+// lazyResult returns the type produced by the provider of a lazy call.
+func lazyResult(c *call) types.Type {
+	return c.out.(*types.Signature).Results().At(0).Type()
+}
+
+func (ig *injectorGen) structProviderCall(lname string, c *call, injectSig outputSignature) {
+	// Read fields tagged with a config key into locals first, since
+	// reading them can fail.
+	configVals := make([]string, len(c.args))
+	for i, a := range c.args {
+		key := c.configKeys[i]
+		if key == "" {
+			continue
+		}
+		name := disambiguate(unexport(c.fieldNames[i]), ig.nameInInjector)
+		ig.extraNames = append(ig.extraNames, name)
+		configVals[i] = name
+		fn := configFunc(c.fieldTypes[i])
+		ig.p("\t%s, %s := %s(%s, %q)\n", name, ig.errVar, ig.g.qualifiedID("wire", "github.com/google/wire", fn), ig.arg(a, c.ins[i]), key)
+		ig.p("\tif %s != nil {\n", ig.errVar)
+		ig.fail(injectSig, len(ig.cleanupNames), ig.providerErr(c))
+		ig.p("\t}\n")
+	}
 	ig.p("\t%s", lname)
 	ig.p(" := ")
 	if _, ok := c.out.(*types.Pointer); ok {
@@ -720,10 +3248,14 @@ func (ig *injectorGen) structProviderCall(lname string, c *call) {
 	ig.p("%s{\n", ig.g.qualifiedID(c.pkg.Name(), c.pkg.Path(), c.name))
 	for i, a := range c.args {
 		ig.p("\t\t%s: ", c.fieldNames[i])
-		if a < len(ig.paramNames) {
-			ig.p("%s", ig.paramNames[a])
-		} else {
-			ig.p("%s", ig.localNames[a-len(ig.paramNames)])
+		switch {
+		case configVals[i] == "":
+			ig.p("%s", ig.arg(a, c.ins[i]))
+		case types.Identical(c.fieldTypes[i], c.fieldTypes[i].Underlying()):
+			ig.p("%s", configVals[i])
+		default:
+			// The field has a named type, so convert the value read.
+			ig.p("%s(%s)", types.TypeString(c.fieldTypes[i], ig.g.qualifyPkg), configVals[i])
 		}
 		ig.p(",\n")
 	}
@@ -731,7 +3263,105 @@ func (ig *injectorGen) structProviderCall(lname string, c *call) {
 }
 
 func (ig *injectorGen) valueExpr(lname string, c *call) {
-	ig.p("\t%s := %s\n", lname, ig.g.values[c.valueExpr])
+	if inlinesConst(c, ig.g.pkg.PkgPath) {
+		ig.p("\t%s := %s\n", lname, ig.g.constLiteral(c.valueConst, c.out))
+		return
+	}
+	if !c.valueVar && !c.valueCall && ig.kit == nil {
+		ig.p("\t%s := %s\n", lname, ig.g.values[c.valueExpr])
+		return
+	}
+	if types.Identical(c.valueTypeInfo.TypeOf(c.valueExpr), c.out) {
+		ig.p("\t%s := ", lname)
+	} else {
+		ig.p("\tvar %s %s = ", lname, types.TypeString(c.out, ig.g.qualifyPkg))
+	}
+	ig.writeAST(c.valueTypeInfo, c.valueExpr)
+	ig.p("\n")
+}
+
+// inlinesConst reports whether injectors in the package at pkgPath write
+// the value of c as a literal. This is the case for a constant value whose
+// type can be named in that package, so the expression need not be
+// accessible from it.
+func inlinesConst(c *call, pkgPath string) bool {
+	if c.kind != valueExpr || c.valueConst == nil {
+		return false
+	}
+	switch c.valueConst.Kind() {
+	case constant.Bool, constant.String, constant.Int, constant.Float:
+	default:
+		return false
+	}
+	switch t := c.out.(type) {
+	case *types.Basic:
+		return true
+	case *types.Named:
+		obj := t.Obj()
+		return obj.Pkg() != nil && (obj.Exported() || obj.Pkg().Path() == pkgPath)
+	default:
+		return false
+	}
+}
+
+// constLiteral returns an expression of type t for the constant v. The
+// literal is converted to t unless t is the literal's default type.
+func (g *gen) constLiteral(v constant.Value, t types.Type) string {
+	var lit string
+	var def types.Type
+	switch v.Kind() {
+	case constant.Bool:
+		lit, def = v.ExactString(), types.Typ[types.Bool]
+	case constant.String:
+		lit, def = v.ExactString(), types.Typ[types.String]
+	case constant.Int:
+		lit, def = v.ExactString(), types.Typ[types.Int]
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		lit, def = strconv.FormatFloat(f, 'g', -1, 64), types.Typ[types.Float64]
+		if !strings.ContainsAny(lit, ".e") {
+			lit += ".0"
+		}
+	default:
+		panic("unreachable")
+	}
+	if types.Identical(t, def) {
+		return lit
+	}
+	return types.TypeString(t, g.qualifyPkg) + "(" + lit + ")"
+}
+
+// groupSlice emits a slice literal of the members of a wire.Group.
+func (ig *injectorGen) groupSlice(lname string, c *call) {
+	elem := c.out.Underlying().(*types.Slice).Elem()
+	ig.p("\t%s := %s{", lname, types.TypeString(c.out, ig.g.qualifyPkg))
+	for i, a := range c.args {
+		if i > 0 {
+			ig.p(", ")
+		}
+		ig.p("%s", ig.arg(a, elem))
+	}
+	ig.p("}\n")
+}
+
+// implementStruct emits the struct generated for a wire.Implement provider,
+// holding its components.
+func (ig *injectorGen) implementStruct(lname string, c *call) {
+	ig.p("\tvar %s %s = &%s{\n", lname, types.TypeString(c.out, ig.g.qualifyPkg), ig.g.implements[implementKey(c)])
+	for i, f := range implementFields(c) {
+		ig.p("\t\t%s: %s,\n", f, ig.arg(c.args[i], c.ins[i]))
+	}
+	ig.p("\t}\n")
+}
+
+// derefExpr emits the conversion of a struct to a pointer to it, or of a
+// pointer to a struct to the struct, added by GenerateOptions.AutoDeref.
+func (ig *injectorGen) derefExpr(lname string, c *call) {
+	if _, ok := c.out.(*types.Pointer); ok {
+		ig.p("\t%s := &%s\n", lname, ig.arg(c.args[0], c.ins[0]))
+	} else {
+		ig.p("\t%s := *%s\n", lname, ig.arg(c.args[0], c.ins[0]))
+	}
 }
 
 func (ig *injectorGen) fieldExpr(lname string, c *call) {
@@ -747,6 +3377,101 @@ func (ig *injectorGen) fieldExpr(lname string, c *call) {
 	}
 }
 
+// arg returns the expression for the value at index a, using the same
+// numbering as call.args, to be used where a value of type want is needed.
+func (ig *injectorGen) arg(a int, want types.Type) string {
+	var name string
+	switch {
+	case ig.inSpan && a == ig.ctxParam:
+		name = ig.spanCtx
+	case a < len(ig.paramNames):
+		name = ig.paramNames[a]
+	default:
+		name = ig.localNames[a-len(ig.paramNames)]
+	}
+	return ig.g.convertExpr(name, ig.argTypes[a], want)
+}
+
+// addressable returns the name of a variable of type t holding the value at
+// index a, declaring one if the value has a different type.
+func (ig *injectorGen) addressable(a int, t types.Type) string {
+	if types.Identical(ig.argTypes[a], t) {
+		return ig.arg(a, t)
+	}
+	name := typeVariableName(t, "v", unexport, ig.nameInInjector)
+	ig.localNames = append(ig.localNames, name)
+	ig.p("\tvar %s %s = %s\n", name, types.TypeString(t, ig.g.qualifyPkg), ig.arg(a, t))
+	return name
+}
+
+// typeAssertExpr emits a checked type assertion for a wire.As provider.
+func (ig *injectorGen) typeAssertExpr(lname string, c *call, injectSig outputSignature) {
+	okName := disambiguate("ok", ig.nameInInjector)
+	ig.extraNames = append(ig.extraNames, okName)
+	v := ig.arg(c.args[0], c.ins[0])
+	ig.p("\t%s, %s := %s.(%s)\n", lname, okName, v, types.TypeString(c.out, ig.g.qualifyPkg))
+	ig.p("\tif !%s {\n", okName)
+	errExpr := fmt.Sprintf("%s.Errorf(\"interface conversion: %%T is not %s\", %s)", ig.g.qualifyImport("fmt", "fmt"), types.TypeString(c.out, nil), v)
+	ig.fail(injectSig, len(ig.cleanupNames), errExpr)
+	ig.p("\t}\n")
+}
+
+// interfaceSelect emits a call to the selector of a wire.BindInterface
+// provider, followed by a checked assertion of its result to the interface.
+func (ig *injectorGen) interfaceSelect(lname string, c *call, injectSig outputSignature) {
+	if ig.g.instrument {
+		ig.traceProvider(c)
+	}
+	if ig.g.otelTrace {
+		ig.startSpan(c)
+	}
+	selected := disambiguate("selected", ig.nameInInjector)
+	ig.extraNames = append(ig.extraNames, selected)
+	okName := disambiguate("ok", ig.nameInInjector)
+	ig.extraNames = append(ig.extraNames, okName)
+	ig.p("\t%s", selected)
+	if c.selectErr {
+		ig.p(", %s", ig.errVar)
+	}
+	ig.p(" := %s(", ig.g.qualifiedID(c.pkg.Name(), c.pkg.Path(), c.name))
+	for i, a := range c.args {
+		if i > 0 {
+			ig.p(", ")
+		}
+		ig.p("%s", ig.arg(a, c.ins[i]))
+	}
+	if c.varargs {
+		ig.p("...")
+	}
+	ig.p(")\n")
+	if ig.g.otelTrace {
+		ig.endSpan()
+	}
+	if ig.g.instrument {
+		ig.p("\t%s()\n", ig.traceVar)
+	}
+	if c.selectErr {
+		ig.p("\tif %s != nil {\n", ig.errVar)
+		ig.fail(injectSig, len(ig.cleanupNames), ig.providerErr(c))
+		ig.p("\t}\n")
+	}
+	ts := types.TypeString(c.out, ig.g.qualifyPkg)
+	ig.p("\t%s, %s := %s.(%s)\n", lname, okName, selected, ts)
+	ig.p("\tif !%s {\n", okName)
+	errExpr := fmt.Sprintf("%s.Errorf(\"selector %s returned %%T, which does not implement %s\", %s)", ig.g.qualifyImport("fmt", "fmt"), providerLabel(c), types.TypeString(c.out, nil), selected)
+	ig.fail(injectSig, len(ig.cleanupNames), errExpr)
+	ig.p("\t}\n")
+}
+
+// inputName picks the name of an injector parameter or receiver, keeping
+// its declared name where possible.
+func (ig *injectorGen) inputName(v *types.Var) string {
+	if name := v.Name(); name != "" && name != "_" {
+		return disambiguate(name, ig.nameInInjector)
+	}
+	return typeVariableName(v.Type(), "arg", unexport, ig.nameInInjector)
+}
+
 // nameInInjector reports whether name collides with any other identifier
 // in the current injector.
 func (ig *injectorGen) nameInInjector(name string) bool {
@@ -768,6 +3493,11 @@ func (ig *injectorGen) nameInInjector(name string) bool {
 			return true
 		}
 	}
+	for _, l := range ig.extraNames {
+		if l == name {
+			return true
+		}
+	}
 	return ig.g.nameInFileScope(name)
 }
 
@@ -778,9 +3508,21 @@ func (ig *injectorGen) p(format string, args ...interface{}) {
 	ig.g.p(format, args...)
 }
 
+func (ig *injectorGen) writeAST(info *types.Info, node ast.Node) {
+	node = ig.g.rewritePkgRefs(info, node)
+	if ig.discard {
+		return
+	}
+	ig.g.printNode(node)
+}
+
 // zeroValue returns the shortest expression that evaluates to the zero
 // value for the given type.
 func zeroValue(t types.Type, qf types.Qualifier) string {
+	if isTypeParam(t) {
+		// The underlying type of a type parameter is its constraint.
+		return "*new(" + types.TypeString(t, qf) + ")"
+	}
 	switch u := t.Underlying().(type) {
 	case *types.Array, *types.Struct:
 		return types.TypeString(t, qf) + "{}"
@@ -793,6 +3535,8 @@ func zeroValue(t types.Type, qf types.Qualifier) string {
 			return "0"
 		case info&types.IsString != 0:
 			return `""`
+		case u.Kind() == types.UnsafePointer:
+			return "nil"
 		default:
 			panic("unreachable")
 		}
@@ -803,6 +3547,21 @@ func zeroValue(t types.Type, qf types.Qualifier) string {
 	}
 }
 
+// typedZeroValue returns the shortest expression for the zero value of t
+// that has type t, as used in a compile-time interface assertion.
+func typedZeroValue(t types.Type, qf types.Qualifier) string {
+	zero := zeroValue(t, qf)
+	if strings.HasSuffix(zero, "{}") {
+		// Composite literals are already typed.
+		return zero
+	}
+	ts := types.TypeString(t, qf)
+	if _, ok := t.(*types.Named); ok {
+		return ts + "(" + zero + ")"
+	}
+	return "(" + ts + ")(" + zero + ")"
+}
+
 // typeVariableName invents a disambiguated variable name derived from the type name.
 // If no name can be derived from the type, defaultName is used.
 // transform is used to transform the derived name(s) (including defaultName);
@@ -811,9 +3570,10 @@ func zeroValue(t types.Type, qf types.Qualifier) string {
 // names is unambiguous, it used; otherwise, the first derived name is
 // disambiguated using disambiguate().
 func typeVariableName(t types.Type, defaultName string, transform func(string) string, collides func(string) bool) string {
-	if p, ok := t.(*types.Pointer); ok {
+	if p, ok := unalias(t).(*types.Pointer); ok {
 		t = p.Elem()
 	}
+	t = unalias(t)
 	var names []string
 	switch t := t.(type) {
 	case *types.Basic:
@@ -922,6 +3682,49 @@ func disambiguate(name string, collides func(string) bool) string {
 	}
 }
 
+// callAccessibleFrom reports an error if the code for c would refer to an
+// unexported provider, struct type, or field of a package other than
+// wantPkg. Provider sets can include such providers when they are declared
+// in the provider's own package.
+func callAccessibleFrom(c *call, wantPkg string) error {
+	if c.pkg == nil || c.pkg.Path() == wantPkg {
+		return nil
+	}
+	switch c.kind {
+	case funcProviderCall, interfaceSelect:
+		if !ast.IsExported(c.name) {
+			return fmt.Errorf("provider %s.%s is unexported and cannot be called from package %s", c.pkg.Path(), c.name, wantPkg)
+		}
+	case structProvider:
+		if !ast.IsExported(c.name) {
+			return fmt.Errorf("struct provider %s.%s is unexported and cannot be used from package %s", c.pkg.Path(), c.name, wantPkg)
+		}
+		for _, f := range c.fieldNames {
+			if !ast.IsExported(f) {
+				return fmt.Errorf("field %s of struct provider %s.%s is unexported and cannot be set from package %s", f, c.pkg.Path(), c.name, wantPkg)
+			}
+		}
+	case selectorExpr:
+		if !ast.IsExported(c.name) {
+			return fmt.Errorf("field %s of %s is unexported and cannot be read from package %s", c.name, c.pkg.Path(), wantPkg)
+		}
+	case implementStruct:
+		obj := c.out.(*types.Named).Obj()
+		if obj.Pkg() == nil || obj.Pkg().Path() == wantPkg {
+			return nil
+		}
+		if !obj.Exported() {
+			return fmt.Errorf("interface %s.%s is unexported and cannot be implemented in package %s", obj.Pkg().Path(), obj.Name(), wantPkg)
+		}
+		for _, m := range c.implement {
+			if !ast.IsExported(m.Name) {
+				return fmt.Errorf("method %s of %s.%s is unexported and cannot be implemented in package %s", m.Name, obj.Pkg().Path(), obj.Name(), wantPkg)
+			}
+		}
+	}
+	return nil
+}
+
 // accessibleFrom reports whether node can be copied to wantPkg without
 // violating Go visibility rules.
 func accessibleFrom(info *types.Info, node ast.Node, wantPkg string) error {
@@ -17,16 +17,25 @@ package wire
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"go/ast"
 	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
 	"go/types"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -42,8 +51,8 @@ func TestWire(t *testing.T) {
 		t.Fatal(err)
 	}
 	// The marker function package source is needed to have the test cases
-	// type check. loadTestCase places this file at the well-known import path.
-	wireGo, err := ioutil.ReadFile(filepath.Join("..", "..", "wire.go"))
+	// type check. loadTestCase places these files at the well-known import path.
+	wireSrc, err := readWirePackage()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,7 +62,7 @@ func TestWire(t *testing.T) {
 		if !ent.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
 			continue
 		}
-		test, err := loadTestCase(filepath.Join(testRoot, name), wireGo)
+		test, err := loadTestCase(filepath.Join(testRoot, name), wireSrc)
 		if err != nil {
 			t.Error(err)
 			continue
@@ -165,6 +174,1895 @@ func TestWire(t *testing.T) {
 	}
 }
 
+func TestGenerateDeterministic(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MultipleSimilarPackages")
+	defer cleanup()
+
+	var first []byte
+	for i := 0; i < 5; i++ {
+		gen := generateOne(t, wd, env, test.pkg, nil)
+		if i == 0 {
+			first = gen.Content
+			continue
+		}
+		if !bytes.Equal(gen.Content, first) {
+			diff := cmp.Diff(strings.Split(string(first), "\n"), strings.Split(string(gen.Content), "\n"))
+			t.Fatalf("Generate call %d produced different output than the first call:\n%s", i+1, diff)
+		}
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+	}{
+		{"MultipleMissingInputs", new(*MissingProviderError)},
+		{"Cycle", new(*CycleError)},
+		{"MultipleBindings", new(*ConflictError)},
+		{"InjectorMissingError", new(*SignatureError)},
+		{"PackageTypeError", new(*PackageError)},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			tc, wd, env, cleanup := materializeTestCase(t, test.name)
+			defer cleanup()
+			gens, errs := Generate(context.Background(), wd, env, []string{tc.pkg}, nil)
+			for _, gen := range gens {
+				errs = append(errs, gen.Errs...)
+			}
+			if len(errs) == 0 {
+				t.Fatal("Generate succeeded; want errors")
+			}
+			for _, err := range errs {
+				if !errors.As(err, test.target) {
+					t.Errorf("errors.As(%q, %T) = false; want true", err, test.target)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictExports(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "ExportedSetUnexportedProvider")
+	defer cleanup()
+	for _, strict := range []bool{false, true} {
+		gens, errs := Generate(context.Background(), wd, env, []string{"example.com/bar"}, &GenerateOptions{StrictExports: strict})
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		if len(gens) != 1 {
+			t.Fatalf("Generate returned %d results; want 1", len(gens))
+		}
+		if !strict {
+			if len(gens[0].Errs) > 0 {
+				t.Errorf("StrictExports = false: Generate errors = %v; want none", gens[0].Errs)
+			}
+			continue
+		}
+		if len(gens[0].Errs) != 1 {
+			t.Fatalf("StrictExports = true: Generate errors = %v; want 1 error", gens[0].Errs)
+		}
+		if got := gens[0].Errs[0].Error(); !strings.Contains(got, "exported provider set Set includes unexported provider newBaz") {
+			t.Errorf("StrictExports = true: error = %q; want it to mention Set and newBaz", got)
+		}
+	}
+}
+
+func TestGenerateTests(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "TestPackageInjector")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{"example.com/bar"}, &GenerateOptions{Tests: true})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := map[string]string{
+		"wire_gen_test.go":          "func injectTestStore() *Store {",
+		"wire_gen_external_test.go": "func injectExternalStore() *bar.Store {",
+	}
+	for _, gen := range gens {
+		if len(gen.Errs) > 0 {
+			t.Fatalf("%s: %v", gen.PkgPath, gen.Errs)
+		}
+		if len(gen.Content) == 0 {
+			continue
+		}
+		name := filepath.Base(gen.OutputPath)
+		injector, ok := want[name]
+		if !ok {
+			t.Errorf("generated unexpected file %s", name)
+			continue
+		}
+		delete(want, name)
+		if !bytes.Contains(gen.Content, []byte(injector)) {
+			t.Errorf("%s does not declare %q:\n%s", name, injector, gen.Content)
+		}
+		if err := gen.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name := range want {
+		t.Errorf("did not generate %s", name)
+	}
+	if !*record {
+		return
+	}
+	// Run the tests that use the generated injectors.
+	cmd := exec.Command(filepath.Join(build.Default.GOROOT, "bin", "go"), "test", "example.com/bar")
+	cmd.Dir = wd
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test: %v; output:\n%s", err, out)
+	}
+}
+
+func TestGenerateTestKit(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "TestKit")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{"example.com/foo"}, &GenerateOptions{Tests: true})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := map[string]string{
+		"wire_gen.go":      "func initApp(cfg *Config) (*App, func(), error) {",
+		"wire_gen_test.go": "func (k *InitAppTestKit) Build(t *testing.T, cfg *Config) *App {",
+	}
+	for _, gen := range gens {
+		if len(gen.Errs) > 0 {
+			t.Fatalf("%s: %v", gen.PkgPath, gen.Errs)
+		}
+		if len(gen.Content) == 0 {
+			continue
+		}
+		name := filepath.Base(gen.OutputPath)
+		decl, ok := want[name]
+		if !ok {
+			t.Errorf("generated unexpected file %s", name)
+			continue
+		}
+		delete(want, name)
+		if !bytes.Contains(gen.Content, []byte(decl)) {
+			t.Errorf("%s does not declare %q:\n%s", name, decl, gen.Content)
+		}
+		if err := gen.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name := range want {
+		t.Errorf("did not generate %s", name)
+	}
+	if !*record {
+		return
+	}
+	// Run the table-driven test that uses the generated kit.
+	cmd := exec.Command(filepath.Join(build.Default.GOROOT, "bin", "go"), "test", "example.com/foo")
+	cmd.Dir = wd
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test: %v; output:\n%s", err, out)
+	}
+}
+
+func TestGenerateMainFunc(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "MainFunc")
+	defer cleanup()
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantOut  string
+	}{
+		{
+			name:    "Default",
+			want:    "func main() {",
+			wantOut: "Hello, World!\ncleanup\n",
+		},
+		{
+			name:     "Template",
+			template: "func {{.Func}}() {\n\t{{.Value}}, {{.Cleanup}}, _ := {{.Injector}}()\n\tdefer {{.Cleanup}}()\n\t{{import \"fmt\"}}.Println({{.Value}}.Config.Greeting + \"!\")\n}\n",
+			want:     `fmt.Println(app.Config.Greeting + "!")`,
+			wantOut:  "Hello, World!!\ncleanup\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := &GenerateOptions{MainFunc: "main", MainTemplate: test.template}
+			gen := generateOne(t, wd, env, "example.com/app", opts)
+			if !bytes.Contains(gen.Content, []byte(test.want)) {
+				t.Fatalf("generated code does not contain %q:\n%s", test.want, gen.Content)
+			}
+			if !*record {
+				return
+			}
+			if out := runGenerated(t, []GenerateResult{gen}, wd, env, "example.com/app"); out != test.wantOut {
+				t.Errorf("go run output = %q; want %q", out, test.wantOut)
+			}
+		})
+	}
+
+	opts := &GenerateOptions{MainFunc: "main", MainTemplate: "{{.Func"}
+	if _, errs := Generate(context.Background(), wd, env, []string{"example.com/app"}, opts); len(errs) == 0 {
+		t.Error("Generate with an invalid main template succeeded; want error")
+	}
+}
+
+func TestGenerateErrorOptions(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "ReturnError")
+	defer cleanup()
+	tests := []struct {
+		name string
+		opts *GenerateOptions
+		want string
+	}{
+		{
+			name: "WrapErrors",
+			opts: &GenerateOptions{WrapErrors: true},
+			want: `return 0, fmt.Errorf("provider main.provideFoo: %w", err)`,
+		},
+		{
+			name: "StructuredErrors",
+			opts: &GenerateOptions{StructuredErrors: true, WrapErrors: true},
+			want: `return 0, &wire.ProviderError{Provider: "provideFoo", Package: "example.com/foo", Cause: err}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gen := generateOne(t, wd, env, test.pkg, tc.opts)
+			if !bytes.Contains(gen.Content, []byte(tc.want)) {
+				t.Fatalf("generated code does not contain %q:\n%s", tc.want, gen.Content)
+			}
+			if !*record {
+				return
+			}
+			if got := runGenerated(t, []GenerateResult{gen}, wd, env, test.pkg); got != string(test.wantProgramOutput) {
+				t.Errorf("go run output = %q; want %q", got, test.wantProgramOutput)
+			}
+		})
+	}
+}
+
+func TestGenerateGoVersionFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		testCase string
+		opts     *GenerateOptions
+		wantErr  string
+	}{
+		{
+			name:     "WrapErrors",
+			testCase: "ReturnError",
+			opts:     &GenerateOptions{WrapErrors: true, GoVersion: "go1.12"},
+			wantErr:  "inject injectFoo: wrapping provider errors with fmt.Errorf's %w verb requires go1.13, but generated code must compile with go1.12",
+		},
+		{
+			name:     "WrapErrorsSupported",
+			testCase: "ReturnError",
+			opts:     &GenerateOptions{WrapErrors: true, GoVersion: "go1.13"},
+		},
+		{
+			name:     "StructuredErrors",
+			testCase: "ReturnError",
+			opts:     &GenerateOptions{WrapErrors: true, StructuredErrors: true, GoVersion: "go1.12"},
+		},
+		{
+			name:     "TestKitCleanup",
+			testCase: "TestKit",
+			opts:     &GenerateOptions{Tests: true, GoVersion: "go1.13"},
+			wantErr:  "inject initApp: calling cleanup functions from a test kit with testing.T.Cleanup requires go1.14, but generated code must compile with go1.13",
+		},
+		{
+			name:     "TestKitCleanupSupported",
+			testCase: "TestKit",
+			opts:     &GenerateOptions{Tests: true, GoVersion: "go1.14"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, wd, env, cleanup := materializeTestCase(t, test.testCase)
+			defer cleanup()
+			gens, errs := Generate(context.Background(), wd, env, []string{"example.com/foo"}, test.opts)
+			for _, gen := range gens {
+				errs = append(errs, gen.Errs...)
+			}
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			switch {
+			case test.wantErr == "" && len(got) > 0:
+				t.Errorf("Generate errors = %q; want none", got)
+			case test.wantErr != "" && (len(got) != 1 || !strings.HasSuffix(got[0], test.wantErr)):
+				t.Errorf("Generate errors = %q; want one ending in %q", got, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "ValidateAll")
+	defer cleanup()
+	errs := ValidateAll(context.Background(), wd, env, "", []string{test.pkg})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"multiple bindings for *example.com/foo.DB",
+		"inject injectDB: no provider found for *example.com/foo.Config",
+		"inject injectCache: no provider found for *example.com/foo.Config",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ValidateAll returned %d errors; want %d:\n%s", len(got), len(want), strings.Join(got, "\n\n"))
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("ValidateAll error %d = %q; want it to contain %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnformattedSource(t *testing.T) {
+	for _, name := range []string{"Cleanup", "FieldsOfValueStruct", "InjectInput", "ReturnError", "Struct"} {
+		t.Run(name, func(t *testing.T) {
+			test, wd, env, cleanup := materializeTestCase(t, name)
+			defer cleanup()
+			pkgs, errs := load(context.Background(), wd, env, "", []string{test.pkg}, false)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			g := newGen(pkgs[0])
+			injectorFiles, errs := generateInjectors(g, pkgs[0], objectCaches(pkgs, false)[0])
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			copyNonInjectorDecls(g, injectorFiles, pkgs[0].TypesInfo)
+			src := g.frame("")
+			if _, err := parser.ParseFile(token.NewFileSet(), "wire_gen.go", src, parser.ParseComments); err != nil {
+				t.Fatalf("unformatted source does not parse: %v\n%s", err, src)
+			}
+			// The indentation should already match gofmt's.
+			fmtSrc, err := format.Source(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines, fmtLines := codeLines(src), codeLines(fmtSrc)
+			if len(lines) != len(fmtLines) {
+				t.Fatalf("unformatted source has %d lines of code; formatted source has %d", len(lines), len(fmtLines))
+			}
+			for i, line := range lines {
+				indent := line[:len(line)-len(strings.TrimLeft(line, "\t"))]
+				fmtIndent := fmtLines[i][:len(fmtLines[i])-len(strings.TrimLeft(fmtLines[i], "\t"))]
+				if indent != fmtIndent {
+					t.Errorf("unformatted source has line %q; formatted, it is %q", line, fmtLines[i])
+				}
+			}
+		})
+	}
+}
+
+// codeLines returns the lines of src that are neither blank nor top-level
+// comments, which gofmt may add, such as a // +build line.
+func codeLines(src []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "//") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestFormatOutputIndent(t *testing.T) {
+	src := []byte("package foo\n\nfunc f() {\n\tif true {\n\t\treturn\n\t}\n")
+	got, errs := formatOutput(nil, src, "  ")
+	if len(errs) == 0 {
+		t.Fatal("formatOutput did not fail on a missing brace")
+	}
+	want := "package foo\n\nfunc f() {\n  if true {\n    return\n  }\n"
+	if string(got) != want {
+		t.Errorf("formatOutput(...) = %q; want %q", got, want)
+	}
+}
+
+func TestGenerateVisitCall(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "ReturnError")
+	defer cleanup()
+	var visited []CallInfo
+	opts := &GenerateOptions{
+		VisitCall: func(call CallInfo) (before, after string) {
+			visited = append(visited, call)
+			return `println("calling ` + call.Provider + `")`, "_ = " + call.Var
+		},
+	}
+	gen := generateOne(t, wd, env, test.pkg, opts)
+	const want = "\tprintln(\"calling main.provideFoo\")\n\tfoo, err := provideFoo()\n\t_ = foo\n\tif err != nil {\n"
+	if !bytes.Contains(gen.Content, []byte(want)) {
+		t.Fatalf("generated code does not contain %q:\n%s", want, gen.Content)
+	}
+	if len(visited) != 1 {
+		t.Fatalf("VisitCall called %d times; want 1", len(visited))
+	}
+	if got := visited[0]; got.Injector != "injectFoo" || got.Provider != "main.provideFoo" || got.Var != "foo" || types.TypeString(got.Out, nil) != "example.com/foo.Foo" {
+		t.Errorf("VisitCall got %+v", got)
+	}
+}
+
+func TestGenerateInstrumentProviders(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "InstrumentProviders")
+	defer cleanup()
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{InstrumentProviders: true})
+	for _, want := range []string{
+		`done := wire.TraceProvider("main.provideFoo")`,
+		`done = wire.TraceProvider("main.provideBar")`,
+	} {
+		if !bytes.Contains(gen.Content, []byte(want)) {
+			t.Fatalf("generated code does not contain %q:\n%s", want, gen.Content)
+		}
+	}
+	if !*record {
+		return
+	}
+	runs := []struct {
+		tags string
+		want string
+	}{
+		{tags: "", want: "42 <nil>\n"},
+		{tags: "wiredebug", want: "calling provider main.provideFoo\nprovider done main.provideFoo\ncalling provider main.provideBar\nprovider done main.provideBar\n42 <nil>\n"},
+	}
+	for _, run := range runs {
+		if out := runGenerated(t, []GenerateResult{gen}, wd, env, "-tags="+run.tags, test.pkg); out != run.want {
+			t.Errorf("go run -tags=%q output = %q; want %q", run.tags, out, run.want)
+		}
+	}
+}
+
+func TestGenerateOtelTrace(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "OtelTrace")
+	defer cleanup()
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{OtelTrace: true})
+	for _, want := range []string{
+		`"go.opentelemetry.io/otel"`,
+		"spanCtx, span := otel.Tracer(\"wire\").Start(ctx, \"main.provideFoo\")\n\tfoo := provideFoo(spanCtx)\n\tspan.End()\n",
+		"_, span = otel.Tracer(\"wire\").Start(ctx, \"main.provideBar\")\n\tbar, err := provideBar(foo)\n\tspan.End()\n",
+	} {
+		if !bytes.Contains(gen.Content, []byte(want)) {
+			t.Errorf("generated code does not contain %q:\n%s", want, gen.Content)
+		}
+	}
+
+	// Injectors without a context.Context can't start spans.
+	test, wd, env, cleanup = materializeTestCase(t, "InstrumentProviders")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{OtelTrace: true})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != 1 || len(gens[0].Errs) != 1 {
+		t.Fatalf("Generate = %+v; want exactly one result with one error", gens)
+	}
+	const wantErr = "inject injectBar: tracing provider calls with OpenTelemetry requires a context.Context parameter"
+	if got := gens[0].Errs[0].Error(); !strings.Contains(got, wantErr) {
+		t.Errorf("error = %q; want to contain %q", got, wantErr)
+	}
+}
+
+func TestGenerateGroupImports(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MultipleSimilarPackages")
+	defer cleanup()
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{GroupImports: true})
+	const want = "import (\n\t\"fmt\"\n\n\t\"example.com/bar\"\n\t\"example.com/baz\"\n\t\"example.com/foo\"\n)\n"
+	if !bytes.Contains(gen.Content, []byte(want)) {
+		t.Errorf("generated code does not contain grouped imports %q:\n%s", want, gen.Content)
+	}
+}
+
+func TestGenerateNolint(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MultipleSimilarPackages")
+	defer cleanup()
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{Nolint: "errcheck, funlen"})
+	const want = "//nolint:errcheck,funlen\npackage main\n"
+	if !bytes.Contains(gen.Content, []byte(want)) {
+		t.Errorf("generated code does not contain %q:\n%s", want, gen.Content)
+	}
+
+	if _, errs := Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{Nolint: "err check;"}); len(errs) == 0 {
+		t.Error("Generate with an invalid linter name succeeded; want error")
+	}
+}
+
+func TestGenerateImportAliases(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MultipleSimilarPackages")
+	defer cleanup()
+	pkgs := []string{test.pkg}
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		want    []string
+	}{
+		{
+			name:    "Preferred",
+			aliases: map[string]string{"example.com/foo": "fooapi"},
+			want:    []string{"fooapi \"example.com/foo\"", "fooapi.New(", "\t\"example.com/bar\"\n"},
+		},
+		{
+			name:    "SameAsPackage",
+			aliases: map[string]string{"example.com/foo": "foo"},
+			want:    []string{"\t\"example.com/foo\"\n", "foo.New("},
+		},
+		{
+			name:    "Conflicting",
+			aliases: map[string]string{"example.com/foo": "svc", "example.com/bar": "svc"},
+			want:    []string{"svc \"example.com/foo\"", "svc2 \"example.com/bar\"", "svc.New(config)", "svc2.New(barConfig, service)"},
+		},
+		{
+			name:    "ConflictsWithImport",
+			aliases: map[string]string{"example.com/baz": "bar"},
+			want:    []string{"\t\"example.com/bar\"\n", "bar2 \"example.com/baz\"", "bar2.New("},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gen := generateOne(t, wd, env, pkgs[0], &GenerateOptions{ImportAliases: test.aliases})
+			for _, want := range test.want {
+				if !bytes.Contains(gen.Content, []byte(want)) {
+					t.Errorf("generated code does not contain %q:\n%s", want, gen.Content)
+				}
+			}
+		})
+	}
+
+	for _, aliases := range []map[string]string{{"example.com/foo": "foo-api"}, {"example.com/foo": "_"}, {"": "foo"}} {
+		_, errs := Generate(context.Background(), wd, env, pkgs, &GenerateOptions{ImportAliases: aliases})
+		if len(errs) == 0 {
+			t.Errorf("Generate with ImportAliases %v succeeded; want error", aliases)
+		}
+	}
+}
+
+func TestGenerateEmitMarkdown(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MultipleSimilarPackages")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{EmitMarkdown: true})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != 2 {
+		t.Fatalf("Generate returned %d results; want the generated code and the Markdown file", len(gens))
+	}
+	doc := gens[1]
+	if len(doc.Errs) > 0 {
+		t.Fatal(doc.Errs)
+	}
+	if got := filepath.Base(doc.OutputPath); got != "wire_graph.md" {
+		t.Errorf("Markdown file is named %s; want wire_graph.md", got)
+	}
+	if got, want := filepath.Dir(doc.OutputPath), filepath.Dir(gens[0].OutputPath); got != want {
+		t.Errorf("Markdown file is written to %s; want %s", got, want)
+	}
+	for _, want := range []string{
+		"| `newMainService` | `func(MainConfig) *MainService` |\n",
+		"| `New` | `example.com/foo` | `func(*foo.Config) *foo.Service` |\n",
+		"| `MainService` | `example.com/main` | `struct{Foo *foo.Service; Bar *bar.Service; baz *baz.Service}` |\n",
+		"```mermaid\nflowchart LR\n",
+		"\tt7([\"MainConfig\"])\n",
+		"\ti0[[\"newMainService\"]]\n",
+		"\tt4 --> t5\n",
+		"\tt6 --> i0\n",
+	} {
+		if !bytes.Contains(doc.Content, []byte(want)) {
+			t.Errorf("Markdown does not contain %q:\n%s", want, doc.Content)
+		}
+	}
+
+	gens, errs = Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != 1 {
+		t.Errorf("Generate without EmitMarkdown returned %d results; want 1", len(gens))
+	}
+}
+
+func TestGenerateAutoDeref(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "AutoDeref")
+	defer cleanup()
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{AutoDeref: true})
+	for _, want := range []string{"mainConfig := &config\n", "conn, err := Dial(mainConfig)", "mainConn := *conn\n", "client := NewClient(mainConn)"} {
+		if !bytes.Contains(gen.Content, []byte(want)) {
+			t.Errorf("generated code does not contain %q:\n%s", want, gen.Content)
+		}
+	}
+}
+
+func TestStrategyDirective(t *testing.T) {
+	tests := []struct {
+		comments []string
+		want     string
+		wantErr  bool
+	}{
+		{nil, "", false},
+		{[]string{"// NewFoo returns a Foo."}, "", false},
+		{[]string{"//wire:strategy Fast"}, "Fast", false},
+		{[]string{"// NewFoo returns a Foo.", "//", "//wire:strategy\tfast_2 "}, "fast_2", false},
+		{[]string{"//wire:strategyFast"}, "", false},
+		{[]string{"//wire:strategy"}, "", true},
+		{[]string{"//wire:strategy 2fast"}, "", true},
+		{[]string{"//wire:strategy Fast Slow"}, "", true},
+		{[]string{"//wire:strategy Fast", "//wire:strategy Slow"}, "", true},
+	}
+	for _, test := range tests {
+		var doc *ast.CommentGroup
+		if test.comments != nil {
+			doc = new(ast.CommentGroup)
+			for _, c := range test.comments {
+				doc.List = append(doc.List, &ast.Comment{Text: c})
+			}
+		}
+		got, err := strategyDirective(doc)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("strategyDirective(%q) = %q, <nil>; want error", test.comments, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("strategyDirective(%q) = %q, %v; want %q, <nil>", test.comments, got, err, test.want)
+		}
+	}
+}
+
+func TestAmbientDirective(t *testing.T) {
+	tests := []struct {
+		comments []string
+		want     []string
+		wantErr  bool
+	}{
+		{nil, nil, false},
+		{[]string{"// initApp returns an App."}, nil, false},
+		{[]string{"//wire:ambient ctx"}, []string{"ctx"}, false},
+		{[]string{"//wire:ambient ctx\ttracer "}, []string{"ctx", "tracer"}, false},
+		{[]string{"//wire:ambient ctx", "//", "//wire:ambient tracer"}, []string{"ctx", "tracer"}, false},
+		{[]string{"//wire:ambientctx"}, nil, false},
+		{[]string{"//wire:ambient"}, nil, true},
+		{[]string{"//wire:ambient ctx, tracer"}, nil, true},
+	}
+	for _, test := range tests {
+		var doc *ast.CommentGroup
+		if test.comments != nil {
+			doc = new(ast.CommentGroup)
+			for _, c := range test.comments {
+				doc.List = append(doc.List, &ast.Comment{Text: c})
+			}
+		}
+		got, err := ambientDirective(doc)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ambientDirective(%q) = %q, <nil>; want error", test.comments, got)
+			}
+			continue
+		}
+		if err != nil || !cmp.Equal(got, test.want) {
+			t.Errorf("ambientDirective(%q) = %q, %v; want %q, <nil>", test.comments, got, err, test.want)
+		}
+	}
+}
+
+func TestGenerateProviderConstraint(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "ProviderConstraint")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, append(env, "GOOS=plan9"), []string{test.pkg}, &GenerateOptions{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != 1 || len(gens[0].Errs) != 1 {
+		t.Fatalf("Generate = %+v; want exactly one result with one error", gens)
+	}
+	const want = "provider main.newStore is constrained by //wire:build !plan9, which GOOS=plan9 GOARCH="
+	if got := gens[0].Errs[0].Error(); !strings.Contains(got, want) {
+		t.Errorf("Generate error = %q; want it to contain %q", got, want)
+	}
+}
+
+func TestConstraintDirective(t *testing.T) {
+	tests := []struct {
+		comments []string
+		want     string
+		wantErr  bool
+	}{
+		{nil, "", false},
+		{[]string{"// NewFoo returns a Foo."}, "", false},
+		{[]string{"//wire:build linux"}, "linux", false},
+		{[]string{"// NewFoo returns a Foo.", "//", "//wire:build linux && !cgo "}, "linux && !cgo", false},
+		{[]string{"//wire:buildlinux"}, "", false},
+		{[]string{"//wire:build"}, "", true},
+		{[]string{"//wire:build linux &&"}, "", true},
+		{[]string{"//wire:build linux", "//wire:build darwin"}, "", true},
+	}
+	for _, test := range tests {
+		var doc *ast.CommentGroup
+		if test.comments != nil {
+			doc = new(ast.CommentGroup)
+			for _, c := range test.comments {
+				doc.List = append(doc.List, &ast.Comment{Text: c})
+			}
+		}
+		got, err := constraintDirective(doc)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("constraintDirective(%q) = %q, <nil>; want error", test.comments, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("constraintDirective(%q) = %q, %v; want %q, <nil>", test.comments, got, err, test.want)
+		}
+	}
+}
+
+func TestNolintDirective(t *testing.T) {
+	tests := []struct {
+		linters string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" , ", "", false},
+		{"all", "//nolint:all", false},
+		{"errcheck", "//nolint:errcheck", false},
+		{"errcheck,funlen", "//nolint:errcheck,funlen", false},
+		{" errcheck  funlen, go-critic ", "//nolint:errcheck,funlen,go-critic", false},
+		{"errcheck;", "", true},
+		{"//nolint", "", true},
+	}
+	for _, test := range tests {
+		got, err := nolintDirective(test.linters)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("nolintDirective(%q) = %q, <nil>; want error", test.linters, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("nolintDirective(%q) = %q, %v; want %q, <nil>", test.linters, got, err, test.want)
+		}
+	}
+}
+
+func TestGenerateInputsHash(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	dir := filepath.Join(wd, "foo")
+	inputsHash := func() string {
+		t.Helper()
+		gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{InputsHash: true})
+		m := regexp.MustCompile(`(?m)^// wire-inputs-hash: ([0-9a-f]{64})$`).FindSubmatch(gen.Content)
+		if m == nil {
+			t.Fatalf("generated code has no inputs hash:\n%s", gen.Content)
+		}
+		return string(m[1])
+	}
+	edit := func(name, old, new string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(src, []byte(old)) {
+			t.Fatalf("%s does not contain %q", name, old)
+		}
+		if err := ioutil.WriteFile(path, bytes.Replace(src, []byte(old), []byte(new), 1), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orig := inputsHash()
+	edit("foo.go", "return 41\n", "return 40\n")
+	if got := inputsHash(); got != orig {
+		t.Errorf("inputs hash changed from %s to %s after editing a provider body", orig, got)
+	}
+	edit("foo.go", "func provideFoo() Foo {\n\treturn 40\n", "func provideFoo() (Foo, error) {\n\treturn 40, nil\n")
+	edit("wire.go", "func injectFooBar() FooBar {\n\twire.Build(Set)\n\treturn 0\n", "func injectFooBar() (FooBar, error) {\n\twire.Build(Set)\n\treturn 0, nil\n")
+	if got := inputsHash(); got == orig {
+		t.Errorf("inputs hash is still %s after changing provider signatures", got)
+	}
+}
+
+func TestGenerateRelativePattern(t *testing.T) {
+	// The injector in foo uses a provider set declared in package bar.
+	test, wd, env, cleanup := materializeTestCase(t, "Rename")
+	defer cleanup()
+	generate := func(wd, pattern string) GenerateResult {
+		t.Helper()
+		gens, errs := Generate(context.Background(), wd, env, []string{pattern}, &GenerateOptions{})
+		if len(errs) > 0 {
+			t.Fatalf("Generate(%q, %q): %v", wd, pattern, errs)
+		}
+		if len(gens) != 1 || len(gens[0].Errs) > 0 {
+			t.Fatalf("Generate(%q, %q) = %+v; want exactly one result without errors", wd, pattern, gens)
+		}
+		return gens[0]
+	}
+	want := generate(wd, test.pkg)
+	tests := []struct {
+		wd      string
+		pattern string
+	}{
+		{wd, "./foo"},
+		{filepath.Join(wd, "foo"), "."},
+		{filepath.Join(wd, "bar"), "../foo"},
+		{filepath.Join(wd, "bar"), filepath.Join(wd, "foo")},
+	}
+	for _, test := range tests {
+		got := generate(test.wd, test.pattern)
+		if got.PkgPath != want.PkgPath || got.OutputPath != want.OutputPath {
+			t.Errorf("Generate(%q, %q) = package %s at %s; want %s at %s", test.wd, test.pattern, got.PkgPath, got.OutputPath, want.PkgPath, want.OutputPath)
+		}
+		if !bytes.Equal(got.Content, want.Content) {
+			t.Errorf("Generate(%q, %q) content differs from Generate with %q:\n%s", test.wd, test.pattern, want.PkgPath, got.Content)
+		}
+	}
+}
+
+func TestGenerateNoImports(t *testing.T) {
+	// Every provider in Chain is declared in the injector's package, so
+	// the generated files import nothing.
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	for _, opts := range []*GenerateOptions{{}, {OneFilePerInjector: true}} {
+		gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, opts)
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		for _, gen := range gens {
+			if len(gen.Errs) > 0 {
+				t.Fatalf("%s: %v", gen.OutputPath, gen.Errs)
+			}
+			name := filepath.Base(gen.OutputPath)
+			if bytes.Contains(gen.Content, []byte("import")) {
+				t.Errorf("OneFilePerInjector=%t: %s has an import block:\n%s", opts.OneFilePerInjector, name, gen.Content)
+			}
+			formatted, err := format.Source(gen.Content)
+			if err != nil {
+				t.Errorf("OneFilePerInjector=%t: %s is not valid Go: %v\n%s", opts.OneFilePerInjector, name, err, gen.Content)
+				continue
+			}
+			if !bytes.Equal(formatted, gen.Content) {
+				t.Errorf("OneFilePerInjector=%t: %s is not gofmt-ed:\n%s", opts.OneFilePerInjector, name, gen.Content)
+			}
+		}
+	}
+}
+
+func TestGenerateOneFilePerInjector(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "OneFilePerInjector")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{OneFilePerInjector: true})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	wants := []struct {
+		file     string
+		contains []string
+		excludes []string
+	}{
+		{
+			file:     "wire_gen.go",
+			contains: []string{"//go:generate", "package main"},
+			excludes: []string{"import", "func "},
+		},
+		{
+			file:     "wire_gen_injectgreeting.go",
+			contains: []string{"\"example.com/bar\"", "func injectGreeting() bar.Greeting {"},
+			excludes: []string{"//go:generate", "example.com/baz", "func injectCount"},
+		},
+		{
+			file:     "wire_gen_injectcount.go",
+			contains: []string{"\"example.com/baz\"", "func injectCount() baz.Count {"},
+			excludes: []string{"//go:generate", "example.com/bar", "func injectGreeting"},
+		},
+	}
+	if len(gens) != len(wants) {
+		t.Fatalf("Generate returned %d results; want %d", len(gens), len(wants))
+	}
+	for i, want := range wants {
+		gen := gens[i]
+		if len(gen.Errs) > 0 {
+			t.Fatalf("%s: %v", gen.OutputPath, gen.Errs)
+		}
+		if got := filepath.Base(gen.OutputPath); got != want.file {
+			t.Errorf("result %d written to %s; want %s", i, got, want.file)
+		}
+		for _, s := range want.contains {
+			if !bytes.Contains(gen.Content, []byte(s)) {
+				t.Errorf("%s does not contain %q:\n%s", want.file, s, gen.Content)
+			}
+		}
+		for _, s := range want.excludes {
+			if bytes.Contains(gen.Content, []byte(s)) {
+				t.Errorf("%s contains %q:\n%s", want.file, s, gen.Content)
+			}
+		}
+	}
+	if !*record {
+		return
+	}
+	if got := runGenerated(t, gens, wd, env, test.pkg); got != string(test.wantProgramOutput) {
+		t.Errorf("go run output = %q; want %q", got, test.wantProgramOutput)
+	}
+}
+
+func TestGenerateMultipleBinaries(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "MultiBinary")
+	defer cleanup()
+	ctx := context.Background()
+	patterns := []string{"example.com/server", "example.com/worker"}
+	gens, errs := Generate(ctx, wd, env, patterns, &GenerateOptions{})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != len(patterns) {
+		t.Fatalf("Generate returned %d results; want %d", len(gens), len(patterns))
+	}
+	for i, gen := range gens {
+		if len(gen.Errs) > 0 {
+			t.Fatalf("%s: %v", gen.PkgPath, gen.Errs)
+		}
+		if gen.PkgPath != patterns[i] {
+			t.Errorf("result %d is for %s; want %s", i, gen.PkgPath, patterns[i])
+		}
+		if !bytes.Contains(gen.Content, []byte("providers.NewStore()")) {
+			t.Errorf("%s does not call providers.NewStore:\n%s", gen.PkgPath, gen.Content)
+		}
+	}
+
+	// The binaries share the analysis of the provider library.
+	pkgs, errs := load(ctx, wd, env, "", patterns, false)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	caches := objectCaches(pkgs, false)
+	if caches[0] != caches[1] {
+		t.Error("packages loaded together use different object caches")
+	}
+	set := pkgs[0].Imports["example.com/providers"].Types.Scope().Lookup("Set")
+	item0, _ := caches[0].get(set)
+	item1, _ := caches[1].get(set)
+	if item0 != item1 {
+		t.Error("provider set shared by both packages was analyzed twice")
+	}
+}
+
+func TestInjectorFileName(t *testing.T) {
+	tests := []struct {
+		outputFile string
+		name       string
+		want       string
+	}{
+		{"wire_gen.go", "NewApp", "wire_gen_newapp.go"},
+		{"wire_gen_test.go", "newServer", "wire_gen_newserver_test.go"},
+		{"wire_gen_external_test.go", "NewServer", "wire_gen_newserver_external_test.go"},
+	}
+	for _, test := range tests {
+		if got := injectorFileName(test.outputFile, test.name); got != test.want {
+			t.Errorf("injectorFileName(%q, %q) = %q; want %q", test.outputFile, test.name, got, test.want)
+		}
+	}
+}
+
+func TestIgnoredInjectFile(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // empty if src is not an ignored inject file
+	}{
+		{
+			name: "Ignored",
+			src:  "//go:build ignore\n\n//wire:inject\n\npackage foo\n",
+			want: "//go:build wireinject\n\n//wire:inject\n\npackage foo\n",
+		},
+		{
+			name: "PlusBuild",
+			src:  "//go:build ignore\n// +build ignore\n\n//wire:inject\npackage foo\n",
+			want: "//go:build wireinject\n// +build wireinject\n\n//wire:inject\npackage foo\n",
+		},
+		{
+			name: "NotMarked",
+			src:  "//go:build ignore\n\npackage foo\n",
+		},
+		{
+			name: "MarkedAfterPackage",
+			src:  "//go:build ignore\n\npackage foo\n\n//wire:inject\n",
+		},
+		{
+			name: "OtherConstraint",
+			src:  "//go:build linux && ignore\n\n//wire:inject\n\npackage foo\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := ignoredInjectFile([]byte(test.src))
+			if ok != (test.want != "") {
+				t.Fatalf("ignoredInjectFile(...) ok = %t; want %t", ok, test.want != "")
+			}
+			if ok && string(got) != test.want {
+				t.Errorf("ignoredInjectFile(...) = %q; want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsStdImport(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"fmt", true},
+		{"net/http", true},
+		{"example.com/foo", false},
+		{"github.com/google/wire", false},
+		{"gopkg.in/yaml.v2", false},
+	}
+	for _, test := range tests {
+		if got := isStdImport(test.path); got != test.want {
+			t.Errorf("isStdImport(%q) = %t; want %t", test.path, got, test.want)
+		}
+	}
+}
+
+func TestGenerateBuildTags(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "BuildTagSets")
+	defer cleanup()
+	tests := []struct {
+		tags string
+		want string
+	}{
+		{"", "provideProdStore()"},
+		{"dev", "provideDevStore()"},
+		{"dev,extra", "provideDevStore()"},
+		{"extra dev", "provideDevStore()"},
+		{"extra  other,dev", "provideDevStore()"},
+		{"extra", "provideProdStore()"},
+	}
+	for _, tc := range tests {
+		gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, &GenerateOptions{Tags: tc.tags})
+		if len(errs) > 0 {
+			t.Errorf("Tags %q: %v", tc.tags, errs)
+			continue
+		}
+		if len(gens) != 1 || len(gens[0].Errs) > 0 {
+			t.Errorf("Tags %q: Generate = %+v; want exactly one result without errors", tc.tags, gens)
+			continue
+		}
+		if !bytes.Contains(gens[0].Content, []byte(tc.want)) {
+			t.Errorf("Tags %q: generated code does not contain %q:\n%s", tc.tags, tc.want, gens[0].Content)
+		}
+	}
+}
+
+func TestBuildTagsFlag(t *testing.T) {
+	tests := []struct {
+		tags string
+		want string
+	}{
+		{"", "-tags=wireinject"},
+		{"dev", "-tags=wireinject,dev"},
+		{"dev prod", "-tags=wireinject,dev,prod"},
+		{"dev,prod", "-tags=wireinject,dev,prod"},
+		{" dev, prod ,\tit ", "-tags=wireinject,dev,prod,it"},
+	}
+	for _, test := range tests {
+		if got := buildTagsFlag(test.tags); got != test.want {
+			t.Errorf("buildTagsFlag(%q) = %q; want %q", test.tags, got, test.want)
+		}
+	}
+}
+
+func TestGenerateCache(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	cacheDir, err := ioutil.TempDir("", "wire_cache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	opts := &GenerateOptions{CacheDir: cacheDir}
+	generate := func() []byte {
+		t.Helper()
+		return generateOne(t, wd, env, test.pkg, opts).Content
+	}
+
+	want := generate()
+	if len(want) == 0 {
+		t.Fatal("Generate produced no output")
+	}
+
+	// Tamper with the cache entry to detect whether it is used.
+	entryPath := cacheEntryPath(cacheDir, test.pkg)
+	e := readCacheEntry(cacheDir, test.pkg)
+	if e == nil {
+		t.Fatalf("no cache entry written to %s", entryPath)
+	}
+	e.Content = []byte("// from cache\n")
+	if err := writeCacheEntry(cacheDir, e); err != nil {
+		t.Fatal(err)
+	}
+	if got := generate(); !bytes.Equal(got, e.Content) {
+		t.Errorf("Generate with unchanged sources = %q; want cached %q", got, e.Content)
+	}
+
+	// Writing the generated file must not invalidate the entry.
+	if err := ioutil.WriteFile(filepath.Join(wd, "foo", "wire_gen.go"), want, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := generate(); !bytes.Equal(got, e.Content) {
+		t.Errorf("Generate after writing its output = %q; want cached %q", got, e.Content)
+	}
+
+	// Changing a source file must invalidate the entry.
+	fooGo := filepath.Join(wd, "foo", "foo.go")
+	src, err := ioutil.ReadFile(fooGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fooGo, append(src, "\n// changed\n"...), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := generate(); !bytes.Equal(got, want) {
+		t.Errorf("Generate with changed sources = %q; want %q", got, want)
+	}
+}
+
+func TestCheckGenerated(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	ctx := context.Background()
+	outPath := filepath.Join(wd, "foo", "wire_gen.go")
+	check := func(update bool) []string {
+		t.Helper()
+		stale, errs := CheckGenerated(ctx, wd, env, []string{test.pkg}, nil, update)
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		return stale
+	}
+
+	if stale := check(false); !cmp.Equal(stale, []string{outPath}) {
+		t.Errorf("stale files before generating = %q; want %q", stale, outPath)
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Errorf("checking without update wrote %s", outPath)
+	}
+	if stale := check(true); !cmp.Equal(stale, []string{outPath}) {
+		t.Errorf("stale files when updating = %q; want %q", stale, outPath)
+	}
+	if stale := check(false); len(stale) > 0 {
+		t.Errorf("stale files after updating = %q; want none", stale)
+	}
+
+	// Editing the generated file makes it stale again.
+	if err := ioutil.WriteFile(outPath, []byte("package main\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if stale := check(false); !cmp.Equal(stale, []string{outPath}) {
+		t.Errorf("stale files after edit = %q; want %q", stale, outPath)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, done := startWatch(t, ctx, wd, env, test.pkg)
+
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 || len(gens[0].Content) == 0 {
+		t.Fatalf("first result = %+v, %v; want exactly one result with content", gens, errs)
+	}
+
+	// Writing the generated file must not cause another generation.
+	select {
+	case res := <-results:
+		t.Fatalf("Watch regenerated after writing the generated file: %+v", res.gens)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Changing a source file must.
+	appendToFile(t, filepath.Join(wd, "foo", "foo.go"), "\n// changed\n")
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Errorf("result after change = %+v, %v; want exactly one result without errors", gens, errs)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Watch returned %v; want %v", err, context.Canceled)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
+
+func TestWatchAfterListError(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	fooGo := filepath.Join(wd, "foo", "foo.go")
+	src, err := ioutil.ReadFile(fooGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An import that can't be resolved keeps the packages from being
+	// listed.
+	broken := bytes.Replace(src, []byte("package main\n"), []byte("package main\n\nimport _ \"example.com/missing\"\n"), 1)
+	if err := ioutil.WriteFile(fooGo, broken, 0666); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, done := startWatch(t, ctx, wd, env, test.pkg)
+
+	if gens, errs := nextWatchResult(t, results, done); len(errs) == 0 && (len(gens) != 1 || len(gens[0].Errs) == 0) {
+		t.Fatalf("first result = %+v; want an error", gens)
+	}
+	if err := ioutil.WriteFile(fooGo, src, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Errorf("result after fix = %+v, %v; want exactly one result without errors", gens, errs)
+	}
+}
+
+func TestWatchTypeError(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	fooGo := filepath.Join(wd, "foo", "foo.go")
+	src, err := ioutil.ReadFile(fooGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The packages can be listed, but not type-checked.
+	if err := ioutil.WriteFile(fooGo, append(src, "\nvar _ = undefinedThing\n"...), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, done := startWatch(t, ctx, wd, env, test.pkg)
+
+	_, errs := nextWatchResult(t, results, done)
+	const want = "undefined: undefinedThing"
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), want) {
+		t.Fatalf("first result errors = %v; want an error containing %q", errs, want)
+	}
+	if err := ioutil.WriteFile(fooGo, src, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Errorf("result after fix = %+v, %v; want exactly one result without errors", gens, errs)
+	}
+}
+
+func TestWatchIgnoredInjectFile(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "IgnoreBuildInjectFile")
+	defer cleanup()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, done := startWatch(t, ctx, wd, env, test.pkg)
+
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Fatalf("first result = %+v, %v; want exactly one result without errors", gens, errs)
+	}
+	// The inject file is excluded by //go:build ignore, so it is not one
+	// of the package's Go files.
+	appendToFile(t, filepath.Join(wd, "foo", "wire.go"), "\n// changed\n")
+	if gens, errs := nextWatchResult(t, results, done); len(errs) > 0 || len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Errorf("result after change = %+v, %v; want exactly one result without errors", gens, errs)
+	}
+}
+
+// A watchResult holds the arguments of a call to the onResult function
+// passed to Watch.
+type watchResult struct {
+	gens []GenerateResult
+	errs []error
+}
+
+// startWatch runs Watch for pkg with short polling intervals until ctx is
+// done. Each result is written out, so that the watcher sees the generated
+// files, and then sent on results. done receives the error Watch returns.
+func startWatch(t *testing.T, ctx context.Context, wd string, env []string, pkg string) (results chan watchResult, done chan error) {
+	t.Helper()
+	poll, debounce := watchPollInterval, watchDebounce
+	watchPollInterval, watchDebounce = 10*time.Millisecond, 20*time.Millisecond
+	results = make(chan watchResult)
+	done = make(chan error, 1)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		done <- Watch(ctx, wd, env, []string{pkg}, nil, func(gens []GenerateResult, errs []error) {
+			for _, gen := range gens {
+				if len(gen.Errs) > 0 {
+					continue
+				}
+				if err := gen.Commit(); err != nil {
+					t.Error(err)
+				}
+			}
+			select {
+			case results <- watchResult{gens, errs}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	t.Cleanup(func() {
+		// Watch must stop before the intervals are restored.
+		<-stopped
+		watchPollInterval, watchDebounce = poll, debounce
+	})
+	return results, done
+}
+
+// nextWatchResult waits for the next result of a Watch started by
+// startWatch.
+func nextWatchResult(t *testing.T, results chan watchResult, done chan error) ([]GenerateResult, []error) {
+	t.Helper()
+	select {
+	case res := <-results:
+		return res.gens, res.errs
+	case err := <-done:
+		done <- err
+		t.Fatalf("Watch returned early: %v", err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for Watch to generate")
+	}
+	return nil, nil
+}
+
+// appendToFile appends text to the file at path.
+func appendToFile(t *testing.T, path, text string) {
+	t.Helper()
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append(src, text...), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveGraph(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "InterfaceBinding")
+	defer cleanup()
+	g, errs := ResolveGraph(context.Background(), wd, env, "", []string{test.pkg})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(g.Injectors) != 1 {
+		t.Fatalf("got %d injectors; want 1", len(g.Injectors))
+	}
+	ig := g.Injectors[0]
+	if got, want := ig.Injector.String(), `"example.com/foo".injectFooer`; got != want {
+		t.Errorf("Injector = %s; want %s", got, want)
+	}
+	if got, want := ig.Output, "example.com/foo.Fooer"; got != want {
+		t.Errorf("Output = %s; want %s", got, want)
+	}
+	// Positions depend on the temporary GOPATH, so they are only checked
+	// for validity.
+	for _, n := range ig.Nodes {
+		if n.Kind == ProviderNode && !n.Pos.IsValid() {
+			t.Errorf("node %s has invalid position", n.Type)
+		}
+		n.Pos = token.Position{}
+	}
+	want := map[string]*GraphNode{
+		"example.com/foo.Fooer": {
+			Type: "example.com/foo.Fooer",
+			Kind: BindingNode,
+			Deps: []string{"*example.com/foo.Bar"},
+		},
+		"*example.com/foo.Bar": {
+			Type:     "*example.com/foo.Bar",
+			Kind:     ProviderNode,
+			Provider: `"example.com/foo".provideBar`,
+		},
+	}
+	if diff := cmp.Diff(want, ig.Nodes); diff != "" {
+		t.Errorf("Nodes (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveGraphWrappers(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Wrap")
+	defer cleanup()
+	g, errs := ResolveGraph(context.Background(), wd, env, "", []string{test.pkg})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(g.Injectors) != 1 {
+		t.Fatalf("got %d injectors; want 1", len(g.Injectors))
+	}
+	n := g.Injectors[0].Nodes["example.com/foo.Handler"]
+	if n == nil {
+		t.Fatal("no node for example.com/foo.Handler")
+	}
+	want := &GraphNode{
+		Type:     "example.com/foo.Handler",
+		Kind:     BindingNode,
+		Deps:     []string{"*example.com/foo.Base", "example.com/foo.Prefix"},
+		Wrappers: []string{`"example.com/foo".WithPrefix`, `"example.com/foo".WithExclamation`},
+	}
+	if diff := cmp.Diff(want, n); diff != "" {
+		t.Errorf("Handler node (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveGraphAnnotations(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Annotate")
+	defer cleanup()
+	g, errs := ResolveGraph(context.Background(), wd, env, "", []string{test.pkg})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(g.Injectors) != 1 {
+		t.Fatalf("got %d injectors; want 1", len(g.Injectors))
+	}
+	got := make(map[string][]Annotation)
+	for _, n := range g.Injectors[0].Nodes {
+		got[n.Type] = n.Annotations
+	}
+	want := map[string][]Annotation{
+		"example.com/foo.Foo": {
+			{Expr: "expensive", Type: "string", Value: "expensive"},
+			{Expr: "2 * time.Second", Type: "time.Duration", Value: "2000000000"},
+		},
+		"example.com/foo.FooBar": {
+			{Expr: "Cost{Latency: time.Millisecond}", Type: "example.com/foo.Cost"},
+			{Expr: `"test-only"`, Type: "string", Value: "test-only"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("annotations (-want +got):\n%s", diff)
+	}
+
+	// Tools read the graph as JSON.
+	data, err := json.Marshal(g.Injectors[0].Nodes["example.com/foo.Foo"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"Kind":"provider"`, `"Annotations":[{"Expr":"expensive","Type":"string","Value":"expensive"}`} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("JSON encoding %s does not contain %s", data, s)
+		}
+	}
+}
+
+func TestListInjectors(t *testing.T) {
+	tests := []struct {
+		name string
+		want *InjectorInfo
+	}{
+		{
+			name: "Wrap",
+			want: &InjectorInfo{
+				Injector:  &Injector{ImportPath: "example.com/foo", FuncName: "injectServer"},
+				Signature: "func() (*Server, error)",
+				Sets:      []string{"HandlerSet", `wire.Value(Prefix("[log] "))`, "NewServer"},
+			},
+		},
+		{
+			// Injectors are listed even if they can't be solved.
+			name: "WrapCycle",
+			want: &InjectorInfo{
+				Injector:  &Injector{ImportPath: "example.com/foo", FuncName: "injectHandler"},
+				Signature: "func() Handler",
+				Sets:      []string{"NewHandler", "NewLogger", "wire.Wrap(new(Handler), WithLogging)"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc, wd, env, cleanup := materializeTestCase(t, test.name)
+			defer cleanup()
+			infos, errs := ListInjectors(context.Background(), wd, env, "", []string{tc.pkg})
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if len(infos) != 1 {
+				t.Fatalf("ListInjectors returned %d injectors; want 1", len(infos))
+			}
+			got := infos[0]
+			if !got.Pos.IsValid() || filepath.Base(got.Pos.Filename) != "wire.go" {
+				t.Errorf("Pos = %v; want a position in wire.go", got.Pos)
+			}
+			got.Pos = token.Position{}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ListInjectors (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMinimalSet(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "MinimalSet")
+	defer cleanup()
+	refs, errs := MinimalSet(context.Background(), wd, env, "", []string{test.pkg}, "injectGreeter")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	// Positions depend on the temporary GOPATH, so they are only checked
+	// for validity and order.
+	for i := range refs {
+		if !refs[i].Pos.IsValid() {
+			t.Errorf("ref %s has invalid position", refs[i].Type)
+		}
+		if i > 0 && refs[i].Pos.Offset < refs[i-1].Pos.Offset {
+			t.Errorf("ref %s is out of position order", refs[i].Type)
+		}
+		refs[i].Pos = token.Position{}
+	}
+	want := []ProviderRef{
+		{Kind: ProviderNode, Name: `"example.com/foo".provideEnglish`, Type: "*example.com/foo.English"},
+		{Kind: BindingNode, Type: "example.com/foo.Greeter"},
+		{Kind: ValueNode, Type: "example.com/foo.Name"},
+	}
+	if diff := cmp.Diff(want, refs); diff != "" {
+		t.Errorf("MinimalSet (-want +got):\n%s", diff)
+	}
+
+	if _, errs := MinimalSet(context.Background(), wd, env, "", []string{test.pkg}, "injectNothing"); len(errs) == 0 {
+		t.Error("MinimalSet for a missing injector succeeded; want error")
+	}
+}
+
+func TestDiffProviderSets(t *testing.T) {
+	_, wd, env, cleanup := materializeTestCase(t, "ProviderSetDiff")
+	defer cleanup()
+	diff, errs := DiffProviderSets(context.Background(), wd, env, "", "example.com/v1", "example.com/v2", "Set")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	// Positions depend on the temporary GOPATH, so they are only checked
+	// for validity.
+	clearPos := func(s *ProviderSummary) {
+		if !s.Pos.IsValid() {
+			t.Errorf("provider %s has invalid position", s.Name)
+		}
+		s.Pos = token.Position{}
+	}
+	for i := range diff.Added {
+		clearPos(&diff.Added[i])
+	}
+	for i := range diff.Removed {
+		clearPos(&diff.Removed[i])
+	}
+	for i := range diff.Changed {
+		clearPos(&diff.Changed[i].Old)
+		clearPos(&diff.Changed[i].New)
+	}
+	want := &ProviderSetDiff{
+		Added:   []ProviderSummary{{Name: "NewMetrics", Signature: "func() *Metrics"}},
+		Removed: []ProviderSummary{{Name: "NewLogger", Signature: "func() *Logger"}},
+		Changed: []ProviderChange{{
+			Old: ProviderSummary{Name: "NewServer", Signature: "func(*Config, *Logger) *Server"},
+			New: ProviderSummary{Name: "NewServer", Signature: "func(*Config, *Metrics) (*Server, error)"},
+		}},
+	}
+	if d := cmp.Diff(want, diff); d != "" {
+		t.Errorf("DiffProviderSets (-want +got):\n%s", d)
+	}
+
+	if _, errs := DiffProviderSets(context.Background(), wd, env, "", "example.com/v1", "example.com/v2", "Missing"); len(errs) == 0 {
+		t.Error("DiffProviderSets for a missing set succeeded; want error")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    *PackageConfig
+		wantErr string
+	}{
+		{
+			name: "Empty",
+			src:  "# nothing here\n",
+			want: &PackageConfig{},
+		},
+		{
+			name: "AllKeys",
+			src: "---\n" +
+				"header_file: header.txt\n" +
+				"output_file_prefix: 'my_'  # comment\n" +
+				"tags: \"integration prod\"\n" +
+				"go_version: go1.17\n" +
+				"cache_dir: .wirecache # comment\n" +
+				"nolint: errcheck,funlen\n",
+			want: &PackageConfig{
+				HeaderFile:       "header.txt",
+				OutputFilePrefix: "my_",
+				Tags:             "integration prod",
+				GoVersion:        "go1.17",
+				CacheDir:         ".wirecache",
+				Nolint:           "errcheck,funlen",
+			},
+		},
+		{
+			name: "EscapedQuotes",
+			src:  "tags: 'it''s'\noutput_file_prefix: \"a\\\"b\"\n",
+			want: &PackageConfig{Tags: "it's", OutputFilePrefix: `a"b`},
+		},
+		{
+			name:    "UnknownKey",
+			src:     "tags: a\nparallel: true\n",
+			wantErr: `line 2: unknown key "parallel"`,
+		},
+		{
+			name:    "DuplicateKey",
+			src:     "tags: a\ntags: b\n",
+			wantErr: `line 2: duplicate key "tags"`,
+		},
+		{
+			name:    "Nested",
+			src:     "tags:\n  - a\n",
+			wantErr: "line 2: nested values are not supported",
+		},
+		{
+			name:    "Unterminated",
+			src:     "tags: \"a\n",
+			wantErr: "line 1: tags: unterminated string",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseConfig([]byte(test.src))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("parseConfig(...) error = %v; want error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseConfig(...) (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wire_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg, err := LoadConfig(dir)
+	if cfg != nil || err != nil {
+		t.Fatalf("LoadConfig with no file = %+v, %v; want <nil>, <nil>", cfg, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "header.txt"), []byte("// header\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	src := "header_file: header.txt\ntags: prod\ngo_version: go1.17\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "header.txt"); cfg.HeaderFile != want {
+		t.Errorf("HeaderFile = %q; want %q", cfg.HeaderFile, want)
+	}
+	// Options that are already set take precedence over the config.
+	opts := &GenerateOptions{Tags: "dev"}
+	if err := cfg.Merge(opts); err != nil {
+		t.Fatal(err)
+	}
+	want := &GenerateOptions{Header: []byte("// header\n"), Tags: "dev", GoVersion: "go1.17"}
+	if diff := cmp.Diff(want, opts, cmp.AllowUnexported(GenerateOptions{})); diff != "" {
+		t.Errorf("merged options (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateConfigFiles(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	write := func(path, content string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(wd, "header.txt"), "// Copyright header.\n\n")
+	write(filepath.Join(wd, ConfigFileName), "header_file: header.txt\nnolint: all\n")
+	// The package's own file takes precedence.
+	write(filepath.Join(wd, "foo", ConfigFileName), "output_file_prefix: pkg_\nnolint: errcheck\n")
+
+	gen := generateOne(t, wd, env, test.pkg, &GenerateOptions{ConfigFiles: true})
+	if want := filepath.Join(wd, "foo", "pkg_wire_gen.go"); gen.OutputPath != want {
+		t.Errorf("OutputPath = %q; want %q", gen.OutputPath, want)
+	}
+	for _, want := range []string{"// Copyright header.\n", "//nolint:errcheck\n"} {
+		if !bytes.Contains(gen.Content, []byte(want)) {
+			t.Errorf("generated code does not contain %q:\n%s", want, gen.Content)
+		}
+	}
+
+	// Without ConfigFiles, the files are ignored.
+	gen = generateOne(t, wd, env, test.pkg, nil)
+	if bytes.Contains(gen.Content, []byte("nolint")) || !strings.HasSuffix(gen.OutputPath, string(filepath.Separator)+"wire_gen.go") {
+		t.Errorf("Generate without ConfigFiles used wire.yaml: %s\n%s", gen.OutputPath, gen.Content)
+	}
+}
+
+func TestProviderCache(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	pc := NewProviderCache()
+	opts := &GenerateOptions{ProviderCache: pc}
+	generate := func() []byte {
+		t.Helper()
+		return generateOne(t, wd, env, test.pkg, opts).Content
+	}
+	cachedPkg := func() interface{} {
+		t.Helper()
+		e := pc.entries[test.pkg]
+		if e == nil {
+			t.Fatalf("no cache entry for %s", test.pkg)
+		}
+		return e.pkg
+	}
+
+	want := generate()
+	first := cachedPkg()
+	if got := generate(); !bytes.Equal(got, want) {
+		t.Errorf("second Generate = %q; want %q", got, want)
+	}
+	if cachedPkg() != first {
+		t.Error("second Generate reloaded the package; want cached package reused")
+	}
+
+	// Changing a source file must cause the package to be reloaded.
+	fooGo := filepath.Join(wd, "foo", "foo.go")
+	src, err := ioutil.ReadFile(fooGo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fooGo, append(src, "\n// changed\n"...), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got := generate(); !bytes.Equal(got, want) {
+		t.Errorf("Generate after change = %q; want %q", got, want)
+	}
+	if cachedPkg() == first {
+		t.Error("Generate after change reused the stale package")
+	}
+}
+
+func TestProviderCacheConcurrent(t *testing.T) {
+	test, wd, env, cleanup := materializeTestCase(t, "Chain")
+	defer cleanup()
+	gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, nil)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := gens[0].Content
+
+	opts := &GenerateOptions{ProviderCache: NewProviderCache()}
+	const n = 4
+	results := make([][]byte, n)
+	errLists := make([][]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Generate twice so that later calls read entries that
+			// other goroutines may be writing.
+			for j := 0; j < 2; j++ {
+				gens, errs := Generate(context.Background(), wd, env, []string{test.pkg}, opts)
+				if len(errs) > 0 {
+					errLists[i] = errs
+					return
+				}
+				if len(gens) != 1 || len(gens[0].Errs) > 0 {
+					errLists[i] = []error{fmt.Errorf("Generate = %+v; want exactly one result without errors", gens)}
+					return
+				}
+				results[i] = gens[0].Content
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < n; i++ {
+		if len(errLists[i]) > 0 {
+			t.Errorf("goroutine %d: %v", i, errLists[i])
+			continue
+		}
+		if !bytes.Equal(results[i], want) {
+			t.Errorf("goroutine %d: Generate = %q; want %q", i, results[i], want)
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	wireSrc, err := readWirePackage()
+	if err != nil {
+		b.Fatal(err)
+	}
+	test, err := loadTestCase(filepath.Join("testdata", "Chain"), wireSrc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	gopath, err := ioutil.TempDir("", "wire_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	if err := test.materialize(gopath); err != nil {
+		b.Fatal(err)
+	}
+	wd := filepath.Join(gopath, "src", "example.com")
+	env := append(os.Environ(), "GOPATH="+gopath)
+	run := func(b *testing.B, opts *GenerateOptions) {
+		for i := 0; i < b.N; i++ {
+			if _, errs := Generate(context.Background(), wd, env, []string{test.pkg}, opts); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	}
+	b.Run("NoCache", func(b *testing.B) {
+		run(b, nil)
+	})
+	b.Run("ProviderCache", func(b *testing.B) {
+		opts := &GenerateOptions{ProviderCache: NewProviderCache()}
+		// Populate the cache outside of the timed loop.
+		if _, errs := Generate(context.Background(), wd, env, []string{test.pkg}, opts); len(errs) > 0 {
+			b.Fatal(errs)
+		}
+		b.ResetTimer()
+		run(b, opts)
+	})
+}
+
+// materializeTestCase loads the named test case from testdata and writes it
+// to a temporary GOPATH. It returns the working directory and environment
+// to pass to Generate, along with a function that removes the GOPATH.
+func materializeTestCase(t *testing.T, name string) (test *testCase, wd string, env []string, cleanup func()) {
+	t.Helper()
+	wireSrc, err := readWirePackage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test, err = loadTestCase(filepath.Join("testdata", name), wireSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gopath, err := ioutil.TempDir("", "wire_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup = func() { os.RemoveAll(gopath) }
+	gopath, err = filepath.EvalSymlinks(gopath)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	if err := test.materialize(gopath); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	wd = filepath.Join(gopath, "src", "example.com")
+	env = append(os.Environ(), "GOPATH="+gopath)
+	return test, wd, env, cleanup
+}
+
 func goBuildCheck(goToolPath, gopath string, test *testCase) error {
 	// Run `go build`.
 	testExePath := filepath.Join(gopath, "bin", "testprog")
@@ -194,6 +2092,39 @@ func goBuildCheck(goToolPath, gopath string, test *testCase) error {
 	return nil
 }
 
+// generateOne runs Generate for pattern and returns its result, failing
+// the test unless there is exactly one result without errors.
+func generateOne(t *testing.T, wd string, env []string, pattern string, opts *GenerateOptions) GenerateResult {
+	t.Helper()
+	gens, errs := Generate(context.Background(), wd, env, []string{pattern}, opts)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(gens) != 1 || len(gens[0].Errs) > 0 {
+		t.Fatalf("Generate = %+v; want exactly one result without errors", gens)
+	}
+	return gens[0]
+}
+
+// runGenerated writes the files of gens and runs "go run" in wd with args,
+// returning the program's output.
+func runGenerated(t *testing.T, gens []GenerateResult, wd string, env []string, args ...string) string {
+	t.Helper()
+	for _, gen := range gens {
+		if err := gen.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(filepath.Join(build.Default.GOROOT, "bin", "go"), append([]string{"run"}, args...)...)
+	cmd.Dir = wd
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run %s: %v; output:\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
 func TestUnexport(t *testing.T) {
 	tests := []struct {
 		name string
@@ -222,6 +2153,35 @@ func TestUnexport(t *testing.T) {
 	}
 }
 
+func TestParseGoVersion(t *testing.T) {
+	tests := []struct {
+		v       string
+		want    int
+		wantErr bool
+	}{
+		{v: "go1.12", want: 12},
+		{v: "go1.17.3", want: 17},
+		{v: "go1.18rc1", want: 18},
+		{v: "go1.22", want: 22},
+		{v: "1.17", wantErr: true},
+		{v: "go2", wantErr: true},
+		{v: "go1.", wantErr: true},
+		{v: "go1.x", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseGoVersion(test.v)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseGoVersion(%q) = %d, <nil>; want error", test.v, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("parseGoVersion(%q) = %d, %v; want %d, <nil>", test.v, got, err, test.want)
+		}
+	}
+}
+
 func TestExport(t *testing.T) {
 	tests := []struct {
 		name string
@@ -283,6 +2243,12 @@ func TestTypeVariableName(t *testing.T) {
 		{"var in pkg type", barVarInFooPkgT, "", "", map[string]bool{}, "bar"},
 		{"var in pkg type with collision", barVarInFooPkgT, "", "", map[string]bool{"bar": true}, "fooBar"},
 		{"var in pkg type with double collision", barVarInFooPkgT, "", "", map[string]bool{"bar": true, "fooBar": true}, "bar2"},
+		{"chan type", types.NewChan(types.SendRecv, boolT), "v", "", map[string]bool{}, "v"},
+		{"map type", types.NewMap(stringT, boolT), "v", "", map[string]bool{}, "v"},
+		{"map type with collision", types.NewMap(stringT, boolT), "v", "", map[string]bool{"v": true}, "v2"},
+		{"func type", types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(types.NewVar(0, nil, "", stringT)), false), "v", "", map[string]bool{}, "v"},
+		{"pointer to named type", types.NewPointer(fooVarT), "", "", map[string]bool{}, "foo"},
+		{"pointer to named type with collision", types.NewPointer(fooVarT), "", "", map[string]bool{"foo": true}, "foo2"},
 	}
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%s: typeVariableName(%v, %q, %q, %v)", test.description, test.typ, test.defaultName, test.transformAppend, test.collides), func(t *testing.T) {
@@ -300,6 +2266,40 @@ func TestTypeVariableName(t *testing.T) {
 	}
 }
 
+func TestZeroValue(t *testing.T) {
+	var (
+		boolT    = types.Typ[types.Bool]
+		stringT  = types.Typ[types.String]
+		pkg      = types.NewPackage("example.com/foo", "foo")
+		namedMap = types.NewNamed(types.NewTypeName(0, pkg, "Set", nil), types.NewMap(stringT, boolT), nil)
+	)
+	qf := func(p *types.Package) string { return p.Name() }
+	tests := []struct {
+		typ  types.Type
+		want string
+	}{
+		{boolT, "false"},
+		{types.Typ[types.Int64], "0"},
+		{types.Typ[types.Complex128], "0"},
+		{stringT, `""`},
+		{types.Typ[types.UnsafePointer], "nil"},
+		{types.NewArray(boolT, 2), "[2]bool{}"},
+		{types.NewStruct(nil, nil), "struct{}{}"},
+		{types.NewChan(types.RecvOnly, stringT), "nil"},
+		{types.NewMap(stringT, boolT), "nil"},
+		{namedMap, "nil"},
+		{types.NewSignatureType(nil, nil, nil, nil, nil, false), "nil"},
+		{types.NewSlice(stringT), "nil"},
+		{types.NewPointer(stringT), "nil"},
+		{types.NewTypeParam(types.NewTypeName(0, pkg, "T", nil), types.NewInterfaceType(nil, nil)), "*new(T)"},
+	}
+	for _, test := range tests {
+		if got := zeroValue(test.typ, qf); got != test.want {
+			t.Errorf("zeroValue(%v) = %q; want %q", test.typ, got, test.want)
+		}
+	}
+}
+
 func TestDisambiguate(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -464,7 +2464,7 @@ type testCase struct {
 //					expected output from the final compiled program,
 //					missing if wire_errs.txt is present
 //
-func loadTestCase(root string, wireGoSrc []byte) (*testCase, error) {
+func loadTestCase(root string, wireSrc map[string][]byte) (*testCase, error) {
 	name := filepath.Base(root)
 	pkg, err := ioutil.ReadFile(filepath.Join(root, "pkg"))
 	if err != nil {
@@ -490,8 +2490,9 @@ func loadTestCase(root string, wireGoSrc []byte) (*testCase, error) {
 			return nil, fmt.Errorf("load test case %s: %v", name, err)
 		}
 	}
-	goFiles := map[string][]byte{
-		"github.com/google/wire/wire.go": wireGoSrc,
+	goFiles := make(map[string][]byte)
+	for name, data := range wireSrc {
+		goFiles["github.com/google/wire/"+name] = data
 	}
 	err = filepath.Walk(root, func(src string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -505,7 +2506,8 @@ func loadTestCase(root string, wireGoSrc []byte) (*testCase, error) {
 			// The "want" directory should not be included in goFiles.
 			return filepath.SkipDir
 		}
-		if !info.Mode().IsRegular() || filepath.Ext(src) != ".go" {
+		// Text files are included so that test cases can embed them.
+		if !info.Mode().IsRegular() || (filepath.Ext(src) != ".go" && filepath.Ext(src) != ".txt") {
 			return nil
 		}
 		data, err := ioutil.ReadFile(src)
@@ -530,6 +2532,27 @@ func loadTestCase(root string, wireGoSrc []byte) (*testCase, error) {
 	}, nil
 }
 
+// readWirePackage reads the non-test Go files of the wire package, keyed by
+// file name.
+func readWirePackage() (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	src := make(map[string][]byte)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src[filepath.Base(path)] = data
+	}
+	return src, nil
+}
+
 // materialize creates a new GOPATH at the given directory, which may or
 // may not exist.
 func (test *testCase) materialize(gopath string) error {
@@ -0,0 +1,38 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+// A Logger receives the debug messages logged by instrumented injectors.
+// args holds alternating keys and values. *slog.Logger implements Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+// SetLogger sets the logger used by TraceProvider. It has no effect unless
+// the program is built with the wiredebug build tag.
+func SetLogger(l Logger) {
+	setLogger(l)
+}
+
+// TraceProvider is called by injectors generated with provider
+// instrumentation before calling the provider named name. The returned
+// function is called when the provider returns.
+//
+// In programs built with the wiredebug build tag, TraceProvider logs the call
+// and the time the provider took to the logger set by SetLogger. Otherwise,
+// it does nothing.
+func TraceProvider(name string) func() {
+	return traceProvider(name)
+}
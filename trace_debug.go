@@ -0,0 +1,48 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wiredebug
+// +build wiredebug
+
+package wire
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	loggerMu sync.Mutex
+	logger   Logger
+)
+
+func setLogger(l Logger) {
+	loggerMu.Lock()
+	logger = l
+	loggerMu.Unlock()
+}
+
+func traceProvider(name string) func() {
+	loggerMu.Lock()
+	l := logger
+	loggerMu.Unlock()
+	if l == nil {
+		return func() {}
+	}
+	l.Debug("calling provider", "name", name)
+	t0 := time.Now()
+	return func() {
+		l.Debug("provider done", "name", name, "elapsed", time.Since(t0))
+	}
+}
@@ -25,13 +25,15 @@
 // instantiate any needed types.
 package wire
 
+import "context"
+
 // ProviderSet is a marker type that collects a group of providers.
 type ProviderSet struct{}
 
 // NewSet creates a new provider set that includes the providers in its
 // arguments. Each argument is a function value, a provider set, a call to
-// Struct, a call to Bind, a call to Value, a call to InterfaceValue or a call
-// to FieldsOf.
+// Struct, a call to Bind, a call to InterfaceProvide, a call to As, a call to
+// Value, a call to InterfaceValue or a call to FieldsOf.
 //
 // Passing a function value to NewSet declares that the function's first
 // return value type will be provided by calling the function. The arguments
@@ -42,7 +44,10 @@ type ProviderSet struct{}
 // guaranteed to be called before the cleanup function of any of the
 // provider's inputs. If any provider returns an error, the injector function
 // will call all the appropriate cleanup functions and return the error from
-// the injector function.
+// the injector function. Only a last return value of exactly type error is
+// treated this way; other error types, such as a named type implementing
+// error, may be provided and consumed like any other type, as may a first
+// return value of type error.
 //
 // Passing a ProviderSet to NewSet is the same as if the set's contents
 // were passed as arguments to NewSet directly.
@@ -90,6 +95,42 @@ func Build(...interface{}) string {
 	return "implementation not generated, run wire"
 }
 
+// BuildOnce is used in place of Build to declare a package-scoped singleton
+// injector. The generated function builds its value the first time it is
+// called and returns the same value (and error, if any) on every later call.
+// Concurrent calls are safe.
+//
+// An injector using BuildOnce must not have parameters or a receiver and must
+// not return a cleanup function. Marking an injector that uses Build with a
+// //wire:lazy directive has the same effect.
+//
+// Example:
+//
+//	func GetApp() (*App, error) {
+//		panic(wire.BuildOnce(AppSet))
+//	}
+func BuildOnce(...interface{}) string {
+	return "implementation not generated, run wire"
+}
+
+// Use is used in place of Build to declare an injector that calls providers
+// for their side effects instead of building a value. The generated function
+// calls every provider in the given sets, in dependency order, whether or not
+// anything uses its result.
+//
+// An injector using Use must either return nothing or return only an error,
+// which it returns as soon as a provider fails. Its providers must not return
+// cleanup functions.
+//
+// Example:
+//
+//	func RegisterAll(r *Router) error {
+//		panic(wire.Use(RouteSet))
+//	}
+func Use(...interface{}) string {
+	return "implementation not generated, run wire"
+}
+
 // A Binding maps an interface to a concrete type.
 type Binding struct{}
 
@@ -97,6 +138,10 @@ type Binding struct{}
 // the type of iface. iface must be a pointer to an interface type, to must be a
 // pointer to a concrete type.
 //
+// iface may instead point to a non-interface type with the same underlying
+// type as to's type, such as a named type and its underlying type. The
+// generated injector then converts the provided value explicitly.
+//
 // Example:
 //
 //	type Fooer interface {
@@ -118,15 +163,35 @@ func Bind(iface, to interface{}) Binding {
 // See https://github.com/google/wire/issues/120 for details.
 const bindToUsePointer = true
 
+// InterfaceProvide declares that provider's output should be used to satisfy
+// a dependency on the type of iface. iface must be a pointer to an interface
+// type and provider must be a provider function whose output type implements
+// the interface. It is shorthand for including provider in a set along with a
+// call to Bind from the interface to provider's output type.
+//
+// Example:
+//
+//	func NewMyReader() *MyReader { /* ... */ }
+//
+//	var MySet = wire.NewSet(wire.InterfaceProvide(new(io.Reader), NewMyReader))
+func InterfaceProvide(iface, provider interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
 // A ProvidedValue is an expression that is copied to the generated injector.
 type ProvidedValue struct{}
 
 // Value binds an expression to provide the type of the expression.
 // The expression may not be an interface value; use InterfaceValue for that.
+// An expression that calls a function is copied into the generated injector
+// and evaluated each time the injector is called. Other expressions are
+// evaluated once, when the injector's package is initialized.
 //
 // Example:
 //
 //	var MySet = wire.NewSet(wire.Value([]string(nil)))
+//
+//	var PortSet = wire.NewSet(wire.Value(Port(os.Getenv("PORT"))))
 func Value(interface{}) ProvidedValue {
 	return ProvidedValue{}
 }
@@ -143,6 +208,23 @@ func InterfaceValue(typ interface{}, x interface{}) ProvidedValue {
 	return ProvidedValue{}
 }
 
+// EmbedValue binds a package-level variable to provide a type. It is meant
+// for values that cannot be built by a provider, such as an embed.FS filled
+// in by a //go:embed directive. The first argument is a pointer to the type
+// to provide, and the second is the variable, whose type must be assignable
+// to the provided type. Unlike with Value, injectors read the variable
+// directly instead of copying it to a variable in the generated file.
+//
+// Example:
+//
+//	//go:embed templates
+//	var templateFS embed.FS
+//
+//	var MySet = wire.NewSet(wire.EmbedValue(new(embed.FS), templateFS))
+func EmbedValue(typ interface{}, v interface{}) ProvidedValue {
+	return ProvidedValue{}
+}
+
 // A StructProvider represents a named struct.
 type StructProvider struct{}
 
@@ -162,6 +244,10 @@ type StructProvider struct{}
 //  }
 //  var Set = wire.NewSet(wire.Struct(new(S), "MyFoo")) -> inject only S.MyFoo
 //  var Set = wire.NewSet(wire.Struct(new(S), "*")) -> inject all fields
+//
+// A string, int or bool field tagged `wire:"key=NAME"` is instead read from
+// a provided map[string]interface{} under the key NAME; see ConfigString,
+// ConfigInt and ConfigBool.
 func Struct(structType interface{}, fieldNames ...string) StructProvider {
 	return StructProvider{}
 }
@@ -194,3 +280,426 @@ type StructFields struct{}
 func FieldsOf(structType interface{}, fieldNames ...string) StructFields {
 	return StructFields{}
 }
+
+// A LazyProvider is a provider function whose output is built on first use.
+type LazyProvider struct{}
+
+// Lazy declares that provider's output should be built the first time it is
+// needed rather than when the injector runs. provider must be a provider
+// function. For a provider returning T, Lazy provides func() T instead of T;
+// if the provider can return an error, it provides func() (T, error).
+// The provider's arguments are still built when the injector runs, and
+// the provider is called at most once, when the returned function is first
+// invoked. If the provider returns a cleanup function, the injector's cleanup
+// function calls it if the value was built.
+//
+// Example:
+//
+//	func NewCache(cfg *Config) (*Cache, func()) { /* ... */ }
+//
+//	var MySet = wire.NewSet(wire.Lazy(NewCache))
+func Lazy(provider interface{}) LazyProvider {
+	return LazyProvider{}
+}
+
+// An AnnotatedProvider is a provider with metadata attached by Annotate.
+type AnnotatedProvider struct{}
+
+// Annotate attaches metadata to provider for use by tools that analyze
+// provider sets. provider is used exactly as if it were passed on its own:
+// Wire ignores the metadata when generating code, but reports it in the
+// Annotations of the provider's node in the graph returned by
+// ResolveGraph in the internal/wire package. metadata may be any expressions;
+// the values of constants are reported as well as their source.
+//
+// Example:
+//
+//	var MySet = wire.NewSet(wire.Annotate(NewIndex, "expensive"), NewServer)
+func Annotate(provider interface{}, metadata ...interface{}) AnnotatedProvider {
+	return AnnotatedProvider{}
+}
+
+// A NoValueProvider is a provider function that is called only for its side
+// effects.
+type NoValueProvider struct{}
+
+// NoValue declares that fn should be called for its side effects by any
+// injector whose provider set includes the call to NoValue. fn must be a
+// function that returns no values. Its parameters are provided like those of
+// any other provider, and the injector calls it before returning.
+//
+// Example:
+//
+//	func RegisterMetrics(r *prometheus.Registry) { /* ... */ }
+//
+//	var MySet = wire.NewSet(NewRegistry, wire.NoValue(RegisterMetrics))
+func NoValue(fn interface{}) NoValueProvider {
+	return NoValueProvider{}
+}
+
+// A TypeAssertion is a provider that asserts a value to another type.
+type TypeAssertion struct{}
+
+// As declares that the type of iface should be provided by asserting a value
+// of an interface type to it. iface must be a pointer to the type to assert
+// to, usually an interface type. from is an optional pointer to the interface
+// type of the value to assert, which defaults to interface{}.
+//
+// Wire cannot check at generation time that the assertion succeeds, so the
+// injector must return an error: the generated code checks the assertion and
+// returns an error if it fails.
+//
+// Example:
+//
+//	func LoadPlugin() interface{} { /* ... */ }
+//
+//	var MySet = wire.NewSet(LoadPlugin, wire.As(new(Greeter)))
+func As(iface interface{}, from ...interface{}) TypeAssertion {
+	return TypeAssertion{}
+}
+
+// Assert declares that the type of target should be provided by asserting
+// the output of provider to it. target must be a pointer to the type to
+// assert to, and provider must be a provider function whose output is of an
+// interface type. It is shorthand for including provider in a set along with
+// a call to As from provider's output type, for use with libraries that
+// return interfaces.
+//
+// As with As, the injector must return an error: the generated code checks
+// the assertion and returns an error if it fails.
+//
+// Example:
+//
+//	func NewReader() io.Reader { /* ... */ }
+//
+//	var MySet = wire.NewSet(wire.Assert(new(*bytes.Buffer), NewReader))
+func Assert(target, provider interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
+// Implement declares that the interface type iface points to is provided by
+// a generated struct that holds a value of each component type and forwards
+// each method of the interface to the component with a method of the same
+// name and signature. The components are pointers to their types, as in
+// new(*Store), and are provided like any other dependency.
+//
+// Every method of the interface must be implemented by exactly one
+// component, and every component must implement at least one method.
+//
+// Example:
+//
+//	type Service interface {
+//		Get(key string) (string, error)
+//		Log(msg string)
+//	}
+//
+//	var MySet = wire.NewSet(NewStore, NewLogger,
+//		wire.Implement(new(Service), new(*Store), new(*Logger)))
+func Implement(iface interface{}, components ...interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
+// An InterfaceSelector is a provider that picks the implementation of an
+// interface at run time.
+type InterfaceSelector struct{}
+
+// BindInterface declares that the interface type iface points to is provided
+// by calling selector and asserting its result to the interface. selector
+// is a provider function that returns a value of an interface type, usually
+// interface{}, and optionally an error, so that it can pick an
+// implementation at run time, for example based on configuration. Its
+// parameters are provided like those of any other provider.
+//
+// Wire checks that every value selector may return, as written in its
+// return statements, implements the interface. Results of interface type
+// are only checked at run time, so the injector must return an error.
+//
+// Example:
+//
+//	func SelectCache(cfg *Config) interface{} {
+//		if cfg.UseRedis {
+//			return NewRedisCache(cfg.RedisAddr)
+//		}
+//		return NewMemcache(cfg.MemcacheAddrs...)
+//	}
+//
+//	var MySet = wire.NewSet(LoadConfig, wire.BindInterface(new(Cache), SelectCache))
+func BindInterface(iface interface{}, selector interface{}) InterfaceSelector {
+	return InterfaceSelector{}
+}
+
+// A FuncProvider is a provider function whose output is provided as an
+// interface type.
+type FuncProvider struct{}
+
+// ProvideFunc declares that the interface type iface points to is provided
+// by calling fn, which must be a provider function whose output type
+// implements the interface. fn's parameters are provided like those of any
+// other provider, so it can choose an implementation at run time from a
+// configuration value. Unlike InterfaceProvide, fn's output type itself is
+// not provided. Wire checks that the output type implements the interface
+// when generating code.
+//
+// Example:
+//
+//	func NewCache(cfg *Config) *TieredCache {
+//		if cfg.DisableRemoteCache {
+//			return &TieredCache{local: newLocalCache()}
+//		}
+//		return &TieredCache{local: newLocalCache(), remote: newRedisCache(cfg.RedisAddr)}
+//	}
+//
+//	var MySet = wire.NewSet(LoadConfig, wire.ProvideFunc(new(Cache), NewCache))
+func ProvideFunc(iface interface{}, fn interface{}) FuncProvider {
+	return FuncProvider{}
+}
+
+// A GroupProvider is a provider whose output is collected into a slice.
+type GroupProvider struct{}
+
+// Group declares that provider's output is a member of a group, which is
+// provided as the slice type that group points to. provider must be a
+// provider function whose output is assignable to the slice's element type.
+// A provider that depends on the slice type receives the outputs of every
+// member of the group in the provider set, including members from included
+// sets, ordered by the position of the provider functions. The output type of
+// each member is also provided on its own, from the same call, unless the set
+// provides it otherwise or it is the output of several members.
+//
+// Example:
+//
+//	func NewUsersHandler(db *DB) http.Handler { /* ... */ }
+//	func NewOrdersHandler(db *DB) http.Handler { /* ... */ }
+//
+//	func NewMux(handlers []http.Handler) *http.ServeMux { /* ... */ }
+//
+//	var MySet = wire.NewSet(
+//		wire.Group(new([]http.Handler), NewUsersHandler),
+//		wire.Group(new([]http.Handler), NewOrdersHandler),
+//		NewMux)
+func Group(group interface{}, provider interface{}) GroupProvider {
+	return GroupProvider{}
+}
+
+// A Conversion is a provider that adapts a value of one type to another.
+type Conversion struct{}
+
+// Convert declares that the type pointed to by typ should be provided by
+// calling fn on a value of another type. fn must be a function with exactly
+// one parameter, the source type, whose first result is the target type. It
+// may also return an error. The source value is provided like any other
+// provider argument, which makes Convert useful for adapting types from
+// packages that don't know about each other.
+//
+// Example:
+//
+//	func ToLogger(z *zap.Logger) *log.Logger { /* ... */ }
+//
+//	var MySet = wire.NewSet(NewZapLogger, wire.Convert(new(*log.Logger), ToLogger))
+func Convert(typ interface{}, fn interface{}) Conversion {
+	return Conversion{}
+}
+
+// Decorate returns a provider set that is identical to base, except that
+// the decorators take precedence over base's providers for the types they
+// provide. Decorators may be providers, values, interface bindings or struct
+// fields, and each must provide a type that base provides. base itself is
+// unchanged, which makes Decorate useful for overriding parts of a
+// production set in tests.
+//
+// Example:
+//
+//	func NewFakeClock() Clock { /* ... */ }
+//
+//	var TestSet = wire.Decorate(ProdSet, NewFakeClock)
+func Decorate(base ProviderSet, decorators ...interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
+// A ContextProvider is a provider of a context.Context.
+type ContextProvider struct{}
+
+// Context declares that context.Context is provided by calling
+// context.Background, so that providers deep in the graph can take a
+// context.Context without the injector taking one as an argument. Generated
+// injectors hold the context in a local variable named ctx.
+//
+// Example:
+//
+//	func NewDB(ctx context.Context, cfg *Config) (*DB, error) { /* ... */ }
+//
+//	var MySet = wire.NewSet(wire.Context(), LoadConfig, NewDB)
+func Context() ContextProvider {
+	return ContextProvider{}
+}
+
+// ContextFrom is like Context, but the context is provided by calling fn,
+// which must be a function declared at package level.
+//
+// Example:
+//
+//	func ShutdownContext() context.Context { /* ... */ }
+//
+//	var MySet = wire.NewSet(wire.ContextFrom(ShutdownContext), LoadConfig, NewDB)
+func ContextFrom(fn func() context.Context) ContextProvider {
+	return ContextProvider{}
+}
+
+// A Wrapper applies functions to a value provided elsewhere, as
+// middleware does.
+type Wrapper struct{}
+
+// Wrap declares that the value of the type typ points to is passed through
+// each of wrappers in order before it is used, for example to add
+// middleware to a handler. Each wrapper must be a function with exactly one
+// parameter of that type, whose first result is the same type. It may also
+// return an error or a cleanup function, and its other parameters are
+// provided like those of any other provider. The type itself must be
+// provided by another entry of the provider set; values passed to the
+// injector are not wrapped. A provider set may wrap each type only once.
+//
+// Example:
+//
+//	func NewHandler(db *DB) Handler { /* ... */ }
+//	func WithLogging(h Handler, l *log.Logger) Handler { /* ... */ }
+//	func WithMetrics(h Handler) Handler { /* ... */ }
+//
+//	var MySet = wire.NewSet(NewDB, NewLogger, NewHandler,
+//		wire.Wrap(new(Handler), WithLogging, WithMetrics))
+//
+// Injectors built from MySet pass the result of NewHandler to WithLogging,
+// pass that result to WithMetrics, and use its result wherever a Handler
+// is needed.
+func Wrap(typ interface{}, wrappers ...interface{}) Wrapper {
+	return Wrapper{}
+}
+
+// Rename returns a provider set that is identical to set, except that the
+// provider function oldFn is replaced by newFn wherever it appears, including
+// in sets that set includes. oldFn and newFn must have identical signatures.
+// Generated injectors call newFn in place of oldFn.
+//
+// Example:
+//
+//	func NewLocalClient(cfg *Config) (*Client, error) { /* ... */ }
+//
+//	var MySet = wire.Rename(lib.ClientSet, lib.NewClient, NewLocalClient)
+func Rename(set ProviderSet, oldFn, newFn interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
+// Copy returns a provider set that is identical to set, except that
+// generated injectors call each of its provider functions, including those
+// of sets that set includes, through wrapper. This instruments every
+// provider of a set at once, for example to record how long each takes.
+//
+// wrapper must be a generic function declared at package level with the
+// signature
+//
+//	func[T any](name string, fn func() (T, error)) func() (T, error)
+//
+// Since a generic function cannot be used as a value on its own, pass it
+// instantiated with any type argument, as in Record[any]. Generated code
+// instantiates it for the type of each provider instead. The name passed to
+// wrapper is that of the provider, as in "foo.NewDB", and calling fn calls
+// the provider. The function wrapper returns may fail without calling fn, so
+// injectors that use the set must return an error, or be marked
+// //wire:panics; the error is handled like the provider's. Struct
+// providers, values, and lazy, singleton and wire.NoValue providers are
+// called as usual.
+//
+// Example:
+//
+//	func Record[T any](name string, fn func() (T, error)) func() (T, error) {
+//		return func() (T, error) {
+//			defer metrics.Time(name)()
+//			return fn()
+//		}
+//	}
+//
+//	var MySet = wire.Copy(ProdSet, Record[any])
+func Copy(set ProviderSet, wrapper interface{}) ProviderSet {
+	return ProviderSet{}
+}
+
+// An InterfaceCheck declares that an injector's result implements an
+// interface.
+type InterfaceCheck struct{}
+
+// Check declares that the value returned by injector implements the
+// interface iface points to. It is assigned to the blank identifier at
+// package level in a file with injectors. Wire reports an error if the
+// injector's result does not implement the interface, and writes a
+// compile-time assertion to the generated code, so that later changes to
+// the interface or to the type are caught when the package is built.
+//
+// Example:
+//
+//	func initServer() *Server {
+//		wire.Build(NewServer, NewStore)
+//		return nil
+//	}
+//
+//	var _ = wire.Check(new(http.Handler), initServer)
+//
+// generates:
+//
+//	var _ http.Handler = (*Server)(nil)
+func Check(iface interface{}, injector interface{}) InterfaceCheck {
+	return InterfaceCheck{}
+}
+
+// An InjectorOverride declares an injector built like another, but with some
+// of its providers replaced.
+type InjectorOverride struct{}
+
+// Override declares an injector that is identical to injector, except that
+// the entries of overrides take precedence over the providers injector is
+// built from. It is assigned to the blank identifier at package level in a
+// file with injectors. The generated function is named after injector with
+// a Test prefix, so that integration tests can swap out one component, such
+// as a database, while the rest of the graph stays the same.
+//
+// Example:
+//
+//	func initApp(cfg *Config) (*App, error) {
+//		panic(wire.Build(AppSet))
+//	}
+//
+//	var _ = wire.Override(initApp, wire.NewSet(NewTestDB))
+//
+// generates initApp and a TestInitApp function with the same signature
+// that calls NewTestDB wherever initApp calls the production provider of
+// its type.
+func Override(injector interface{}, overrides ProviderSet) InjectorOverride {
+	return InjectorOverride{}
+}
+
+// An InjectorTestKit declares a type that builds an injector's result in
+// tests with some of its providers replaced.
+type InjectorTestKit struct{}
+
+// TestKit declares a test kit for injector. It is assigned to the blank
+// identifier at package level in a _test.go file with the wireinject build
+// tag, and is generated into wire_gen_test.go by wire gen -tests. injector
+// may be declared in any file of the package.
+//
+// The kit is a struct named after injector with a TestKit suffix, with a
+// field for each type that overridable points to, such as DB for new(*DB).
+// Its Build method takes a *testing.T followed by injector's parameters and
+// returns injector's result. Build calls the same providers as injector,
+// except that the value of each field that is set is used in place of the
+// provider of its type. Errors fail the test, and cleanup functions are
+// registered with t.Cleanup. The overridable types must have a nil value and
+// be the results of provider functions that injector calls.
+//
+// Example:
+//
+//	var _ = wire.TestKit(initApp, new(*sql.DB), new(Cache))
+//
+// generates an InitAppTestKit type for table-driven tests:
+//
+//	app := (&InitAppTestKit{Cache: fakeCache{}}).Build(t, cfg)
+func TestKit(injector interface{}, overridable ...interface{}) InjectorTestKit {
+	return InjectorTestKit{}
+}
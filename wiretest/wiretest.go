@@ -0,0 +1,95 @@
+// Copyright 2026 The Wire Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wiretest checks from tests that generated Wire code is up to date.
+//
+// It is a separate package from github.com/google/wire so that programs
+// using Wire's markers do not depend on the code generator.
+package wiretest
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/wire/internal/wire"
+)
+
+var update = flag.Bool("wire.update", false, "rewrite out-of-date Wire generated files")
+
+// MustGenerate generates the injectors for the packages that match pkgs, as
+// wire gen would from the current directory, and fails t if any generated
+// file on disk is missing or out of date, or if generation fails. The
+// wire.yaml files of the packages and of the current directory supply
+// options as they do for wire gen.
+// If pkgs is empty, it defaults to ".".
+//
+// MustGenerate calls Wire's code generator directly, so the wire command
+// need not be installed. When the test binary is run with the -wire.update
+// flag, or with an -update flag defined by the test itself, MustGenerate
+// rewrites the out-of-date files instead of failing.
+//
+// A typical use is in TestMain or a dedicated test:
+//
+//	func TestWireUpToDate(t *testing.T) {
+//		wiretest.MustGenerate(t, ".")
+//	}
+func MustGenerate(t testing.TB, pkgs ...string) {
+	t.Helper()
+	if len(pkgs) == 0 {
+		pkgs = []string{"."}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &wire.GenerateOptions{ConfigFiles: true}
+	stale, errs := wire.CheckGenerated(context.Background(), wd, os.Environ(), pkgs, opts, updating())
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		t.Fatalf("wire: generate failed:\n%s", strings.Join(msgs, "\n"))
+	}
+	if len(stale) == 0 {
+		return
+	}
+	if updating() {
+		for _, path := range stale {
+			t.Logf("wire: wrote %s", path)
+		}
+		return
+	}
+	t.Fatalf("wire: generated files are out of date; run wire gen, or rerun the test with -wire.update:\n%s", strings.Join(stale, "\n"))
+}
+
+// updating reports whether out-of-date files should be rewritten.
+func updating() bool {
+	if *update {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	b, ok := getter.Get().(bool)
+	return ok && b
+}